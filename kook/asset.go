@@ -48,12 +48,34 @@ func (s *AssetService) UploadFileContent(fileName string, content []byte) (*Asse
 		return nil, fmt.Errorf("文件内容不能为空")
 	}
 
+	response, err := s.client.postMultipart("asset/create", nil, "file", fileName, content)
+	if err != nil {
+		return nil, err
+	}
+
+	var asset Asset
+	if err := json.Unmarshal(response.Data, &asset); err != nil {
+		return nil, fmt.Errorf("解析资源信息失败: %w", err)
+	}
+
+	s.client.Logger(LogSubsystemHTTP).Debug("文件上传成功", F("file", fileName), F("url", asset.URL))
+	return &asset, nil
+}
+
+// postMultipart 以multipart表单上传文件，fields 为附加的表单字段
+func (c *Client) postMultipart(endpoint string, fields map[string]string, fileField, fileName string, content []byte) (*Response, error) {
 	// 创建multipart表单
 	var buf bytes.Buffer
 	writer := multipart.NewWriter(&buf)
 
+	for key, value := range fields {
+		if err := writer.WriteField(key, value); err != nil {
+			return nil, fmt.Errorf("写入表单字段失败: %w", err)
+		}
+	}
+
 	// 添加文件字段
-	part, err := writer.CreateFormFile("file", fileName)
+	part, err := writer.CreateFormFile(fileField, fileName)
 	if err != nil {
 		return nil, fmt.Errorf("创建表单文件失败: %w", err)
 	}
@@ -66,23 +88,24 @@ func (s *AssetService) UploadFileContent(fileName string, content []byte) (*Asse
 	writer.Close()
 
 	// 构建请求
-	url := s.client.buildURL("asset/create")
-	req, err := http.NewRequest("POST", url, &buf)
+	url := c.buildURL(endpoint)
+	req, err := http.NewRequestWithContext(c.Context(), "POST", url, &buf)
 	if err != nil {
 		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
 
 	// 设置请求头
-	req.Header.Set("Authorization", fmt.Sprintf("%s %s", s.client.tokenType, s.client.token))
+	req.Header.Set("Authorization", fmt.Sprintf("%s %s", c.tokenType, c.token))
 	req.Header.Set("User-Agent", UserAgent)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	s.client.logger.Debugf("上传文件: %s", fileName)
+	logger := c.Logger(LogSubsystemHTTP).With(F("endpoint", endpoint), F("file", fileName))
+	logger.Debug("上传文件")
 
 	// 执行请求
-	resp, err := s.client.httpClient.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		s.client.logger.WithError(err).Errorf("上传文件失败")
+		logger.Error("上传文件失败", ErrField(err))
 		return nil, fmt.Errorf("上传文件失败: %w", err)
 	}
 	defer resp.Body.Close()
@@ -90,11 +113,11 @@ func (s *AssetService) UploadFileContent(fileName string, content []byte) (*Asse
 	// 读取响应
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		s.client.logger.WithError(err).Errorf("读取上传响应失败")
+		logger.Error("读取上传响应失败", ErrField(err))
 		return nil, fmt.Errorf("读取上传响应失败: %w", err)
 	}
 
-	s.client.logger.Debugf("文件上传响应: %s", string(respBody))
+	logger.Debug("文件上传响应", F("status", resp.StatusCode), F("body", string(respBody)))
 
 	// 解析响应
 	var response Response
@@ -108,17 +131,11 @@ func (s *AssetService) UploadFileContent(fileName string, content []byte) (*Asse
 			Code:    response.Code,
 			Message: response.Message,
 		}
-		s.client.logger.WithError(err).Errorf("文件上传API错误")
+		logger.Error("文件上传API错误", ErrField(err))
 		return nil, err
 	}
 
-	var asset Asset
-	if err := json.Unmarshal(response.Data, &asset); err != nil {
-		return nil, fmt.Errorf("解析资源信息失败: %w", err)
-	}
-
-	s.client.logger.Infof("文件上传成功: %s -> %s", fileName, asset.URL)
-	return &asset, nil
+	return &response, nil
 }
 
 // 数据结构定义
@@ -0,0 +1,210 @@
+package kook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// SystemEventAuditLog 审计日志事件，由 AuditLogWatcher 轮询审计日志生成，不来自网关
+//
+// 事件数据为 AuditLogEntry，可通过 Event.AuditLogEntry 解析。
+const SystemEventAuditLog = "audit_log"
+
+// Time 获取日志的创建时间
+func (e *AuditLogEntry) Time() time.Time {
+	return time.UnixMilli(e.CreatedAt)
+}
+
+// DecodeOptions 将日志选项解析到 v
+func (e *AuditLogEntry) DecodeOptions(v interface{}) error {
+	if len(e.Options) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(e.Options)
+	if err != nil {
+		return fmt.Errorf("序列化审计日志选项失败: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("解析审计日志选项失败: %w", err)
+	}
+	return nil
+}
+
+// AuditLogDetail 按操作类型解析后的审计日志
+//
+// 具体类型为 *AuditGuildUpdate、*AuditChannelChange、*AuditRoleChange、*AuditMemberAction、
+// *AuditMemberUpdate、*AuditMemberRoleUpdate、*AuditMessageDelete，未知操作类型为 *AuditUnknown。
+type AuditLogDetail interface {
+	// Entry 返回原始日志条目
+	Entry() *AuditLogEntry
+}
+
+// auditBase 解析后日志的公共部分
+type auditBase struct {
+	entry *AuditLogEntry
+}
+
+// Entry 返回原始日志条目
+func (b auditBase) Entry() *AuditLogEntry {
+	return b.entry
+}
+
+// AuditGuildUpdate 服务器更新（AuditLogActionGuildUpdate）
+type AuditGuildUpdate struct {
+	auditBase
+	Name   string `json:"name"`   // 服务器名称
+	Icon   string `json:"icon"`   // 服务器图标
+	Region string `json:"region"` // 服务器区域
+}
+
+// AuditChannelChange 频道创建、更新与删除（AuditLogActionChannelCreate/Update/Delete）
+type AuditChannelChange struct {
+	auditBase
+	ChannelID string `json:"channel_id"` // 频道ID，选项中没有时取日志的目标ID
+	Name      string `json:"name"`       // 频道名称
+	Type      int    `json:"type"`       // 频道类型
+	ParentID  string `json:"parent_id"`  // 所属分组ID
+}
+
+// AuditRoleChange 角色创建、更新与删除（AuditLogActionRoleCreate/Update/Delete）
+type AuditRoleChange struct {
+	auditBase
+	RoleID      int         `json:"role_id"`     // 角色ID，选项中没有时取日志的目标ID
+	Name        string      `json:"name"`        // 角色名称
+	Color       int         `json:"color"`       // 角色颜色
+	Permissions Permissions `json:"permissions"` // 角色权限
+}
+
+// AuditMemberAction 踢出、封禁与解封成员（AuditLogActionMemberKick/Ban/Unban）
+type AuditMemberAction struct {
+	auditBase
+	UserID     string `json:"-"`            // 被处理的用户ID，即日志的目标ID
+	DelMsgDays int    `json:"del_msg_days"` // 封禁时删除的消息天数
+}
+
+// AuditMemberUpdate 成员信息更新（AuditLogActionMemberUpdate）
+type AuditMemberUpdate struct {
+	auditBase
+	UserID   string `json:"-"`        // 被修改的用户ID，即日志的目标ID
+	Nickname string `json:"nickname"` // 修改后的昵称
+}
+
+// AuditMemberRoleUpdate 成员角色变更（AuditLogActionMemberRoleUpdate）
+type AuditMemberRoleUpdate struct {
+	auditBase
+	UserID       string `json:"-"`             // 被修改的用户ID，即日志的目标ID
+	AddedRoles   []int  `json:"added_roles"`   // 授予的角色
+	RemovedRoles []int  `json:"removed_roles"` // 移除的角色
+}
+
+// AuditMessageDelete 删除消息（AuditLogActionMessageDelete）
+type AuditMessageDelete struct {
+	auditBase
+	MsgID     string `json:"msg_id"`     // 消息ID，选项中没有时取日志的目标ID
+	ChannelID string `json:"channel_id"` // 消息所在频道ID
+	AuthorID  string `json:"author_id"`  // 消息发送者ID
+	Content   string `json:"content"`    // 消息内容
+}
+
+// AuditUnknown 未知的操作类型，选项可通过 Entry().DecodeOptions 自行解析
+type AuditUnknown struct {
+	auditBase
+}
+
+// Detail 按操作类型解析日志选项
+//
+// 选项中缺少的字段保持零值，未列出的字段仍可通过 Options 或 DecodeOptions 获取。
+func (e *AuditLogEntry) Detail() (AuditLogDetail, error) {
+	base := auditBase{entry: e}
+
+	switch e.ActionType {
+	case AuditLogActionGuildUpdate:
+		detail := &AuditGuildUpdate{auditBase: base}
+		return detail, e.DecodeOptions(detail)
+
+	case AuditLogActionChannelCreate, AuditLogActionChannelUpdate, AuditLogActionChannelDelete:
+		detail := &AuditChannelChange{auditBase: base}
+		if err := e.DecodeOptions(detail); err != nil {
+			return nil, err
+		}
+		if detail.ChannelID == "" {
+			detail.ChannelID = e.TargetID
+		}
+		return detail, nil
+
+	case AuditLogActionRoleCreate, AuditLogActionRoleUpdate, AuditLogActionRoleDelete:
+		detail := &AuditRoleChange{auditBase: base}
+		if err := e.DecodeOptions(detail); err != nil {
+			return nil, err
+		}
+		if detail.RoleID == 0 {
+			detail.RoleID, _ = strconv.Atoi(e.TargetID)
+		}
+		return detail, nil
+
+	case AuditLogActionMemberKick, AuditLogActionMemberBan, AuditLogActionMemberUnban:
+		detail := &AuditMemberAction{auditBase: base, UserID: e.TargetID}
+		return detail, e.DecodeOptions(detail)
+
+	case AuditLogActionMemberUpdate:
+		detail := &AuditMemberUpdate{auditBase: base, UserID: e.TargetID}
+		return detail, e.DecodeOptions(detail)
+
+	case AuditLogActionMemberRoleUpdate:
+		detail := &AuditMemberRoleUpdate{auditBase: base, UserID: e.TargetID}
+		return detail, e.DecodeOptions(detail)
+
+	case AuditLogActionMessageDelete:
+		detail := &AuditMessageDelete{auditBase: base}
+		if err := e.DecodeOptions(detail); err != nil {
+			return nil, err
+		}
+		if detail.MsgID == "" {
+			detail.MsgID = e.TargetID
+		}
+		return detail, nil
+	}
+
+	return &AuditUnknown{auditBase: base}, nil
+}
+
+// event 将日志条目包装为审计日志系统事件
+func (e *AuditLogEntry) event(guildID string) (*Event, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("序列化审计日志失败: %w", err)
+	}
+	extra, err := json.Marshal(SystemEvent{Type: SystemEventAuditLog, Body: body})
+	if err != nil {
+		return nil, fmt.Errorf("序列化审计日志失败: %w", err)
+	}
+
+	return &Event{
+		ChannelType:  "GROUP",
+		Type:         EventTypeSystem,
+		TargetID:     guildID,
+		AuthorID:     e.UserID,
+		MsgID:        e.ID,
+		MsgTimestamp: e.CreatedAt,
+		Extra:        json.RawMessage(extra),
+	}, nil
+}
+
+// AuditLogEntry 解析审计日志事件中的日志条目
+func (e *Event) AuditLogEntry() (*AuditLogEntry, error) {
+	system, err := e.SystemEvent()
+	if err != nil {
+		return nil, err
+	}
+	if system.Type != SystemEventAuditLog {
+		return nil, fmt.Errorf("系统事件 %s 不是审计日志事件", system.Type)
+	}
+
+	var entry AuditLogEntry
+	if err := system.DecodeBody(&entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
@@ -0,0 +1,290 @@
+package kook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AuditLogFilter 审计日志过滤条件，同一字段内满足任一值即可，不同字段需同时满足，空字段不过滤
+type AuditLogFilter struct {
+	Actions   []int    // 操作类型，AuditLogAction* 常量
+	UserIDs   []string // 操作者ID
+	TargetIDs []string // 目标ID
+}
+
+// Match 判断日志是否满足过滤条件
+func (f AuditLogFilter) Match(entry *AuditLogEntry) bool {
+	if len(f.Actions) > 0 && !containsInt(f.Actions, entry.ActionType) {
+		return false
+	}
+	if len(f.UserIDs) > 0 && !containsString(f.UserIDs, entry.UserID) {
+		return false
+	}
+	if len(f.TargetIDs) > 0 && !containsString(f.TargetIDs, entry.TargetID) {
+		return false
+	}
+	return true
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// auditCursor 服务器已处理到的位置
+type auditCursor struct {
+	lastID string
+	lastAt int64
+}
+
+// AuditLogWatcher 审计日志轮询器
+//
+// 定期拉取各服务器的审计日志，记录最后处理的日志ID，将新日志作为 EventTypeSystem 事件分发，
+// 事件子类型为 SystemEventAuditLog，可通过 Event.AuditLogEntry 解析。
+// 实现了 EventDispatcher，可像网关一样用 OnEvent 注册处理器或传给各模块的 Attach。
+// 首次轮询某个服务器时只记录当前位置，不分发历史日志；可用 Resume 从保存的位置继续。
+type AuditLogWatcher struct {
+	client   *Client
+	logger   Logger
+	interval time.Duration
+	pageSize int
+	maxPages int
+	filter   AuditLogFilter
+
+	mu         sync.RWMutex
+	handlers   map[int][]EventHandler
+	cursors    map[string]*auditCursor
+	streamSlot hubSlot
+}
+
+// AuditLogWatcherOption 审计日志轮询器配置选项
+type AuditLogWatcherOption func(*AuditLogWatcher)
+
+// WithAuditLogGuilds 设置要轮询的服务器
+func WithAuditLogGuilds(guildIDs ...string) AuditLogWatcherOption {
+	return func(w *AuditLogWatcher) {
+		for _, guildID := range guildIDs {
+			w.cursors[guildID] = &auditCursor{}
+		}
+	}
+}
+
+// WithAuditLogInterval 设置轮询间隔，默认1分钟
+func WithAuditLogInterval(interval time.Duration) AuditLogWatcherOption {
+	return func(w *AuditLogWatcher) {
+		w.interval = interval
+	}
+}
+
+// WithAuditLogFilter 设置过滤条件，只分发满足条件的日志
+func WithAuditLogFilter(filter AuditLogFilter) AuditLogWatcherOption {
+	return func(w *AuditLogWatcher) {
+		w.filter = filter
+	}
+}
+
+// WithAuditLogMaxPages 设置每次轮询最多拉取的页数，默认10页，每页50条
+//
+// 两次轮询之间的日志超过该数量时，更早的日志不会被分发。
+func WithAuditLogMaxPages(pages int) AuditLogWatcherOption {
+	return func(w *AuditLogWatcher) {
+		w.maxPages = pages
+	}
+}
+
+// NewAuditLogWatcher 创建审计日志轮询器
+func NewAuditLogWatcher(client *Client, opts ...AuditLogWatcherOption) *AuditLogWatcher {
+	w := &AuditLogWatcher{
+		client:   client,
+		logger:   client.Logger(LogSubsystemAuditLog),
+		interval: time.Minute,
+		pageSize: 50,
+		maxPages: 10,
+		handlers: make(map[int][]EventHandler),
+		cursors:  make(map[string]*auditCursor),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// OnEvent 注册事件处理器，审计日志事件的类型为 EventTypeSystem
+func (w *AuditLogWatcher) OnEvent(eventType int, handler EventHandler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.handlers[eventType] = append(w.handlers[eventType], handler)
+}
+
+// streamHub 实现 hubSource，事件流共用同一组处理器
+func (w *AuditLogWatcher) streamHub() *streamHub {
+	return w.streamSlot.get(w)
+}
+
+// Watch 开始轮询服务器，已在轮询时不改变其位置
+func (w *AuditLogWatcher) Watch(guildID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.cursors[guildID]; !ok {
+		w.cursors[guildID] = &auditCursor{}
+	}
+}
+
+// Unwatch 停止轮询服务器
+func (w *AuditLogWatcher) Unwatch(guildID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.cursors, guildID)
+}
+
+// Resume 从保存的日志ID继续轮询服务器，之后的日志都会被分发
+func (w *AuditLogWatcher) Resume(guildID, lastID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.cursors[guildID] = &auditCursor{lastID: lastID}
+}
+
+// LastID 获取服务器最后处理的日志ID，可保存后用 Resume 恢复
+func (w *AuditLogWatcher) LastID(guildID string) string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if cursor, ok := w.cursors[guildID]; ok {
+		return cursor.lastID
+	}
+	return ""
+}
+
+// Run 按间隔轮询直到 ctx 结束，轮询失败只记录日志
+func (w *AuditLogWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.Poll(); err != nil {
+			w.logger.Warn("轮询审计日志失败", ErrField(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll 立即轮询所有服务器一次，返回分发的事件数
+func (w *AuditLogWatcher) Poll() (int, error) {
+	w.mu.RLock()
+	guildIDs := make([]string, 0, len(w.cursors))
+	for guildID := range w.cursors {
+		guildIDs = append(guildIDs, guildID)
+	}
+	w.mu.RUnlock()
+
+	total := 0
+	var errs []error
+	for _, guildID := range guildIDs {
+		n, err := w.poll(guildID)
+		total += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("服务器 %s: %w", guildID, err))
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+// poll 拉取服务器上次位置之后的日志并按时间顺序分发
+func (w *AuditLogWatcher) poll(guildID string) (int, error) {
+	w.mu.RLock()
+	cursor, ok := w.cursors[guildID]
+	if !ok {
+		w.mu.RUnlock()
+		return 0, nil
+	}
+	last := *cursor
+	w.mu.RUnlock()
+
+	// 日志按时间倒序返回，遇到上次处理的日志或更早的日志即停止
+	var fresh []AuditLogEntry
+	reached := false
+	for page := 1; page <= w.maxPages && !reached; page++ {
+		result, err := w.client.Admin.GetAuditLog(guildID, "", "", 0, page, w.pageSize)
+		if err != nil {
+			return 0, err
+		}
+		for _, entry := range result.Items {
+			if entry.ID == last.lastID || (last.lastAt > 0 && entry.CreatedAt < last.lastAt) {
+				reached = true
+				break
+			}
+			fresh = append(fresh, entry)
+		}
+		// 首次轮询只需要最新的一条日志
+		if last.lastID == "" || page >= result.Meta.PageTotal {
+			break
+		}
+	}
+	if len(fresh) == 0 {
+		return 0, nil
+	}
+
+	w.mu.Lock()
+	if current, ok := w.cursors[guildID]; ok && *current == last {
+		current.lastID = fresh[0].ID
+		current.lastAt = fresh[0].CreatedAt
+	} else {
+		// 轮询期间位置被 Resume 或 Unwatch 修改
+		w.mu.Unlock()
+		return 0, nil
+	}
+	handlers := append([]EventHandler(nil), w.handlers[EventTypeSystem]...)
+	w.mu.Unlock()
+
+	if last.lastID == "" {
+		w.logger.Debug("记录审计日志起始位置", F("guild_id", guildID), F("last_id", fresh[0].ID))
+		return 0, nil
+	}
+	if !reached {
+		w.logger.Warn("新增审计日志超过轮询上限，更早的日志已跳过",
+			F("guild_id", guildID),
+			F("max_pages", w.maxPages),
+		)
+	}
+
+	dispatched := 0
+	for i := len(fresh) - 1; i >= 0; i-- {
+		entry := &fresh[i]
+		if !w.filter.Match(entry) {
+			continue
+		}
+		event, err := entry.event(guildID)
+		if err != nil {
+			w.logger.Error("构造审计日志事件失败", F("id", entry.ID), ErrField(err))
+			continue
+		}
+		dispatchEvent(w.client, w.logger, EventSourceAuditLog, handlers, event, nil)
+		dispatched++
+	}
+	return dispatched, nil
+}
@@ -0,0 +1,564 @@
+// Package automod 自动管理：按服务器配置的规则检查频道消息与成员加入，
+// 对违规者执行逐级升级的处罚（删除消息、私聊警告、禁言、踢出、加入黑名单），
+// 并将处理记录发送到日志频道。
+package automod
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"kook-go-sdk/kook"
+)
+
+// Action 处罚动作，可以组合
+type Action uint8
+
+const (
+	ActionDelete    Action = 1 << iota // 删除消息
+	ActionWarn                         // 私聊警告
+	ActionMute                         // 授予禁言角色，到期后移除
+	ActionKick                         // 踢出服务器
+	ActionBlacklist                    // 加入黑名单
+)
+
+var actionNames = []struct {
+	action Action
+	name   string
+}{
+	{ActionDelete, "删除消息"},
+	{ActionWarn, "警告"},
+	{ActionMute, "禁言"},
+	{ActionKick, "踢出"},
+	{ActionBlacklist, "加入黑名单"},
+}
+
+// Has 判断是否包含指定动作
+func (a Action) Has(action Action) bool {
+	return a&action == action
+}
+
+// String 返回动作名称
+func (a Action) String() string {
+	var names []string
+	for _, n := range actionNames {
+		if a.Has(n.action) {
+			names = append(names, n.name)
+		}
+	}
+	if len(names) == 0 {
+		return "无"
+	}
+	return strings.Join(names, "、")
+}
+
+// Step 升级处罚的一级
+type Step struct {
+	Strikes  int           // 累计违规次数达到该值时执行
+	Action   Action        // 处罚动作
+	Duration time.Duration // 禁言时长，为0时使用 Config.MuteDuration
+}
+
+// Config 单个服务器的自动管理配置
+type Config struct {
+	Rules []Rule // 消息检查规则，按顺序检查，命中第一个即停止
+
+	// Actions 每次违规都执行的动作，默认删除消息并私聊警告
+	Actions Action
+	// Escalation 按累计违规次数追加的处罚，取已达到的最高一级
+	Escalation []Step
+	// StrikeWindow 违规记录的保留时长，默认24小时
+	StrikeWindow time.Duration
+
+	MuteRoleID   int           // 禁言角色ID，使用 ActionMute 时必填
+	MuteDuration time.Duration // 默认禁言时长，默认10分钟
+	LogChannelID string        // 日志频道ID，为空时不发送审计记录
+	WarnMessage  string        // 私聊警告内容，其中的 %s 替换为违规原因，不作为格式化字符串解析
+
+	ExemptChannels []string // 不检查的频道
+	ExemptRoles    []int    // 不检查的角色
+	ExemptUsers    []string // 不检查的用户
+
+	Raid       *JoinRaid // 加入突袭检测，为 nil 时不检测
+	RaidAction Action    // 突袭期间对新成员执行的动作，默认踢出
+}
+
+// defaultWarnMessage 默认私聊警告内容
+const defaultWarnMessage = "你在服务器中发送的消息违反了规则：%s"
+
+// validate 检查配置并填充默认值
+func (c *Config) validate() error {
+	if c.Actions == 0 {
+		c.Actions = ActionDelete | ActionWarn
+	}
+	if c.StrikeWindow <= 0 {
+		c.StrikeWindow = 24 * time.Hour
+	}
+	if c.MuteDuration <= 0 {
+		c.MuteDuration = 10 * time.Minute
+	}
+	if c.WarnMessage == "" {
+		c.WarnMessage = defaultWarnMessage
+	}
+	if c.RaidAction == 0 {
+		c.RaidAction = ActionKick
+	}
+
+	uses := c.Actions | c.RaidAction
+	for _, step := range c.Escalation {
+		if step.Strikes <= 0 {
+			return fmt.Errorf("处罚等级的违规次数必须大于0")
+		}
+		uses |= step.Action
+	}
+	if uses.Has(ActionMute) && c.MuteRoleID == 0 {
+		return fmt.Errorf("使用禁言处罚时必须设置禁言角色")
+	}
+
+	sort.SliceStable(c.Escalation, func(i, j int) bool {
+		return c.Escalation[i].Strikes < c.Escalation[j].Strikes
+	})
+	return nil
+}
+
+// exempt 判断消息是否免检
+func (c *Config) exempt(msg *Message) bool {
+	for _, id := range c.ExemptChannels {
+		if id == msg.ChannelID {
+			return true
+		}
+	}
+	for _, id := range c.ExemptUsers {
+		if id == msg.AuthorID {
+			return true
+		}
+	}
+	for _, exempt := range c.ExemptRoles {
+		for _, role := range msg.AuthorRoles {
+			if role == exempt {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Violation 一次违规及其处理
+type Violation struct {
+	Rule     string        // 命中的规则
+	Reason   string        // 违规原因
+	Message  *Message      // 违规消息，加入突袭时为 nil
+	GuildID  string        // 服务器ID
+	UserID   string        // 违规用户ID
+	Strikes  int           // 含本次在内的累计违规次数
+	Action   Action        // 执行的处罚
+	Duration time.Duration // 禁言时长
+}
+
+// Muter 执行禁言
+//
+// 默认实现授予禁言角色并用定时器在到期后移除，进程重启后不会移除。
+type Muter interface {
+	Mute(guildID, userID string, roleID int, duration time.Duration, reason string) error
+}
+
+// roleMuter 基于定时器的禁言
+type roleMuter struct {
+	client *kook.Client
+	logger kook.Logger
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+// Mute 授予禁言角色，到期后移除；重复禁言时重新计时
+func (m *roleMuter) Mute(guildID, userID string, roleID int, duration time.Duration, reason string) error {
+	if _, err := m.client.Role.GrantRole(guildID, userID, roleID); err != nil {
+		return err
+	}
+
+	key := guildID + "/" + userID
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if timer, ok := m.timers[key]; ok {
+		timer.Stop()
+	}
+	m.timers[key] = time.AfterFunc(duration, func() {
+		m.mu.Lock()
+		delete(m.timers, key)
+		m.mu.Unlock()
+
+		if _, err := m.client.Role.RevokeRole(guildID, userID, roleID); err != nil {
+			m.logger.Error("解除禁言失败", kook.F("user_id", userID), kook.ErrField(err))
+		}
+	})
+	return nil
+}
+
+// Engine 自动管理引擎
+type Engine struct {
+	client *kook.Client
+	logger kook.Logger
+	muter  Muter
+	now    func() time.Time
+
+	mu        sync.Mutex
+	configs   map[string]*Config
+	strikes   map[string][]time.Time
+	lastSweep time.Time
+
+	onViolation []func(*Violation)
+}
+
+// Option 自动管理引擎配置选项
+type Option func(*Engine)
+
+// WithLogger 设置日志器
+func WithLogger(logger kook.Logger) Option {
+	return func(e *Engine) {
+		e.logger = logger
+	}
+}
+
+// WithMuter 设置禁言实现，例如可在重启后恢复的持久化实现
+func WithMuter(muter Muter) Option {
+	return func(e *Engine) {
+		e.muter = muter
+	}
+}
+
+// New 创建自动管理引擎
+func New(client *kook.Client, opts ...Option) *Engine {
+	e := &Engine{
+		client:  client,
+		now:     time.Now,
+		configs: make(map[string]*Config),
+		strikes: make(map[string][]time.Time),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.logger == nil {
+		e.logger = client.Logger("automod")
+	}
+	if e.muter == nil {
+		e.muter = &roleMuter{client: client, logger: e.logger, timers: make(map[string]*time.Timer)}
+	}
+	return e
+}
+
+// Muter 获取引擎使用的禁言实现
+func (e *Engine) Muter() Muter {
+	return e.muter
+}
+
+// Configure 设置服务器的自动管理配置，已有配置时替换
+func (e *Engine) Configure(guildID string, config Config) error {
+	if guildID == "" {
+		return fmt.Errorf("服务器ID不能为空")
+	}
+	if err := config.validate(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.configs[guildID] = &config
+	return nil
+}
+
+// Remove 移除服务器的自动管理配置
+func (e *Engine) Remove(guildID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.configs, guildID)
+}
+
+// OnViolation 注册违规处理完成后的回调
+func (e *Engine) OnViolation(handler func(v *Violation)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onViolation = append(e.onViolation, handler)
+}
+
+// Attach 在事件源上注册频道消息与成员加入事件处理器
+func (e *Engine) Attach(dispatcher kook.EventDispatcher) {
+	for _, eventType := range []int{
+		kook.EventTypeTextMessage,
+		kook.EventTypeImageMessage,
+		kook.EventTypeVideoMessage,
+		kook.EventTypeFileMessage,
+		kook.EventTypeAudioMessage,
+		kook.EventTypeKMDMessage,
+		kook.EventTypeCardMessage,
+		kook.EventTypeSystem,
+	} {
+		dispatcher.OnEvent(eventType, e.HandleEvent)
+	}
+}
+
+// HandleEvent 检查频道消息与成员加入事件
+func (e *Engine) HandleEvent(event *kook.Event) {
+	if event.IsSystemEvent() {
+		e.handleSystemEvent(event)
+		return
+	}
+	if event.ChannelType != "GROUP" {
+		return
+	}
+
+	msg, err := MessageFromEvent(event)
+	if err != nil {
+		e.logger.Warn("解析消息失败", kook.F("msg_id", event.MsgID), kook.ErrField(err))
+		return
+	}
+	e.CheckMessage(msg)
+}
+
+// handleSystemEvent 处理成员加入事件
+func (e *Engine) handleSystemEvent(event *kook.Event) {
+	system, err := event.SystemEvent()
+	if err != nil || system.Type != kook.SystemEventJoinedGuild {
+		return
+	}
+	var body kook.GuildMemberJoinedBody
+	if err := system.DecodeBody(&body); err != nil || body.UserID == "" {
+		return
+	}
+
+	guildID := event.GuildID()
+	config := e.config(guildID)
+	if config == nil || config.Raid == nil {
+		return
+	}
+
+	at := e.now()
+	if body.JoinedAt > 0 {
+		at = time.UnixMilli(body.JoinedAt)
+	}
+	reason, raid := config.Raid.Join(guildID, at)
+	if !raid {
+		return
+	}
+
+	e.enforce(config, &Violation{
+		Rule:     "join_raid",
+		Reason:   reason,
+		GuildID:  guildID,
+		UserID:   body.UserID,
+		Strikes:  e.strike(guildID, body.UserID, config.StrikeWindow),
+		Action:   config.RaidAction,
+		Duration: config.MuteDuration,
+	})
+}
+
+// CheckMessage 按服务器配置检查消息，违规时执行处罚并返回处理记录，未违规时返回 nil
+func (e *Engine) CheckMessage(msg *Message) *Violation {
+	if msg.AuthorBot || msg.AuthorID == "" {
+		return nil
+	}
+	config := e.config(msg.GuildID)
+	if config == nil || config.exempt(msg) {
+		return nil
+	}
+
+	for _, rule := range config.Rules {
+		reason, violated := rule.Check(msg)
+		if !violated {
+			continue
+		}
+
+		strikes := e.strike(msg.GuildID, msg.AuthorID, config.StrikeWindow)
+		v := &Violation{
+			Rule:     rule.Name(),
+			Reason:   reason,
+			Message:  msg,
+			GuildID:  msg.GuildID,
+			UserID:   msg.AuthorID,
+			Strikes:  strikes,
+			Action:   config.Actions,
+			Duration: config.MuteDuration,
+		}
+		// 取已达到的最高一级处罚
+		for _, step := range config.Escalation {
+			if strikes < step.Strikes {
+				break
+			}
+			v.Action = config.Actions | step.Action
+			if step.Duration > 0 {
+				v.Duration = step.Duration
+			} else {
+				v.Duration = config.MuteDuration
+			}
+		}
+
+		e.enforce(config, v)
+		return v
+	}
+	return nil
+}
+
+// config 获取服务器配置
+func (e *Engine) config(guildID string) *Config {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.configs[guildID]
+}
+
+// strike 记录一次违规，返回窗口内的累计次数
+func (e *Engine) strike(guildID, userID string, window time.Duration) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	if now.Sub(e.lastSweep) > window {
+		e.sweepStrikes(now)
+	}
+
+	key := guildID + "/" + userID
+	recent := e.strikes[key][:0]
+	for _, at := range e.strikes[key] {
+		if now.Sub(at) < window {
+			recent = append(recent, at)
+		}
+	}
+	recent = append(recent, now)
+	e.strikes[key] = recent
+	return len(recent)
+}
+
+// sweepStrikes 定期清理窗口内不再违规的用户，调用方需持有锁
+func (e *Engine) sweepStrikes(now time.Time) {
+	for key, times := range e.strikes {
+		guildID, _, _ := strings.Cut(key, "/")
+		config := e.configs[guildID]
+		if len(times) == 0 || config == nil || now.Sub(times[len(times)-1]) >= config.StrikeWindow {
+			delete(e.strikes, key)
+		}
+	}
+	e.lastSweep = now
+}
+
+// Strikes 获取用户在窗口内的累计违规次数
+func (e *Engine) Strikes(guildID, userID string) int {
+	config := e.config(guildID)
+	if config == nil {
+		return 0
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	count := 0
+	for _, at := range e.strikes[guildID+"/"+userID] {
+		if e.now().Sub(at) < config.StrikeWindow {
+			count++
+		}
+	}
+	return count
+}
+
+// ResetStrikes 清除用户的违规记录
+func (e *Engine) ResetStrikes(guildID, userID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.strikes, guildID+"/"+userID)
+}
+
+// enforce 执行处罚并记录审计日志，单个动作失败不影响其余动作
+func (e *Engine) enforce(config *Config, v *Violation) {
+	fields := []kook.Field{
+		kook.F("guild_id", v.GuildID),
+		kook.F("user_id", v.UserID),
+		kook.F("rule", v.Rule),
+		kook.F("strikes", v.Strikes),
+	}
+	e.logger.Info("自动管理处罚", append(fields, kook.F("action", v.Action.String()))...)
+
+	var failed []string
+	fail := func(action Action, err error) {
+		failed = append(failed, action.String())
+		e.logger.Error("执行处罚失败", append(fields, kook.F("action", action.String()), kook.ErrField(err))...)
+	}
+
+	if v.Action.Has(ActionDelete) && v.Message != nil {
+		if err := e.client.Message.DeleteMessage(v.Message.MsgID); err != nil {
+			fail(ActionDelete, err)
+		}
+	}
+	if v.Action.Has(ActionWarn) {
+		_, err := e.client.DirectMessage.SendMessage(kook.SendDirectMessageParams{
+			TargetID: v.UserID,
+			Content:  strings.ReplaceAll(config.WarnMessage, "%s", v.Reason),
+		})
+		if err != nil {
+			fail(ActionWarn, err)
+		}
+	}
+	if v.Action.Has(ActionMute) {
+		if err := e.muter.Mute(v.GuildID, v.UserID, config.MuteRoleID, v.Duration, v.Reason); err != nil {
+			fail(ActionMute, err)
+		}
+	}
+	// 加入黑名单会同时移出服务器，无需再踢出
+	if v.Action.Has(ActionBlacklist) {
+		if err := e.client.Blacklist.CreateBlacklistUser(v.GuildID, v.UserID, "自动管理："+v.Reason, 0); err != nil {
+			fail(ActionBlacklist, err)
+		}
+	} else if v.Action.Has(ActionKick) {
+		if err := e.client.Guild.KickGuildMember(v.GuildID, v.UserID); err != nil {
+			fail(ActionKick, err)
+		}
+	}
+
+	if config.LogChannelID != "" {
+		_, err := e.client.Message.SendMessage(kook.SendMessageParams{
+			TargetID: config.LogChannelID,
+			Content:  auditContent(v, failed),
+			MsgType:  kook.MessageTypeKMD,
+		})
+		if err != nil {
+			e.logger.Error("发送审计记录失败", append(fields, kook.ErrField(err))...)
+		}
+	}
+
+	e.mu.Lock()
+	handlers := append([]func(*Violation){}, e.onViolation...)
+	e.mu.Unlock()
+	for _, handler := range handlers {
+		handler(v)
+	}
+}
+
+// auditContent 生成日志频道中的审计记录
+func auditContent(v *Violation, failed []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**自动管理** | 规则 `%s`\n", v.Rule)
+	fmt.Fprintf(&b, "用户：(met)%s(met)", v.UserID)
+	if v.Message != nil {
+		fmt.Fprintf(&b, "　频道：(chn)%s(chn)", v.Message.ChannelID)
+	}
+	fmt.Fprintf(&b, "\n原因：%s\n", v.Reason)
+
+	action := v.Action.String()
+	if v.Action.Has(ActionMute) {
+		action += fmt.Sprintf("（禁言 %s）", v.Duration)
+	}
+	fmt.Fprintf(&b, "处理：%s\n累计违规：%d 次", action, v.Strikes)
+	if len(failed) > 0 {
+		fmt.Fprintf(&b, "\n执行失败：%s", strings.Join(failed, "、"))
+	}
+
+	if v.Message != nil && v.Message.Content != "" {
+		content := []rune(v.Message.Content)
+		if len(content) > 200 {
+			content = append(content[:200], []rune("…")...)
+		}
+		// 放入代码块，避免原消息中的提及与格式生效
+		fmt.Fprintf(&b, "\n```\n%s\n```", strings.ReplaceAll(string(content), "`", "'"))
+	}
+	return b.String()
+}
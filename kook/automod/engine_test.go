@@ -0,0 +1,31 @@
+package automod
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kook-go-sdk/kook"
+)
+
+func TestEngineSweepsExpiredStrikes(t *testing.T) {
+	client := kook.NewClient("x", kook.WithLogLevel(kook.LogLevelOff))
+	e := New(client)
+	require.NoError(t, e.Configure("g", Config{StrikeWindow: time.Hour}))
+
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+
+	for _, userID := range []string{"u1", "u2", "u3"} {
+		e.strike("g", userID, time.Hour)
+	}
+	assert.Len(t, e.strikes, 3)
+
+	// 超过窗口后再有违规时清理不再违规的用户
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, 1, e.strike("g", "u4", time.Hour))
+	assert.Len(t, e.strikes, 1)
+	assert.Equal(t, 0, e.Strikes("g", "u1"))
+}
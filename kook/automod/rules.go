@@ -0,0 +1,334 @@
+package automod
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"kook-go-sdk/kook"
+)
+
+// Message 待检查的频道消息
+type Message struct {
+	MsgID        string           // 消息ID
+	GuildID      string           // 服务器ID
+	ChannelID    string           // 频道ID
+	AuthorID     string           // 发送者ID
+	AuthorRoles  []int            // 发送者的角色
+	AuthorBot    bool             // 发送者是否为机器人
+	Type         int              // 消息类型
+	Content      string           // 消息内容
+	Mention      []string         // 提及的用户ID
+	MentionRoles []int            // 提及的角色ID
+	MentionAll   bool             // 是否提及全体成员
+	MentionHere  bool             // 是否提及在线成员
+	Attachment   *kook.Attachment // 附件
+	Time         time.Time        // 发送时间
+}
+
+// MessageFromEvent 从频道消息事件构造待检查的消息
+func MessageFromEvent(event *kook.Event) (*Message, error) {
+	if event.IsSystemEvent() || event.ChannelType != "GROUP" {
+		return nil, fmt.Errorf("不是频道消息")
+	}
+	extra, err := event.MessageExtra()
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &Message{
+		MsgID:        event.MsgID,
+		GuildID:      extra.GuildID,
+		ChannelID:    event.TargetID,
+		AuthorID:     event.AuthorID,
+		AuthorRoles:  extra.Author.Roles,
+		AuthorBot:    extra.Author.Bot,
+		Type:         event.Type,
+		Content:      event.Content,
+		Mention:      extra.Mention,
+		MentionRoles: extra.MentionRoles,
+		MentionAll:   extra.MentionAll,
+		MentionHere:  extra.MentionHere,
+		Attachment:   extra.Attachments,
+		Time:         time.Now(),
+	}
+	if event.MsgTimestamp > 0 {
+		msg.Time = time.UnixMilli(event.MsgTimestamp)
+	}
+	return msg, nil
+}
+
+// Rule 消息检查规则
+//
+// 规则可能被多个协程同时调用，有状态的规则需要自行加锁。
+type Rule interface {
+	// Name 规则名称，用于日志与审计记录
+	Name() string
+	// Check 检查消息，违规时返回原因与 true
+	Check(msg *Message) (reason string, violated bool)
+}
+
+// funcRule 函数形式的规则
+type funcRule struct {
+	name  string
+	check func(*Message) (string, bool)
+}
+
+func (r *funcRule) Name() string                      { return r.name }
+func (r *funcRule) Check(msg *Message) (string, bool) { return r.check(msg) }
+
+// NewRule 用函数创建规则
+func NewRule(name string, check func(msg *Message) (reason string, violated bool)) Rule {
+	return &funcRule{name: name, check: check}
+}
+
+// ExceptChannels 规则在指定频道中不生效
+func ExceptChannels(rule Rule, channelIDs ...string) Rule {
+	exempt := make(map[string]bool, len(channelIDs))
+	for _, id := range channelIDs {
+		exempt[id] = true
+	}
+	return NewRule(rule.Name(), func(msg *Message) (string, bool) {
+		if exempt[msg.ChannelID] {
+			return "", false
+		}
+		return rule.Check(msg)
+	})
+}
+
+// BannedWords 消息包含违禁词时违规，不区分大小写
+func BannedWords(words ...string) Rule {
+	lowered := make([]string, 0, len(words))
+	for _, word := range words {
+		if word != "" {
+			lowered = append(lowered, strings.ToLower(word))
+		}
+	}
+	return NewRule("banned_words", func(msg *Message) (string, bool) {
+		content := strings.ToLower(msg.Content)
+		for _, word := range lowered {
+			if strings.Contains(content, word) {
+				return fmt.Sprintf("包含违禁词 %q", word), true
+			}
+		}
+		return "", false
+	})
+}
+
+// BannedPatterns 消息匹配任一正则表达式时违规
+func BannedPatterns(patterns ...string) (Rule, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("违禁表达式 %q 无效: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return NewRule("banned_patterns", func(msg *Message) (string, bool) {
+		for _, re := range compiled {
+			if match := re.FindString(msg.Content); match != "" {
+				return fmt.Sprintf("匹配违禁内容 %q", match), true
+			}
+		}
+		return "", false
+	}), nil
+}
+
+// inviteLink 匹配KOOK与常见平台的邀请链接，捕获邀请码
+var inviteLink = regexp.MustCompile(`(?i)(?:https?://)?(?:www\.)?(?:kook\.top|kaihei\.co|kookapp\.cn/app/invite|discord\.gg|discord\.com/invite)/([A-Za-z0-9_-]+)`)
+
+// InviteLinks 消息包含邀请链接时违规，allowedCodes 为允许的邀请码（如本服务器的邀请）
+func InviteLinks(allowedCodes ...string) Rule {
+	allowed := make(map[string]bool, len(allowedCodes))
+	for _, code := range allowedCodes {
+		allowed[code] = true
+	}
+	return NewRule("invite_links", func(msg *Message) (string, bool) {
+		for _, match := range inviteLink.FindAllStringSubmatch(msg.Content, -1) {
+			if !allowed[match[1]] {
+				return fmt.Sprintf("包含邀请链接 %s", match[0]), true
+			}
+		}
+		return "", false
+	})
+}
+
+// MentionSpam 单条消息提及的用户与角色超过 max 个，或提及全体/在线成员时违规
+//
+// allowEveryone 为 true 时不检查全体/在线成员提及。
+func MentionSpam(max int, allowEveryone bool) Rule {
+	return NewRule("mention_spam", func(msg *Message) (string, bool) {
+		if !allowEveryone && (msg.MentionAll || msg.MentionHere) {
+			return "提及全体成员", true
+		}
+		if count := len(msg.Mention) + len(msg.MentionRoles); count > max {
+			return fmt.Sprintf("提及 %d 个用户或角色，超过上限 %d", count, max), true
+		}
+		return "", false
+	})
+}
+
+// CapsRatio 字母不少于 minLetters 个且大写比例超过 ratio 时违规
+func CapsRatio(ratio float64, minLetters int) Rule {
+	return NewRule("caps_ratio", func(msg *Message) (string, bool) {
+		letters, upper := 0, 0
+		for _, r := range msg.Content {
+			if !unicode.IsLetter(r) || !(unicode.IsUpper(r) || unicode.IsLower(r)) {
+				continue
+			}
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+		if letters < minLetters || letters == 0 {
+			return "", false
+		}
+		if actual := float64(upper) / float64(letters); actual > ratio {
+			return fmt.Sprintf("大写字母占 %.0f%%", actual*100), true
+		}
+		return "", false
+	})
+}
+
+// BlockedAttachments 附件扩展名在列表中时违规，如 ".exe"、".bat"，不区分大小写
+func BlockedAttachments(extensions ...string) Rule {
+	blocked := make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		blocked[ext] = true
+	}
+	return NewRule("blocked_attachments", func(msg *Message) (string, bool) {
+		if msg.Attachment == nil {
+			return "", false
+		}
+		name := msg.Attachment.Name
+		if name == "" {
+			name = msg.Attachment.URL
+		}
+		if ext := strings.ToLower(path.Ext(name)); blocked[ext] {
+			return fmt.Sprintf("附件类型 %s 不允许", ext), true
+		}
+		return "", false
+	})
+}
+
+// repeatedRule 重复消息规则
+type repeatedRule struct {
+	count  int
+	window time.Duration
+
+	mu        sync.Mutex
+	history   map[string][]repeatedEntry
+	lastSweep time.Time
+}
+
+type repeatedEntry struct {
+	content string
+	at      time.Time
+}
+
+// RepeatedMessages 同一用户在 window 内于同一服务器发送 count 条相同内容时违规
+func RepeatedMessages(count int, window time.Duration) Rule {
+	return &repeatedRule{
+		count:   count,
+		window:  window,
+		history: make(map[string][]repeatedEntry),
+	}
+}
+
+// Name 规则名称
+func (r *repeatedRule) Name() string {
+	return "repeated_messages"
+}
+
+// Check 记录消息并检查重复次数
+func (r *repeatedRule) Check(msg *Message) (string, bool) {
+	content := strings.ToLower(strings.TrimSpace(msg.Content))
+	if content == "" {
+		return "", false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := msg.Time.Add(-r.window)
+	if msg.Time.Sub(r.lastSweep) > r.window {
+		// 定期清理不再发言的用户
+		for key, entries := range r.history {
+			if len(entries) == 0 || entries[len(entries)-1].at.Before(cutoff) {
+				delete(r.history, key)
+			}
+		}
+		r.lastSweep = msg.Time
+	}
+
+	key := msg.GuildID + "/" + msg.AuthorID
+	entries := r.history[key][:0]
+	same := 1
+	for _, entry := range r.history[key] {
+		if entry.at.Before(cutoff) {
+			continue
+		}
+		entries = append(entries, entry)
+		if entry.content == content {
+			same++
+		}
+	}
+	r.history[key] = append(entries, repeatedEntry{content: content, at: msg.Time})
+
+	if same >= r.count {
+		return fmt.Sprintf("%s 内重复发送相同内容 %d 次", r.window, same), true
+	}
+	return "", false
+}
+
+// JoinRaid 加入突袭检测：同一服务器在 window 内有 joins 个及以上成员加入时视为突袭
+//
+// KOOK 不提供账号注册时间，突袭期间加入的成员都按新账号处理。
+// 突袭状态在最后一次加入后持续 window 时长。
+type JoinRaid struct {
+	joins  int
+	window time.Duration
+
+	mu     sync.Mutex
+	recent map[string][]time.Time
+}
+
+// NewJoinRaid 创建加入突袭检测
+func NewJoinRaid(joins int, window time.Duration) *JoinRaid {
+	return &JoinRaid{
+		joins:  joins,
+		window: window,
+		recent: make(map[string][]time.Time),
+	}
+}
+
+// Join 记录一次加入，处于突袭状态时返回原因与 true
+func (r *JoinRaid) Join(guildID string, at time.Time) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := at.Add(-r.window)
+	times := r.recent[guildID][:0]
+	for _, t := range r.recent[guildID] {
+		if !t.Before(cutoff) {
+			times = append(times, t)
+		}
+	}
+	times = append(times, at)
+	r.recent[guildID] = times
+
+	if len(times) >= r.joins {
+		return fmt.Sprintf("%s 内有 %d 名成员加入，疑似突袭", r.window, len(times)), true
+	}
+	return "", false
+}
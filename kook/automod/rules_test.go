@@ -0,0 +1,145 @@
+package automod
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"kook-go-sdk/kook"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     Rule
+		msg      Message
+		violated bool
+	}{
+		{"违禁词", BannedWords("spam"), Message{Content: "buy SPAM now"}, true},
+		{"违禁词不区分大小写", BannedWords("SPAM"), Message{Content: "spam"}, true},
+		{"没有违禁词", BannedWords("spam", ""), Message{Content: "hello"}, false},
+		{"邀请链接", InviteLinks(), Message{Content: "来玩 https://kook.top/abc123"}, true},
+		{"其他平台邀请链接", InviteLinks(), Message{Content: "discord.gg/xyz"}, true},
+		{"允许的邀请码", InviteLinks("abc123"), Message{Content: "kook.top/abc123"}, false},
+		{"允许的邀请码之外还有其他邀请", InviteLinks("abc123"), Message{Content: "kook.top/abc123 kook.top/other"}, true},
+		{"没有邀请链接", InviteLinks(), Message{Content: "https://www.kookapp.cn"}, false},
+		{"提及过多", MentionSpam(2, true), Message{Mention: []string{"a", "b"}, MentionRoles: []int{1}}, true},
+		{"提及未超过上限", MentionSpam(3, true), Message{Mention: []string{"a", "b"}, MentionRoles: []int{1}}, false},
+		{"提及全体成员", MentionSpam(5, false), Message{MentionAll: true}, true},
+		{"提及在线成员", MentionSpam(5, false), Message{MentionHere: true}, true},
+		{"允许提及全体成员", MentionSpam(5, true), Message{MentionAll: true}, false},
+		{"大写比例过高", CapsRatio(0.7, 5), Message{Content: "HELLO WORLD"}, true},
+		{"大写比例正常", CapsRatio(0.7, 5), Message{Content: "Hello World"}, false},
+		{"字母不足", CapsRatio(0.7, 5), Message{Content: "OK!"}, false},
+		{"只统计有大小写的字母", CapsRatio(0.7, 5), Message{Content: "ABCDE 你好世界你好世界"}, true},
+		{"禁止的附件", BlockedAttachments("exe"), Message{Attachment: &kook.Attachment{Name: "setup.EXE"}}, true},
+		{"从链接识别附件类型", BlockedAttachments(".bat"), Message{Attachment: &kook.Attachment{URL: "https://img.kookapp.cn/a.bat"}}, true},
+		{"允许的附件", BlockedAttachments(".exe"), Message{Attachment: &kook.Attachment{Name: "a.png"}}, false},
+		{"没有附件", BlockedAttachments(".exe"), Message{Content: "a.exe"}, false},
+		{"例外频道", ExceptChannels(BannedWords("spam"), "c1"), Message{ChannelID: "c1", Content: "spam"}, false},
+		{"非例外频道", ExceptChannels(BannedWords("spam"), "c1"), Message{ChannelID: "c2", Content: "spam"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, violated := tt.rule.Check(&tt.msg)
+			assert.Equal(t, tt.violated, violated)
+			assert.Equal(t, tt.violated, reason != "")
+		})
+	}
+}
+
+func TestBannedPatterns(t *testing.T) {
+	_, err := BannedPatterns("(")
+	assert.Error(t, err)
+
+	rule, err := BannedPatterns(`\d{11}`)
+	assert.NoError(t, err)
+	reason, violated := rule.Check(&Message{Content: "电话 13800138000"})
+	assert.True(t, violated)
+	assert.Contains(t, reason, "13800138000")
+}
+
+func TestRepeatedMessages(t *testing.T) {
+	start := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	msg := func(guildID, authorID, content string, offset time.Duration) *Message {
+		return &Message{GuildID: guildID, AuthorID: authorID, Content: content, Time: start.Add(offset)}
+	}
+
+	tests := []struct {
+		name     string
+		msgs     []*Message
+		violated bool
+	}{
+		{"窗口内重复", []*Message{
+			msg("g", "u", "hi", 0), msg("g", "u", " HI ", time.Second), msg("g", "u", "hi", 2*time.Second),
+		}, true},
+		{"超出窗口", []*Message{
+			msg("g", "u", "hi", 0), msg("g", "u", "hi", time.Second), msg("g", "u", "hi", 11*time.Second),
+		}, false},
+		{"内容不同", []*Message{
+			msg("g", "u", "a", 0), msg("g", "u", "b", time.Second), msg("g", "u", "a", 2*time.Second),
+		}, false},
+		{"不同用户分别计数", []*Message{
+			msg("g", "u1", "hi", 0), msg("g", "u2", "hi", time.Second), msg("g", "u1", "hi", 2*time.Second),
+		}, false},
+		{"不同服务器分别计数", []*Message{
+			msg("g1", "u", "hi", 0), msg("g2", "u", "hi", time.Second), msg("g1", "u", "hi", 2*time.Second),
+		}, false},
+		{"空消息不计数", []*Message{
+			msg("g", "u", " ", 0), msg("g", "u", "", time.Second), msg("g", "u", "  ", 2*time.Second),
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := RepeatedMessages(3, 10*time.Second)
+			violated := false
+			for _, m := range tt.msgs {
+				_, violated = rule.Check(m)
+			}
+			assert.Equal(t, tt.violated, violated)
+		})
+	}
+}
+
+func TestRepeatedMessagesSweepsIdleUsers(t *testing.T) {
+	rule := RepeatedMessages(3, time.Second).(*repeatedRule)
+	start := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	rule.Check(&Message{GuildID: "g", AuthorID: "u1", Content: "hi", Time: start})
+	rule.Check(&Message{GuildID: "g", AuthorID: "u2", Content: "hi", Time: start.Add(time.Minute)})
+	assert.Len(t, rule.history, 1)
+}
+
+func TestJoinRaid(t *testing.T) {
+	start := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		joins   []time.Duration
+		guildID []string
+		raid    bool
+	}{
+		{"窗口内达到阈值", []time.Duration{0, time.Second, 2 * time.Second}, nil, true},
+		{"未达到阈值", []time.Duration{0, time.Second}, nil, false},
+		{"超出窗口", []time.Duration{0, 5 * time.Second, 11 * time.Second}, nil, false},
+		{"不同服务器分别计数", []time.Duration{0, time.Second, 2 * time.Second}, []string{"g1", "g2", "g1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raid := NewJoinRaid(3, 10*time.Second)
+			var violated bool
+			for i, offset := range tt.joins {
+				guildID := "g"
+				if tt.guildID != nil {
+					guildID = tt.guildID[i]
+				}
+				_, violated = raid.Join(guildID, start.Add(offset))
+			}
+			assert.Equal(t, tt.raid, violated)
+		})
+	}
+}
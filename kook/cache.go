@@ -0,0 +1,583 @@
+package kook
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// CachePolicy 状态缓存策略
+type CachePolicy struct {
+	Guilds             bool          // 是否缓存服务器信息
+	Channels           bool          // 是否缓存频道
+	Roles              bool          // 是否缓存角色
+	Members            bool          // 是否缓存成员
+	MaxMembersPerGuild int           // 每个服务器最多缓存的成员数，0表示不限制
+	TTL                time.Duration // 缓存有效期，0表示永不过期，过期的缓存在写入时定期清除
+}
+
+// DefaultCachePolicy 默认缓存策略：缓存服务器、频道和角色，不缓存成员
+func DefaultCachePolicy() CachePolicy {
+	return CachePolicy{
+		Guilds:   true,
+		Channels: true,
+		Roles:    true,
+	}
+}
+
+// StateCache 服务器状态缓存
+//
+// 通过 Prime 从 REST 列表接口预热，再通过 Attach 注册到网关或 Webhook，
+// 由频道、成员、角色和服务器相关的系统事件保持最新。
+// 所有查询返回的都是副本，修改返回值不会影响缓存。
+// 设置了 TTL 时，写入缓存的间隔超过 TTL 会顺带清除所有过期的缓存，也可调用 Sweep 立即清除。
+type StateCache struct {
+	client *Client
+	policy CachePolicy
+
+	mu        sync.RWMutex
+	lastSweep time.Time
+	guilds    map[string]cachedGuild
+	channels  map[string]cachedChannel
+	roles     map[string]*cachedRoles
+	members   map[string]map[string]cachedMember
+}
+
+type cachedGuild struct {
+	guild    Guild
+	storedAt time.Time
+}
+
+type cachedChannel struct {
+	channel  Channel
+	storedAt time.Time
+}
+
+// cachedRoles 服务器的角色集合，整体写入、整体过期
+//
+// 只由角色事件写入的集合不完整，只能用于按ID查询，GuildRoles 与 GetRoles 不会使用。
+type cachedRoles struct {
+	roles    map[int]GuildRole
+	complete bool
+	storedAt time.Time
+}
+
+type cachedMember struct {
+	member   GuildMember
+	storedAt time.Time
+}
+
+// NewStateCache 创建状态缓存
+func NewStateCache(client *Client, policy CachePolicy) *StateCache {
+	return &StateCache{
+		client:   client,
+		policy:   policy,
+		guilds:   make(map[string]cachedGuild),
+		channels: make(map[string]cachedChannel),
+		roles:    make(map[string]*cachedRoles),
+		members:  make(map[string]map[string]cachedMember),
+	}
+}
+
+// Attach 注册系统事件处理器，使缓存随事件更新
+func (c *StateCache) Attach(dispatcher EventDispatcher) {
+	dispatcher.OnEvent(EventTypeSystem, c.HandleEvent)
+}
+
+// Prime 从 REST 接口加载指定服务器的数据
+func (c *StateCache) Prime(guildID string) error {
+	if c.policy.Guilds {
+		guild, err := c.client.Guild.GetGuildInfo(guildID)
+		if err != nil {
+			return err
+		}
+		c.storeGuild(*guild)
+	}
+
+	if c.policy.Channels {
+		for page := 1; ; page++ {
+			result, err := c.client.Channel.GetChannelList(guildID, page, 50, "")
+			if err != nil {
+				return err
+			}
+			for _, channel := range result.Items {
+				if channel.GuildID == "" {
+					channel.GuildID = guildID
+				}
+				c.storeChannel(channel)
+			}
+			if page >= result.Meta.PageTotal {
+				break
+			}
+		}
+	}
+
+	if c.policy.Roles {
+		roles, err := c.fetchRoles(guildID)
+		if err != nil {
+			return err
+		}
+		c.storeRoles(guildID, roles)
+	}
+
+	if c.policy.Members {
+		loaded := 0
+		for page := 1; ; page++ {
+			result, err := c.client.Guild.GetGuildMembers(guildID, page, 50, "")
+			if err != nil {
+				return err
+			}
+			for _, member := range result.Items {
+				c.storeMember(guildID, member)
+				loaded++
+			}
+			if page >= result.Meta.PageTotal {
+				break
+			}
+			if c.policy.MaxMembersPerGuild > 0 && loaded >= c.policy.MaxMembersPerGuild {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// HandleEvent 根据系统事件更新缓存
+func (c *StateCache) HandleEvent(event *Event) {
+	sys, err := event.SystemEvent()
+	if err != nil {
+		return
+	}
+
+	guildID := event.TargetID
+
+	switch sys.Type {
+	case SystemEventAddedChannel, SystemEventUpdatedChannel:
+		var channel Channel
+		if sys.DecodeBody(&channel) == nil && channel.ID != "" {
+			if channel.GuildID == "" {
+				channel.GuildID = guildID
+			}
+			c.storeChannel(channel)
+		}
+
+	case SystemEventDeletedChannel:
+		var body DeletedChannelBody
+		if sys.DecodeBody(&body) == nil {
+			c.mu.Lock()
+			delete(c.channels, body.ID)
+			c.mu.Unlock()
+		}
+
+	case SystemEventAddedRole, SystemEventUpdatedRole:
+		var role GuildRole
+		if sys.DecodeBody(&role) == nil {
+			c.storeRole(guildID, role)
+		}
+
+	case SystemEventDeletedRole:
+		var role GuildRole
+		if sys.DecodeBody(&role) == nil {
+			c.mu.Lock()
+			if set, ok := c.roles[guildID]; ok {
+				delete(set.roles, role.RoleID)
+			}
+			c.mu.Unlock()
+		}
+
+	case SystemEventJoinedGuild:
+		var body GuildMemberJoinedBody
+		if sys.DecodeBody(&body) == nil {
+			// 事件只携带用户ID，完整信息需通过 GetMember 获取
+			c.storeMember(guildID, GuildMember{ID: body.UserID, JoinedAt: body.JoinedAt})
+		}
+
+	case SystemEventExitedGuild:
+		var body GuildMemberExitedBody
+		if sys.DecodeBody(&body) == nil {
+			c.mu.Lock()
+			delete(c.members[guildID], body.UserID)
+			c.mu.Unlock()
+		}
+
+	case SystemEventUpdatedGuildMember:
+		var body GuildMemberUpdatedBody
+		if sys.DecodeBody(&body) == nil {
+			c.mu.Lock()
+			if entry, ok := c.members[guildID][body.UserID]; ok {
+				entry.member.Nickname = body.Nickname
+				entry.storedAt = time.Now()
+				c.members[guildID][body.UserID] = entry
+			}
+			c.mu.Unlock()
+		}
+
+	case SystemEventUpdatedGuild:
+		var guild Guild
+		if sys.DecodeBody(&guild) == nil && guild.ID != "" {
+			c.storeGuild(guild)
+		}
+
+	case SystemEventDeletedGuild, SystemEventSelfExitedGuild:
+		c.Invalidate(guildID)
+	}
+}
+
+// Invalidate 清除指定服务器的所有缓存
+func (c *StateCache) Invalidate(guildID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.guilds, guildID)
+	delete(c.roles, guildID)
+	delete(c.members, guildID)
+	for id, entry := range c.channels {
+		if entry.channel.GuildID == guildID {
+			delete(c.channels, id)
+		}
+	}
+}
+
+// Guild 从缓存获取服务器信息
+//
+// 返回的 Guild 不包含 Roles 和 Channels，请使用 GuildRoles 和 GuildChannels。
+func (c *StateCache) Guild(guildID string) (*Guild, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.guilds[guildID]
+	if !ok || c.expired(entry.storedAt) {
+		return nil, false
+	}
+	guild := entry.guild
+	return &guild, true
+}
+
+// Channel 从缓存获取频道信息
+func (c *StateCache) Channel(channelID string) (*Channel, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.channels[channelID]
+	if !ok || c.expired(entry.storedAt) {
+		return nil, false
+	}
+	channel := copyChannel(entry.channel)
+	return &channel, true
+}
+
+// GuildChannels 从缓存获取服务器的所有频道
+func (c *StateCache) GuildChannels(guildID string) []Channel {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var channels []Channel
+	for _, entry := range c.channels {
+		if entry.channel.GuildID == guildID && !c.expired(entry.storedAt) {
+			channels = append(channels, copyChannel(entry.channel))
+		}
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Level < channels[j].Level })
+	return channels
+}
+
+// Role 从缓存获取角色信息
+func (c *StateCache) Role(guildID string, roleID int) (*GuildRole, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	set, ok := c.roles[guildID]
+	if !ok || c.expired(set.storedAt) {
+		return nil, false
+	}
+	role, ok := set.roles[roleID]
+	if !ok {
+		return nil, false
+	}
+	return &role, true
+}
+
+// GuildRoles 从缓存获取服务器的所有角色，按位置排序
+//
+// 只在缓存了完整的角色列表时返回，即调用过 Prime 或 GetRoles 且未过期，否则返回 nil。
+func (c *StateCache) GuildRoles(guildID string) []GuildRole {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	set, ok := c.roles[guildID]
+	if !ok || !set.complete || c.expired(set.storedAt) {
+		return nil
+	}
+	roles := make([]GuildRole, 0, len(set.roles))
+	for _, role := range set.roles {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Position < roles[j].Position })
+	return roles
+}
+
+// Member 从缓存获取服务器成员信息
+func (c *StateCache) Member(guildID, userID string) (*GuildMember, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.members[guildID][userID]
+	if !ok || c.expired(entry.storedAt) {
+		return nil, false
+	}
+	member := entry.member
+	member.Roles = append([]int(nil), entry.member.Roles...)
+	return &member, true
+}
+
+// GuildMembers 从缓存获取服务器的所有成员
+func (c *StateCache) GuildMembers(guildID string) []GuildMember {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var members []GuildMember
+	for _, entry := range c.members[guildID] {
+		if !c.expired(entry.storedAt) {
+			member := entry.member
+			member.Roles = append([]int(nil), entry.member.Roles...)
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+// GetGuild 获取服务器信息，缓存未命中时调用 REST 接口并写入缓存
+func (c *StateCache) GetGuild(guildID string) (*Guild, error) {
+	if guild, ok := c.Guild(guildID); ok {
+		return guild, nil
+	}
+
+	guild, err := c.client.Guild.GetGuildInfo(guildID)
+	if err != nil {
+		return nil, err
+	}
+	if c.policy.Guilds {
+		c.storeGuild(*guild)
+	}
+	return guild, nil
+}
+
+// GetChannel 获取频道信息，缓存未命中时调用 REST 接口并写入缓存
+func (c *StateCache) GetChannel(channelID string) (*Channel, error) {
+	if channel, ok := c.Channel(channelID); ok {
+		return channel, nil
+	}
+
+	channel, err := c.client.Channel.GetChannelInfo(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if c.policy.Channels {
+		c.storeChannel(*channel)
+	}
+	return channel, nil
+}
+
+// GetRoles 获取服务器角色列表，没有缓存完整的角色列表时调用 REST 接口并写入缓存
+func (c *StateCache) GetRoles(guildID string) ([]GuildRole, error) {
+	if roles := c.GuildRoles(guildID); roles != nil {
+		return roles, nil
+	}
+
+	roles, err := c.fetchRoles(guildID)
+	if err != nil {
+		return nil, err
+	}
+	c.storeRoles(guildID, roles)
+	return roles, nil
+}
+
+// fetchRoles 分页获取服务器的所有角色
+func (c *StateCache) fetchRoles(guildID string) ([]GuildRole, error) {
+	var roles []GuildRole
+	for page := 1; ; page++ {
+		result, err := c.client.Role.GetRoleList(guildID, page, 50)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, result.Items...)
+		if page >= result.Meta.PageTotal {
+			break
+		}
+	}
+	return roles, nil
+}
+
+// GetMember 获取服务器成员信息，缓存未命中时调用 REST 接口并写入缓存
+func (c *StateCache) GetMember(guildID, userID string) (*GuildMember, error) {
+	if member, ok := c.Member(guildID, userID); ok && member.Username != "" {
+		return member, nil
+	}
+
+	member, err := c.client.Guild.GetGuildMember(guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if c.policy.Members {
+		c.storeMember(guildID, *member)
+	}
+	return member, nil
+}
+
+// Sweep 清除所有过期的缓存，返回清除的数量
+func (c *StateCache) Sweep() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sweep()
+}
+
+// expired 判断缓存是否过期，调用方需持有读锁
+func (c *StateCache) expired(storedAt time.Time) bool {
+	return c.policy.TTL > 0 && time.Since(storedAt) > c.policy.TTL
+}
+
+// sweepIfDue 距上次清除超过 TTL 时清除过期缓存，调用方需持有写锁
+func (c *StateCache) sweepIfDue() {
+	if c.policy.TTL > 0 && time.Since(c.lastSweep) > c.policy.TTL {
+		c.sweep()
+	}
+}
+
+// sweep 清除过期缓存并删除空的服务器索引，调用方需持有写锁
+func (c *StateCache) sweep() int {
+	c.lastSweep = time.Now()
+	if c.policy.TTL <= 0 {
+		return 0
+	}
+
+	removed := 0
+	for id, entry := range c.guilds {
+		if c.expired(entry.storedAt) {
+			delete(c.guilds, id)
+			removed++
+		}
+	}
+	for id, entry := range c.channels {
+		if c.expired(entry.storedAt) {
+			delete(c.channels, id)
+			removed++
+		}
+	}
+	for guildID, set := range c.roles {
+		if c.expired(set.storedAt) {
+			delete(c.roles, guildID)
+			removed += len(set.roles)
+		}
+	}
+	for guildID, members := range c.members {
+		for id, entry := range members {
+			if c.expired(entry.storedAt) {
+				delete(members, id)
+				removed++
+			}
+		}
+		if len(members) == 0 {
+			delete(c.members, guildID)
+		}
+	}
+	return removed
+}
+
+func (c *StateCache) storeGuild(guild Guild) {
+	if !c.policy.Guilds {
+		return
+	}
+	guild.Roles = nil
+	guild.Channels = nil
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweepIfDue()
+
+	c.guilds[guild.ID] = cachedGuild{guild: guild, storedAt: time.Now()}
+}
+
+func (c *StateCache) storeChannel(channel Channel) {
+	if !c.policy.Channels {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweepIfDue()
+
+	c.channels[channel.ID] = cachedChannel{channel: copyChannel(channel), storedAt: time.Now()}
+}
+
+func (c *StateCache) storeRole(guildID string, role GuildRole) {
+	if !c.policy.Roles {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweepIfDue()
+
+	set, ok := c.roles[guildID]
+	if !ok || c.expired(set.storedAt) {
+		set = &cachedRoles{roles: make(map[int]GuildRole), storedAt: time.Now()}
+		c.roles[guildID] = set
+	}
+	set.roles[role.RoleID] = role
+}
+
+// storeRoles 以完整的角色列表替换服务器的角色缓存
+func (c *StateCache) storeRoles(guildID string, roles []GuildRole) {
+	if !c.policy.Roles {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweepIfDue()
+
+	set := &cachedRoles{roles: make(map[int]GuildRole, len(roles)), complete: true, storedAt: time.Now()}
+	for _, role := range roles {
+		set.roles[role.RoleID] = role
+	}
+	c.roles[guildID] = set
+}
+
+func (c *StateCache) storeMember(guildID string, member GuildMember) {
+	if !c.policy.Members {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweepIfDue()
+
+	members, ok := c.members[guildID]
+	if !ok {
+		members = make(map[string]cachedMember)
+		c.members[guildID] = members
+	}
+
+	// 超出上限时淘汰最早写入的成员
+	if _, exists := members[member.ID]; !exists && c.policy.MaxMembersPerGuild > 0 && len(members) >= c.policy.MaxMembersPerGuild {
+		var oldestID string
+		var oldest time.Time
+		for id, entry := range members {
+			if oldestID == "" || entry.storedAt.Before(oldest) {
+				oldestID, oldest = id, entry.storedAt
+			}
+		}
+		delete(members, oldestID)
+	}
+
+	member.Roles = append([]int(nil), member.Roles...)
+	members[member.ID] = cachedMember{member: member, storedAt: time.Now()}
+}
+
+// copyChannel 深拷贝频道的权限切片
+func copyChannel(channel Channel) Channel {
+	channel.PermissionOverwrites = append([]PermissionOverwrite(nil), channel.PermissionOverwrites...)
+	channel.PermissionUsers = append([]PermissionUser(nil), channel.PermissionUsers...)
+	return channel
+}
@@ -141,6 +141,9 @@ func (s *ChannelService) UpdateChannel(channelID string, params UpdateChannelPar
 	if params.Password != "" {
 		requestParams["password"] = params.Password
 	}
+	if params.ParentID != "" {
+		requestParams["parent_id"] = params.ParentID
+	}
 
 	resp, err := s.client.Post("channel/update", requestParams)
 	if err != nil {
@@ -265,26 +268,10 @@ func (s *ChannelService) GetChannelUserList(channelID string) ([]User, error) {
 }
 
 // SyncChannelRole 同步频道权限
+//
+// Deprecated: 使用 client.ChannelRole.Sync。
 func (s *ChannelService) SyncChannelRole(channelID string) (*ChannelRoleResponse, error) {
-	if channelID == "" {
-		return nil, fmt.Errorf("频道ID不能为空")
-	}
-
-	params := map[string]interface{}{
-		"channel_id": channelID,
-	}
-
-	resp, err := s.client.Post("channel-role/sync", params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result ChannelRoleResponse
-	if err := json.Unmarshal(resp.Data, &result); err != nil {
-		return nil, fmt.Errorf("解析频道权限同步结果失败: %w", err)
-	}
-
-	return &result, nil
+	return s.client.ChannelRole.Sync(channelID)
 }
 
 // CreateChannelParams 创建频道参数
@@ -305,6 +292,7 @@ type UpdateChannelParams struct {
 	LimitAmount  int    `json:"limit_amount,omitempty"` // 语音频道人数限制
 	VoiceQuality int    `json:"voice_quality,omitempty"`// 语音质量
 	Password     string `json:"password,omitempty"`     // 频道密码
+	ParentID     string `json:"parent_id,omitempty"`    // 父分组ID
 }
 
 // ListChannelsResponse 频道列表响应
@@ -318,4 +306,5 @@ type ListChannelsResponse struct {
 type ChannelRoleResponse struct {
 	PermissionOverwrites []PermissionOverwrite `json:"permission_overwrites"`
 	PermissionUsers      []PermissionUser      `json:"permission_users"`
+	PermissionSync       int                   `json:"permission_sync"` // 是否与分组权限同步
 } 
\ No newline at end of file
@@ -0,0 +1,293 @@
+package kook
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// 频道权限覆写目标类型
+const (
+	OverwriteTypeRole = "role_id" // 按角色覆写
+	OverwriteTypeUser = "user_id" // 按用户覆写
+)
+
+// ChannelRoleService 频道权限覆写相关API服务
+type ChannelRoleService struct {
+	client *Client
+}
+
+// ChannelOverwrite 频道权限覆写
+type ChannelOverwrite struct {
+	Type  string      `json:"type"`  // 覆写目标类型：role_id 或 user_id
+	Value string      `json:"value"` // 角色ID或用户ID
+	Allow Permissions `json:"allow"` // 允许的权限
+	Deny  Permissions `json:"deny"`  // 拒绝的权限
+}
+
+// RoleOverwrite 创建按角色的权限覆写
+func RoleOverwrite(roleID int, allow, deny Permissions) ChannelOverwrite {
+	return ChannelOverwrite{Type: OverwriteTypeRole, Value: strconv.Itoa(roleID), Allow: allow, Deny: deny}
+}
+
+// UserOverwrite 创建按用户的权限覆写
+func UserOverwrite(userID string, allow, deny Permissions) ChannelOverwrite {
+	return ChannelOverwrite{Type: OverwriteTypeUser, Value: userID, Allow: allow, Deny: deny}
+}
+
+// key 覆写目标的唯一标识
+func (o ChannelOverwrite) key() string {
+	return o.Type + ":" + o.Value
+}
+
+// ChannelRoleResult 创建或更新权限覆写的结果
+type ChannelRoleResult struct {
+	RoleID int         `json:"role_id"` // 角色ID，按用户覆写时为0
+	UserID string      `json:"user_id"` // 用户ID，按角色覆写时为空
+	Allow  Permissions `json:"allow"`   // 允许的权限
+	Deny   Permissions `json:"deny"`    // 拒绝的权限
+}
+
+// Overwrites 将频道权限列表转换为统一的覆写列表
+func (r *ChannelRoleResponse) Overwrites() []ChannelOverwrite {
+	overwrites := make([]ChannelOverwrite, 0, len(r.PermissionOverwrites)+len(r.PermissionUsers))
+	for _, o := range r.PermissionOverwrites {
+		overwrites = append(overwrites, RoleOverwrite(o.RoleID, o.Allow, o.Deny))
+	}
+	for _, u := range r.PermissionUsers {
+		overwrites = append(overwrites, UserOverwrite(u.User.ID, u.Allow, u.Deny))
+	}
+	return overwrites
+}
+
+// validateOverwriteTarget 校验覆写目标
+func validateOverwriteTarget(channelID, targetType, value string) error {
+	if channelID == "" {
+		return fmt.Errorf("频道ID不能为空")
+	}
+	if targetType != OverwriteTypeRole && targetType != OverwriteTypeUser {
+		return fmt.Errorf("无效的覆写类型: %s", targetType)
+	}
+	if value == "" {
+		return fmt.Errorf("覆写目标不能为空")
+	}
+	return nil
+}
+
+// List 获取频道的权限覆写列表
+func (s *ChannelRoleService) List(channelID string) (*ChannelRoleResponse, error) {
+	if channelID == "" {
+		return nil, fmt.Errorf("频道ID不能为空")
+	}
+
+	query := map[string]string{
+		"channel_id": channelID,
+	}
+
+	resp, err := s.client.Get("channel-role/index", query)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ChannelRoleResponse
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("解析频道权限列表失败: %w", err)
+	}
+
+	return &result, nil
+}
+
+// Create 为角色或用户创建频道权限覆写，新建的覆写不包含任何权限
+func (s *ChannelRoleService) Create(channelID, targetType, value string) (*ChannelRoleResult, error) {
+	if err := validateOverwriteTarget(channelID, targetType, value); err != nil {
+		return nil, err
+	}
+
+	params := map[string]interface{}{
+		"channel_id": channelID,
+		"type":       targetType,
+		"value":      value,
+	}
+
+	resp, err := s.client.Post("channel-role/create", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ChannelRoleResult
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("解析频道权限创建结果失败: %w", err)
+	}
+
+	return &result, nil
+}
+
+// Update 更新角色或用户的频道权限覆写
+func (s *ChannelRoleService) Update(channelID string, overwrite ChannelOverwrite) (*ChannelRoleResult, error) {
+	if err := validateOverwriteTarget(channelID, overwrite.Type, overwrite.Value); err != nil {
+		return nil, err
+	}
+
+	params := map[string]interface{}{
+		"channel_id": channelID,
+		"type":       overwrite.Type,
+		"value":      overwrite.Value,
+		"allow":      overwrite.Allow,
+		"deny":       overwrite.Deny,
+	}
+
+	resp, err := s.client.Post("channel-role/update", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ChannelRoleResult
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("解析频道权限更新结果失败: %w", err)
+	}
+
+	return &result, nil
+}
+
+// Delete 删除角色或用户的频道权限覆写
+func (s *ChannelRoleService) Delete(channelID, targetType, value string) error {
+	if err := validateOverwriteTarget(channelID, targetType, value); err != nil {
+		return err
+	}
+
+	params := map[string]interface{}{
+		"channel_id": channelID,
+		"type":       targetType,
+		"value":      value,
+	}
+
+	_, err := s.client.Post("channel-role/delete", params)
+	return err
+}
+
+// Sync 将频道权限同步为所属分组的权限
+func (s *ChannelRoleService) Sync(channelID string) (*ChannelRoleResponse, error) {
+	if channelID == "" {
+		return nil, fmt.Errorf("频道ID不能为空")
+	}
+
+	params := map[string]interface{}{
+		"channel_id": channelID,
+	}
+
+	resp, err := s.client.Post("channel-role/sync", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ChannelRoleResponse
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("解析频道权限同步结果失败: %w", err)
+	}
+
+	return &result, nil
+}
+
+// 权限覆写变更类型
+const (
+	OverwriteActionCreate = "create" // 新建覆写
+	OverwriteActionUpdate = "update" // 更新覆写
+	OverwriteActionDelete = "delete" // 删除覆写
+)
+
+// OverwriteChange 权限覆写变更
+type OverwriteChange struct {
+	Action    string           // 变更类型
+	Overwrite ChannelOverwrite // 目标覆写，删除时为当前值
+}
+
+// DiffOverwrites 计算从 current 变为 desired 所需的变更
+//
+// 新增的覆写会生成 create 与 update 两个变更，因为KOOK创建覆写时不能指定权限。
+// prune 为 true 时删除 desired 中不存在的覆写，全体成员角色（ID为0）的覆写不会被删除，
+// 只会被重置为空权限。返回的变更按删除、新建、更新的顺序排列。
+func DiffOverwrites(current, desired []ChannelOverwrite, prune bool) []OverwriteChange {
+	currentByKey := make(map[string]ChannelOverwrite, len(current))
+	for _, o := range current {
+		currentByKey[o.key()] = o
+	}
+	desiredByKey := make(map[string]ChannelOverwrite, len(desired))
+	for _, o := range desired {
+		desiredByKey[o.key()] = o
+	}
+
+	var deletes, creates, updates []OverwriteChange
+	for _, o := range desired {
+		existing, ok := currentByKey[o.key()]
+		if !ok {
+			creates = append(creates, OverwriteChange{Action: OverwriteActionCreate, Overwrite: o})
+			if o.Allow != 0 || o.Deny != 0 {
+				updates = append(updates, OverwriteChange{Action: OverwriteActionUpdate, Overwrite: o})
+			}
+			continue
+		}
+		if existing.Allow != o.Allow || existing.Deny != o.Deny {
+			updates = append(updates, OverwriteChange{Action: OverwriteActionUpdate, Overwrite: o})
+		}
+	}
+
+	if prune {
+		for _, o := range current {
+			if _, ok := desiredByKey[o.key()]; ok {
+				continue
+			}
+			if o.Type == OverwriteTypeRole && o.Value == strconv.Itoa(everyoneRoleID) {
+				if o.Allow != 0 || o.Deny != 0 {
+					updates = append(updates, OverwriteChange{
+						Action:    OverwriteActionUpdate,
+						Overwrite: RoleOverwrite(everyoneRoleID, 0, 0),
+					})
+				}
+				continue
+			}
+			deletes = append(deletes, OverwriteChange{Action: OverwriteActionDelete, Overwrite: o})
+		}
+	}
+
+	for _, changes := range [][]OverwriteChange{deletes, creates, updates} {
+		sort.SliceStable(changes, func(i, j int) bool {
+			return changes[i].Overwrite.key() < changes[j].Overwrite.key()
+		})
+	}
+
+	changes := make([]OverwriteChange, 0, len(deletes)+len(creates)+len(updates))
+	changes = append(changes, deletes...)
+	changes = append(changes, creates...)
+	return append(changes, updates...)
+}
+
+// Apply 将频道的权限覆写调整为 desired，返回已执行的变更
+//
+// 遇到错误时立即停止，返回值中包含出错前已执行的变更。
+func (s *ChannelRoleService) Apply(channelID string, desired []ChannelOverwrite, prune bool) ([]OverwriteChange, error) {
+	current, err := s.List(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := DiffOverwrites(current.Overwrites(), desired, prune)
+	applied := make([]OverwriteChange, 0, len(changes))
+	for _, change := range changes {
+		o := change.Overwrite
+		switch change.Action {
+		case OverwriteActionCreate:
+			_, err = s.Create(channelID, o.Type, o.Value)
+		case OverwriteActionUpdate:
+			_, err = s.Update(channelID, o)
+		case OverwriteActionDelete:
+			err = s.Delete(channelID, o.Type, o.Value)
+		}
+		if err != nil {
+			return applied, fmt.Errorf("%s权限覆写 %s 失败: %w", change.Action, o.key(), err)
+		}
+		applied = append(applied, change)
+	}
+
+	return applied, nil
+}
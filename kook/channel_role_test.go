@@ -0,0 +1,102 @@
+package kook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffOverwrites(t *testing.T) {
+	create := func(o ChannelOverwrite) OverwriteChange {
+		return OverwriteChange{Action: OverwriteActionCreate, Overwrite: o}
+	}
+	update := func(o ChannelOverwrite) OverwriteChange {
+		return OverwriteChange{Action: OverwriteActionUpdate, Overwrite: o}
+	}
+	remove := func(o ChannelOverwrite) OverwriteChange {
+		return OverwriteChange{Action: OverwriteActionDelete, Overwrite: o}
+	}
+
+	view := PermissionViewChannel
+	send := PermissionSendMessages
+
+	tests := []struct {
+		name    string
+		current []ChannelOverwrite
+		desired []ChannelOverwrite
+		prune   bool
+		want    []OverwriteChange
+	}{
+		{
+			name:    "没有变化",
+			current: []ChannelOverwrite{RoleOverwrite(1, view, 0)},
+			desired: []ChannelOverwrite{RoleOverwrite(1, view, 0)},
+			want:    []OverwriteChange{},
+		},
+		{
+			name:    "新建后设置权限",
+			desired: []ChannelOverwrite{RoleOverwrite(1, view, send)},
+			want: []OverwriteChange{
+				create(RoleOverwrite(1, view, send)),
+				update(RoleOverwrite(1, view, send)),
+			},
+		},
+		{
+			name:    "新建空权限只需创建",
+			desired: []ChannelOverwrite{UserOverwrite("u", 0, 0)},
+			want:    []OverwriteChange{create(UserOverwrite("u", 0, 0))},
+		},
+		{
+			name:    "权限不同时更新",
+			current: []ChannelOverwrite{RoleOverwrite(1, view, 0)},
+			desired: []ChannelOverwrite{RoleOverwrite(1, view, send)},
+			want:    []OverwriteChange{update(RoleOverwrite(1, view, send))},
+		},
+		{
+			name:    "不清理时保留多余覆写",
+			current: []ChannelOverwrite{RoleOverwrite(1, view, 0), UserOverwrite("u", send, 0)},
+			desired: []ChannelOverwrite{RoleOverwrite(1, view, 0)},
+			want:    []OverwriteChange{},
+		},
+		{
+			name:    "清理时删除多余覆写",
+			current: []ChannelOverwrite{RoleOverwrite(1, view, 0), UserOverwrite("u", send, 0)},
+			desired: []ChannelOverwrite{RoleOverwrite(1, view, 0)},
+			prune:   true,
+			want:    []OverwriteChange{remove(UserOverwrite("u", send, 0))},
+		},
+		{
+			name:    "全体成员覆写只重置不删除",
+			current: []ChannelOverwrite{RoleOverwrite(0, view, send)},
+			prune:   true,
+			want:    []OverwriteChange{update(RoleOverwrite(0, 0, 0))},
+		},
+		{
+			name:    "空的全体成员覆写无需重置",
+			current: []ChannelOverwrite{RoleOverwrite(0, 0, 0)},
+			prune:   true,
+			want:    []OverwriteChange{},
+		},
+		{
+			name:    "按删除、新建、更新排序",
+			current: []ChannelOverwrite{RoleOverwrite(3, view, 0), RoleOverwrite(2, 0, 0), RoleOverwrite(1, 0, 0)},
+			desired: []ChannelOverwrite{UserOverwrite("b", send, 0), RoleOverwrite(2, send, 0), UserOverwrite("a", view, 0)},
+			prune:   true,
+			want: []OverwriteChange{
+				remove(RoleOverwrite(1, 0, 0)),
+				remove(RoleOverwrite(3, view, 0)),
+				create(UserOverwrite("a", view, 0)),
+				create(UserOverwrite("b", send, 0)),
+				update(RoleOverwrite(2, send, 0)),
+				update(UserOverwrite("a", view, 0)),
+				update(UserOverwrite("b", send, 0)),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DiffOverwrites(tt.current, tt.desired, tt.prune))
+		})
+	}
+}
@@ -2,15 +2,15 @@ package kook
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
-
-	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -38,35 +38,42 @@ type Client struct {
 	token       string
 	tokenType   TokenType
 	baseURL     string
-	logger      *logrus.Logger
+	logger      Logger
+	logLevel    LogLevel
+	logLevels   map[string]LogLevel
+	metrics     Metrics
+	tracer      Tracer
+	ctx         context.Context
 	rateLimiter *GlobalRateLimiter
 	retryConfig *RetryConfig
 
 	// API服务
-	User      *UserService
-	Guild     *GuildService
-	Channel   *ChannelService
-	Message   *MessageService
-	Gateway   *GatewayService
-	Role      *RoleService
-	Game      *GameService
-	Friend    *FriendService
-	Invite    *InviteService
-	Asset     *AssetService
-	Intimacy  *IntimacyService
-	Badge     *BadgeService
-	Blacklist *BlacklistService
-	Emoji     *EmojiService
-	Region    *RegionService
-	OAuth     *OAuthService
-	Live      *LiveService
-	Admin     *AdminService
-	Security  *SecurityService
-	Voice     *VoiceService
-	Item      *ItemService
-	Order     *OrderService
-	Coupon    *CouponService
-	Boost     *BoostService
+	User          *UserService
+	Guild         *GuildService
+	Channel       *ChannelService
+	ChannelRole   *ChannelRoleService
+	Message       *MessageService
+	DirectMessage *DirectMessageService
+	Gateway       *GatewayService
+	Role          *RoleService
+	Game          *GameService
+	Friend        *FriendService
+	Invite        *InviteService
+	Asset         *AssetService
+	Intimacy      *IntimacyService
+	Badge         *BadgeService
+	Blacklist     *BlacklistService
+	Emoji         *EmojiService
+	Region        *RegionService
+	OAuth         *OAuthService
+	Live          *LiveService
+	Admin         *AdminService
+	Security      *SecurityService
+	Voice         *VoiceService
+	Item          *ItemService
+	Order         *OrderService
+	Coupon        *CouponService
+	Boost         *BoostService
 }
 
 // ClientOption 客户端配置选项
@@ -94,12 +101,40 @@ func WithBaseURL(baseURL string) ClientOption {
 }
 
 // WithLogger 设置自定义日志器
-func WithLogger(logger *logrus.Logger) ClientOption {
+func WithLogger(logger Logger) ClientOption {
 	return func(c *Client) {
 		c.logger = logger
 	}
 }
 
+// WithLogLevel 设置默认日志级别
+func WithLogLevel(level LogLevel) ClientOption {
+	return func(c *Client) {
+		c.logLevel = level
+	}
+}
+
+// WithSubsystemLogLevel 设置指定子系统的日志级别
+func WithSubsystemLogLevel(subsystem string, level LogLevel) ClientOption {
+	return func(c *Client) {
+		c.logLevels[subsystem] = level
+	}
+}
+
+// WithMetrics 设置指标收集器
+func WithMetrics(metrics Metrics) ClientOption {
+	return func(c *Client) {
+		c.metrics = metrics
+	}
+}
+
+// WithTracer 设置链路追踪器
+func WithTracer(tracer Tracer) ClientOption {
+	return func(c *Client) {
+		c.tracer = tracer
+	}
+}
+
 // WithRateLimiter 设置自定义速率限制器
 func WithRateLimiter(rateLimiter *GlobalRateLimiter) ClientOption {
 	return func(c *Client) {
@@ -139,20 +174,16 @@ func NewClient(token string, options ...ClientOption) *Client {
 		Timeout: 30 * time.Second,
 	}
 
-	// 默认日志器
-	logger := logrus.New()
-	logger.SetLevel(logrus.InfoLevel)
-	logger.SetFormatter(&logrus.TextFormatter{
-		TimestampFormat: "2006-01-02 15:04:05",
-		FullTimestamp:   true,
-	})
-
 	client := &Client{
 		httpClient:  httpClient,
 		token:       token,
 		tokenType:   TokenTypeBot,
 		baseURL:     BaseURL,
-		logger:      logger,
+		logger:      newDefaultLogger(),
+		logLevel:    LogLevelInfo,
+		logLevels:   make(map[string]LogLevel),
+		metrics:     NewNopMetrics(),
+		tracer:      NewNopTracer(),
 		rateLimiter: NewGlobalRateLimiter(),
 		retryConfig: DefaultRetryConfig(),
 	}
@@ -162,35 +193,92 @@ func NewClient(token string, options ...ClientOption) *Client {
 		option(client)
 	}
 
-	// 初始化API服务
-	client.User = &UserService{client: client}
-	client.Guild = &GuildService{client: client}
-	client.Channel = &ChannelService{client: client}
-	client.Message = &MessageService{client: client}
-	client.Gateway = &GatewayService{client: client}
-	client.Role = &RoleService{client: client}
-	client.Game = &GameService{client: client}
-	client.Friend = &FriendService{client: client}
-	client.Invite = &InviteService{client: client}
-	client.Asset = &AssetService{client: client}
-	client.Intimacy = &IntimacyService{client: client}
-	client.Badge = &BadgeService{client: client}
-	client.Blacklist = &BlacklistService{client: client}
-	client.Emoji = &EmojiService{client: client}
-	client.Region = &RegionService{client: client}
-	client.OAuth = &OAuthService{client: client}
-	client.Live = &LiveService{client: client}
-	client.Admin = &AdminService{client: client}
-	client.Security = &SecurityService{client: client}
-	client.Voice = &VoiceService{client: client}
-	client.Item = &ItemService{client: client}
-	client.Order = &OrderService{client: client}
-	client.Coupon = &CouponService{client: client}
-	client.Boost = &BoostService{client: client}
+	if client.logger == nil {
+		client.logger = NewNopLogger()
+	}
+	if client.metrics == nil {
+		client.metrics = NewNopMetrics()
+	}
+	if client.tracer == nil {
+		client.tracer = NewNopTracer()
+	}
+
+	client.initServices()
 
 	return client
 }
 
+// initServices 初始化API服务
+func (c *Client) initServices() {
+	c.User = &UserService{client: c}
+	c.Guild = &GuildService{client: c}
+	c.Channel = &ChannelService{client: c}
+	c.ChannelRole = &ChannelRoleService{client: c}
+	c.Message = &MessageService{client: c}
+	c.DirectMessage = &DirectMessageService{client: c}
+	c.Gateway = &GatewayService{client: c}
+	c.Role = &RoleService{client: c}
+	c.Game = &GameService{client: c}
+	c.Friend = &FriendService{client: c}
+	c.Invite = &InviteService{client: c}
+	c.Asset = &AssetService{client: c}
+	c.Intimacy = &IntimacyService{client: c}
+	c.Badge = &BadgeService{client: c}
+	c.Blacklist = &BlacklistService{client: c}
+	c.Emoji = &EmojiService{client: c}
+	c.Region = &RegionService{client: c}
+	c.OAuth = &OAuthService{client: c}
+	c.Live = &LiveService{client: c}
+	c.Admin = &AdminService{client: c}
+	c.Security = &SecurityService{client: c}
+	c.Voice = &VoiceService{client: c}
+	c.Item = &ItemService{client: c}
+	c.Order = &OrderService{client: c}
+	c.Coupon = &CouponService{client: c}
+	c.Boost = &BoostService{client: c}
+}
+
+// WithContext 返回绑定了 ctx 的客户端副本
+//
+// 副本发出的所有请求都会使用 ctx，可用于取消请求，以及让 API 调用的追踪片段
+// 挂在 ctx 中的父片段下，例如在事件处理器中使用 client.WithContext(event.Context())。
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("ctx不能为空")
+	}
+	clone := *c
+	clone.ctx = ctx
+	clone.initServices()
+	return &clone
+}
+
+// Context 获取客户端绑定的上下文
+func (c *Client) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// Tracer 获取链路追踪器
+func (c *Client) Tracer() Tracer {
+	return c.tracer
+}
+
+// Logger 获取指定子系统的日志器
+func (c *Client) Logger(subsystem string) Logger {
+	level, ok := c.logLevels[subsystem]
+	if !ok {
+		level = c.logLevel
+	}
+	return NewLeveledLogger(c.logger.With(F("subsystem", subsystem)), level)
+}
+
+// Metrics 获取指标收集器
+func (c *Client) Metrics() Metrics {
+	return c.metrics
+}
+
 // buildURL 构建完整的API URL
 func (c *Client) buildURL(endpoint string) string {
 	endpoint = strings.TrimPrefix(endpoint, "/")
@@ -199,17 +287,60 @@ func (c *Client) buildURL(endpoint string) string {
 
 // doRequest 执行HTTP请求
 func (c *Client) doRequest(method, endpoint string, params map[string]interface{}, query map[string]string) (*Response, error) {
+	logger := c.Logger(LogSubsystemHTTP)
+
+	ctx, span := c.tracer.Start(c.Context(), "kook.api "+endpoint,
+		F(TraceAttrMethod, method),
+		F(TraceAttrEndpoint, endpoint),
+	)
+	defer span.End()
+
 	// 使用重试机制执行请求
-	return DoWithRetry(func() (*Response, error) {
-		return c.doSingleRequest(method, endpoint, params, query)
-	}, c.retryConfig, c.logger)
+	attempt := 0
+	resp, err := doWithRetry(func() (*Response, error) {
+		attempt++
+		return c.doSingleRequest(ctx, method, endpoint, params, query, attempt)
+	}, c.retryConfig, logger.With(F("method", method), F("endpoint", endpoint)), func(err error) {
+		reason := "error"
+		if IsRateLimitError(err) {
+			reason = "rate_limit"
+		}
+		c.metrics.IncCounter(MetricAPIRetries, Labels{"endpoint": endpoint, "reason": reason})
+	})
+
+	span.SetAttributes(F(TraceAttrAttempts, attempt))
+	if err != nil {
+		span.RecordError(err)
+	}
+	return resp, err
 }
 
 // doSingleRequest 执行单次HTTP请求
-func (c *Client) doSingleRequest(method, endpoint string, params map[string]interface{}, query map[string]string) (*Response, error) {
+func (c *Client) doSingleRequest(ctx context.Context, method, endpoint string, params map[string]interface{}, query map[string]string, attempt int) (resp *Response, err error) {
+	logger := c.Logger(LogSubsystemHTTP).With(
+		F("method", method),
+		F("endpoint", endpoint),
+		F("attempt", attempt),
+	)
+
+	ctx, span := c.tracer.Start(ctx, "kook.api.attempt",
+		F(TraceAttrEndpoint, endpoint),
+		F(TraceAttrAttempt, attempt),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}()
+
 	// 应用速率限制
 	if c.rateLimiter != nil {
+		_, waitSpan := c.tracer.Start(ctx, "kook.ratelimit.wait", F(TraceAttrEndpoint, endpoint))
+		waitStart := time.Now()
 		c.rateLimiter.Wait(endpoint)
+		c.metrics.ObserveDuration(MetricRateLimitWait, time.Since(waitStart), Labels{"endpoint": endpoint})
+		waitSpan.End()
 	}
 
 	requestURL := c.buildURL(endpoint)
@@ -236,10 +367,10 @@ func (c *Client) doSingleRequest(method, endpoint string, params map[string]inte
 			return nil, fmt.Errorf("序列化请求参数失败: %w", err)
 		}
 		body = bytes.NewBuffer(jsonData)
-		c.logger.WithField("params", string(jsonData)).Debugf("请求参数")
+		logger.Debug("请求参数", F("params", string(jsonData)))
 	}
 
-	req, err := http.NewRequest(method, requestURL, body)
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, body)
 	if err != nil {
 		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
@@ -252,36 +383,47 @@ func (c *Client) doSingleRequest(method, endpoint string, params map[string]inte
 	}
 	req.Header.Set("Accept-Language", "zh-cn")
 
-	c.logger.WithFields(logrus.Fields{
-		"method":  method,
-		"url":     requestURL,
-		"headers": req.Header,
-	}).Debugf("发送API请求")
+	logger.Debug("发送API请求",
+		F("url", requestURL),
+		F("headers", redactHeaders(req.Header)),
+	)
 
 	// 执行请求
-	resp, err := c.httpClient.Do(req)
+	start := time.Now()
+	httpResp, err := c.httpClient.Do(req)
 	if err != nil {
-		c.logger.WithError(err).Errorf("请求失败")
+		latency := time.Since(start)
+		c.observeRequest(method, endpoint, "network_error", latency)
+		logger.Error("请求失败", ErrField(err), F("latency", latency))
 		return nil, fmt.Errorf("请求失败: %w", err)
 	}
-	defer resp.Body.Close()
+	defer httpResp.Body.Close()
 
 	// 读取响应
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(httpResp.Body)
+	latency := time.Since(start)
+	c.observeRequest(method, endpoint, strconv.Itoa(httpResp.StatusCode), latency)
 	if err != nil {
-		c.logger.WithError(err).Errorf("读取响应失败")
+		logger.Error("读取响应失败", ErrField(err), F("latency", latency))
 		return nil, fmt.Errorf("读取响应失败: %w", err)
 	}
 
-	c.logger.WithFields(logrus.Fields{
-		"status": resp.StatusCode,
-		"body":   string(respBody),
-	}).Debugf("收到API响应")
+	requestID := httpResp.Header.Get("X-Request-Id")
+	span.SetAttributes(
+		F(TraceAttrStatus, httpResp.StatusCode),
+		F(TraceAttrRequestID, requestID),
+	)
+	logger = logger.With(
+		F("status", httpResp.StatusCode),
+		F("request_id", requestID),
+		F("latency", latency),
+	)
+	logger.Debug("收到API响应", F("body", string(respBody)))
 
 	// 解析响应
 	var response Response
 	if err := json.Unmarshal(respBody, &response); err != nil {
-		c.logger.WithError(err).Errorf("解析响应失败")
+		logger.Error("解析响应失败", ErrField(err))
 		return nil, fmt.Errorf("解析响应失败: %w", err)
 	}
 
@@ -291,27 +433,36 @@ func (c *Client) doSingleRequest(method, endpoint string, params map[string]inte
 			WithContext(method, endpoint)
 
 		// 从响应头中提取请求ID
-		if requestID := resp.Header.Get("X-Request-Id"); requestID != "" {
+		if requestID != "" {
 			err = err.WithRequestID(requestID)
 		}
 
 		// 从响应头中提取重试延迟
-		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if retryAfter := httpResp.Header.Get("Retry-After"); retryAfter != "" {
 			if seconds, parseErr := time.ParseDuration(retryAfter + "s"); parseErr == nil {
 				err = err.WithRetryAfter(seconds)
 			}
 		}
 
-		err.HTTPStatus = resp.StatusCode
+		err.HTTPStatus = httpResp.StatusCode
 
-		c.logger.WithError(err).Errorf("API返回错误")
+		span.SetAttributes(F(TraceAttrCode, response.Code))
+		c.metrics.IncCounter(MetricAPIErrors, Labels{"endpoint": endpoint, "code": strconv.Itoa(response.Code)})
+		logger.Error("API返回错误", ErrField(err), F("code", response.Code))
 		return &response, err
 	}
 
-	c.logger.Infof("API请求成功: %s %s", method, requestURL)
+	logger.Debug("API请求成功")
 	return &response, nil
 }
 
+// observeRequest 上报单次请求指标
+func (c *Client) observeRequest(method, endpoint, status string, latency time.Duration) {
+	labels := Labels{"method": method, "endpoint": endpoint, "status": status}
+	c.metrics.IncCounter(MetricAPIRequests, labels)
+	c.metrics.ObserveDuration(MetricAPIRequestDuration, latency, Labels{"method": method, "endpoint": endpoint})
+}
+
 // Get 发送GET请求
 func (c *Client) Get(endpoint string, query map[string]string) (*Response, error) {
 	return c.doRequest("GET", endpoint, nil, query)
@@ -0,0 +1,234 @@
+package kook
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule 周期计划
+type CronSchedule interface {
+	// Next 返回 t 之后的下一次执行时间，找不到时返回零值
+	Next(t time.Time) time.Time
+}
+
+// cronShortcuts 预定义的cron表达式
+var cronShortcuts = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronField cron表达式字段的取值范围
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = [5]cronField{
+	{"分钟", 0, 59},
+	{"小时", 0, 23},
+	{"日期", 1, 31},
+	{"月份", 1, 12},
+	{"星期", 0, 7},
+}
+
+// ParseCron 解析cron表达式
+//
+// 支持标准的5段格式「分 时 日 月 周」，每段可使用 *、数字、范围 a-b、步长 /n 与逗号列表，
+// 星期中 0 和 7 都表示周日；日与周同时指定时满足其一即可。
+// 另支持 @yearly、@monthly、@weekly、@daily、@hourly 以及 @every <时长>（如 @every 90m）。
+func ParseCron(spec string) (CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("cron表达式 %q 无效: %w", spec, err)
+		}
+		if interval < time.Second {
+			return nil, fmt.Errorf("cron表达式 %q 无效: 间隔不能小于1秒", spec)
+		}
+		return everySchedule{interval: interval}, nil
+	}
+	if expanded, ok := cronShortcuts[spec]; ok {
+		spec = expanded
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("cron表达式 %q 无效: 需要5段，实际为%d段", spec, len(parts))
+	}
+
+	var bits [5]uint64
+	for i, part := range parts {
+		b, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron表达式 %q 无效: %w", spec, err)
+		}
+		bits[i] = b
+	}
+
+	// 7 与 0 都表示周日
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	return &cronSchedule{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: parts[2] == "*" || parts[2] == "?",
+		dowStar: parts[4] == "*" || parts[4] == "?",
+	}, nil
+}
+
+// parseCronField 解析单个字段，返回取值位图
+func parseCronField(field string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s步长 %q 无效", f.name, stepPart)
+			}
+			step = n
+		}
+
+		low, high := f.min, f.max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			lowPart, highPart, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseCronValue(lowPart, f); err != nil {
+				return 0, err
+			}
+			if high, err = parseCronValue(highPart, f); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("%s范围 %q 无效", f.name, rangePart)
+			}
+		default:
+			value, err := parseCronValue(rangePart, f)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			if !hasStep {
+				high = value
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// parseCronValue 解析字段中的数值并检查范围
+func parseCronValue(s string, f cronField) (int, error) {
+	value, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s %q 不是数字", f.name, s)
+	}
+	if value < f.min || value > f.max {
+		return 0, fmt.Errorf("%s %d 超出范围 %d-%d", f.name, value, f.min, f.max)
+	}
+	return value, nil
+}
+
+// cronSchedule 5段cron表达式
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+// Next 从 t 的下一分钟开始逐级查找匹配的时间，按 t 所在时区计算
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+	limit := t.Year() + 5
+
+wrap:
+	if t.Year() > limit {
+		return time.Time{}
+	}
+
+	for s.month&(1<<uint(t.Month())) == 0 {
+		t = startOfDay(t.Year(), t.Month()+1, 1, loc)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !s.dayMatches(t) {
+		t = startOfDay(t.Year(), t.Month(), t.Day()+1, loc)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		if !next.After(t) {
+			// 下一个小时因夏令时被跳过，time.Date 会将其规范化到更早的时间
+			next = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+2, 0, 0, 0, loc)
+		}
+		t = next
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	return t
+}
+
+// startOfDay 返回某天的第一个时刻，零点因夏令时不存在时顺延到当天第一个存在的整点
+func startOfDay(year int, month time.Month, day int, loc *time.Location) time.Time {
+	want := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	for hour := 0; hour < 24; hour++ {
+		t := time.Date(year, month, day, hour, 0, 0, 0, loc)
+		if t.Day() == want.Day() && t.Month() == want.Month() {
+			return t
+		}
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// dayMatches 检查日期与星期，两者都指定时满足其一即可
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// everySchedule 固定间隔
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Next 返回 t 加上间隔后的时间，精确到秒
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval).Truncate(time.Second)
+}
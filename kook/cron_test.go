@@ -0,0 +1,142 @@
+package kook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"1-b * * * *",
+		"@every",
+		"@every 500ms",
+		"@every soon",
+		"@fortnightly",
+	}
+	for _, spec := range specs {
+		_, err := ParseCron(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		require.NoError(t, err)
+		return v
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		from string
+		want string
+	}{
+		{"每分钟", "* * * * *", "2024-03-10 08:15", "2024-03-10 08:16"},
+		{"分钟步长", "*/15 * * * *", "2024-03-10 08:16", "2024-03-10 08:30"},
+		{"步长跨小时", "*/20 * * * *", "2024-03-10 08:45", "2024-03-10 09:00"},
+		{"带起点的步长", "5/20 * * * *", "2024-03-10 08:26", "2024-03-10 08:45"},
+		{"范围与步长", "0 9-17/4 * * *", "2024-03-10 10:00", "2024-03-10 13:00"},
+		{"列表", "0 8,20 * * *", "2024-03-10 08:00", "2024-03-10 20:00"},
+		{"跨天", "30 6 * * *", "2024-03-10 07:00", "2024-03-11 06:30"},
+		{"月末跨月", "0 0 * * *", "2024-01-31 12:00", "2024-02-01 00:00"},
+		{"闰年二月", "0 0 29 2 *", "2023-03-01 00:00", "2024-02-29 00:00"},
+		{"跳过没有31日的月份", "0 0 31 * *", "2024-04-01 00:00", "2024-05-31 00:00"},
+		{"跨年", "0 0 1 1 *", "2024-06-15 00:00", "2025-01-01 00:00"},
+		{"月份范围", "0 0 1 6-8 *", "2024-08-02 00:00", "2025-06-01 00:00"},
+		{"星期0为周日", "0 0 * * 0", "2024-03-11 00:00", "2024-03-17 00:00"},
+		{"星期7为周日", "0 0 * * 7", "2024-03-11 00:00", "2024-03-17 00:00"},
+		{"星期范围含7", "0 0 * * 5-7", "2024-03-11 00:00", "2024-03-15 00:00"},
+		{"只指定日期", "0 0 13 * *", "2024-03-10 00:00", "2024-03-13 00:00"},
+		{"只指定星期", "0 0 * * 1", "2024-03-10 00:00", "2024-03-11 00:00"},
+		{"日期与星期满足其一_星期先到", "0 0 13 * 1", "2024-03-10 00:00", "2024-03-11 00:00"},
+		{"日期与星期满足其一_日期先到", "0 0 13 * 1", "2024-03-11 00:00", "2024-03-13 00:00"},
+		{"问号等同星号", "0 0 ? * 1", "2024-03-10 00:00", "2024-03-11 00:00"},
+		{"快捷方式", "@monthly", "2024-12-15 00:00", "2025-01-01 00:00"},
+		{"每周快捷方式", "@weekly", "2024-03-11 00:00", "2024-03-17 00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, at(tt.want), schedule.Next(at(tt.from)))
+		})
+	}
+}
+
+func TestCronScheduleNextTruncatesSeconds(t *testing.T) {
+	schedule, err := ParseCron("* * * * *")
+	require.NoError(t, err)
+
+	from := time.Date(2024, 3, 10, 8, 15, 42, 500, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 10, 8, 16, 0, 0, time.UTC), schedule.Next(from))
+}
+
+func TestCronScheduleNextNeverMatches(t *testing.T) {
+	schedule, err := ParseCron("0 0 31 2 *")
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero())
+}
+
+func TestCronScheduleNextDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("缺少时区数据")
+	}
+
+	// 2024-03-10 02:00 时钟拨快到 03:00，当天没有 02:30
+	schedule, err := ParseCron("30 2 * * *")
+	require.NoError(t, err)
+	next := schedule.Next(time.Date(2024, 3, 9, 12, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2024, 3, 11, 2, 30, 0, 0, loc), next)
+
+	// 每小时的任务在拨快当天跳过不存在的小时
+	schedule, err = ParseCron("0 * * * *")
+	require.NoError(t, err)
+	next = schedule.Next(time.Date(2024, 3, 10, 1, 30, 0, 0, loc))
+	assert.Equal(t, time.Date(2024, 3, 10, 3, 0, 0, 0, loc), next)
+
+	// 2024-11-03 时钟回拨，01:30 出现两次，取第一次
+	schedule, err = ParseCron("30 1 * * *")
+	require.NoError(t, err)
+	next = schedule.Next(time.Date(2024, 11, 3, 0, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC), next.UTC())
+}
+
+func TestCronScheduleNextSkippedMidnight(t *testing.T) {
+	loc, err := time.LoadLocation("America/Santiago")
+	if err != nil {
+		t.Skip("缺少时区数据")
+	}
+
+	// 2024-09-08 00:00 时钟拨快到 01:00，当天没有零点
+	schedule, err := ParseCron("0 12 * * *")
+	require.NoError(t, err)
+	next := schedule.Next(time.Date(2024, 9, 7, 13, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2024, 9, 8, 12, 0, 0, 0, loc), next)
+}
+
+func TestEveryScheduleNext(t *testing.T) {
+	schedule, err := ParseCron("@every 90m")
+	require.NoError(t, err)
+
+	from := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, from.Add(90*time.Minute), schedule.Next(from))
+}
@@ -0,0 +1,399 @@
+package kook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// DirectMessageService 私聊服务，管理私聊会话与私聊消息
+//
+// 私聊消息既可以用对方的用户ID（target_id）寻址，也可以用私聊会话的 chat_code 寻址。
+type DirectMessageService struct {
+	client *Client
+}
+
+// UserChat 私聊会话
+type UserChat struct {
+	Code            string         `json:"code"`              // 私聊会话 chat_code
+	LastReadTime    int64          `json:"last_read_time"`    // 上次阅读消息的时间（毫秒）
+	LatestMsgTime   int64          `json:"latest_msg_time"`   // 最新消息时间（毫秒）
+	UnreadCount     int            `json:"unread_count"`      // 未读消息数
+	IsFriend        bool           `json:"is_friend"`         // 是否为好友
+	IsBlocked       bool           `json:"is_blocked"`        // 是否已屏蔽对方
+	IsTargetBlocked bool           `json:"is_target_blocked"` // 是否已被对方屏蔽
+	TargetInfo      UserChatTarget `json:"target_info"`       // 对方信息
+}
+
+// UserChatTarget 私聊会话的对方信息
+type UserChatTarget struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Online   bool   `json:"online"`
+	Avatar   string `json:"avatar"`
+}
+
+// UserChatListResponse 私聊会话列表响应
+type UserChatListResponse struct {
+	Items []UserChat     `json:"items"`
+	Meta  PaginationMeta `json:"meta"`
+}
+
+// DirectMessage 私聊消息
+type DirectMessage struct {
+	ID          string        `json:"id"`
+	Type        int           `json:"type"`
+	AuthorID    string        `json:"author_id"`
+	Content     string        `json:"content"`
+	Embeds      []interface{} `json:"embeds"`
+	Attachments *Attachment   `json:"attachments"`
+	CreateAt    int64         `json:"create_at"`
+	UpdatedAt   int64         `json:"updated_at"`
+	Reactions   []Reaction    `json:"reactions"`
+	ImageName   string        `json:"image_name"`
+	ReadStatus  bool          `json:"read_status"`
+	Quote       *Quote        `json:"quote"`
+	MentionInfo MentionInfo   `json:"mention_info"`
+}
+
+// DirectMessageListResponse 私聊消息列表响应
+type DirectMessageListResponse struct {
+	Items []DirectMessage `json:"items"`
+}
+
+// SendDirectMessageParams 发送私聊消息参数，TargetID 与 ChatCode 二选一
+type SendDirectMessageParams struct {
+	TargetID   string `json:"target_id,omitempty"`   // 对方用户ID
+	ChatCode   string `json:"chat_code,omitempty"`   // 私聊会话 chat_code
+	Content    string `json:"content"`               // 消息内容
+	Type       int    `json:"type,omitempty"`        // 消息类型，默认1文本
+	Quote      string `json:"quote,omitempty"`       // 引用消息ID
+	Nonce      string `json:"nonce,omitempty"`       // 随机字符串，防重复
+	TemplateID string `json:"template_id,omitempty"` // 模板消息ID
+}
+
+// SendDirectMessageResult 发送私聊消息结果
+type SendDirectMessageResult struct {
+	MsgID        string `json:"msg_id"`        // 消息ID
+	MsgTimestamp int64  `json:"msg_timestamp"` // 发送时间（毫秒）
+	Nonce        string `json:"nonce"`         // 请求中的随机字符串
+}
+
+// GetDirectMessageListParams 获取私聊消息列表参数，TargetID 与 ChatCode 二选一
+type GetDirectMessageListParams struct {
+	TargetID string `json:"target_id,omitempty"` // 对方用户ID
+	ChatCode string `json:"chat_code,omitempty"` // 私聊会话 chat_code
+	MsgID    string `json:"msg_id,omitempty"`    // 参考消息ID
+	Flag     string `json:"flag,omitempty"`      // 查询模式：before, around, after
+	PageSize int    `json:"page_size,omitempty"` // 返回数量，默认50，最大100
+}
+
+// UpdateDirectMessageParams 更新私聊消息参数
+type UpdateDirectMessageParams struct {
+	MsgID      string `json:"msg_id"`                // 消息ID
+	Content    string `json:"content"`               // 新的消息内容
+	Quote      string `json:"quote,omitempty"`       // 引用消息ID
+	TemplateID string `json:"template_id,omitempty"` // 模板消息ID
+}
+
+// ReactionUser 回应用户
+type ReactionUser struct {
+	User
+	ReactionTime int64 `json:"reaction_time"` // 回应时间（毫秒）
+}
+
+// GetChatList 获取私聊会话列表
+func (s *DirectMessageService) GetChatList(page, pageSize int) (*UserChatListResponse, error) {
+	query := make(map[string]string)
+	if page > 0 {
+		query["page"] = strconv.Itoa(page)
+	}
+	if pageSize > 0 && pageSize <= 50 {
+		query["page_size"] = strconv.Itoa(pageSize)
+	}
+
+	resp, err := s.client.Get("user-chat/list", query)
+	if err != nil {
+		return nil, err
+	}
+
+	var result UserChatListResponse
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("解析私聊会话列表失败: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetChat 获取私聊会话详情
+func (s *DirectMessageService) GetChat(chatCode string) (*UserChat, error) {
+	if chatCode == "" {
+		return nil, fmt.Errorf("私聊会话code不能为空")
+	}
+
+	query := map[string]string{
+		"chat_code": chatCode,
+	}
+
+	resp, err := s.client.Get("user-chat/view", query)
+	if err != nil {
+		return nil, err
+	}
+
+	var chat UserChat
+	if err := json.Unmarshal(resp.Data, &chat); err != nil {
+		return nil, fmt.Errorf("解析私聊会话失败: %w", err)
+	}
+
+	return &chat, nil
+}
+
+// CreateChat 创建与用户的私聊会话，已存在时返回现有会话
+func (s *DirectMessageService) CreateChat(targetID string) (*UserChat, error) {
+	if targetID == "" {
+		return nil, fmt.Errorf("目标用户ID不能为空")
+	}
+
+	params := map[string]interface{}{
+		"target_id": targetID,
+	}
+
+	resp, err := s.client.Post("user-chat/create", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var chat UserChat
+	if err := json.Unmarshal(resp.Data, &chat); err != nil {
+		return nil, fmt.Errorf("解析私聊会话失败: %w", err)
+	}
+
+	return &chat, nil
+}
+
+// DeleteChat 删除私聊会话
+func (s *DirectMessageService) DeleteChat(chatCode string) error {
+	if chatCode == "" {
+		return fmt.Errorf("私聊会话code不能为空")
+	}
+
+	params := map[string]interface{}{
+		"chat_code": chatCode,
+	}
+
+	_, err := s.client.Post("user-chat/delete", params)
+	return err
+}
+
+// SendMessage 发送私聊消息
+func (s *DirectMessageService) SendMessage(params SendDirectMessageParams) (*SendDirectMessageResult, error) {
+	if params.TargetID == "" && params.ChatCode == "" {
+		return nil, fmt.Errorf("目标用户ID和私聊会话code不能同时为空")
+	}
+	if params.Content == "" {
+		return nil, fmt.Errorf("消息内容不能为空")
+	}
+
+	requestParams := map[string]interface{}{
+		"content": params.Content,
+	}
+	if params.ChatCode != "" {
+		requestParams["chat_code"] = params.ChatCode
+	} else {
+		requestParams["target_id"] = params.TargetID
+	}
+
+	if params.Type > 0 {
+		requestParams["type"] = params.Type
+	} else {
+		requestParams["type"] = MessageTypeText
+	}
+
+	// 设置可选参数
+	if params.Quote != "" {
+		requestParams["quote"] = params.Quote
+	}
+	if params.Nonce != "" {
+		requestParams["nonce"] = params.Nonce
+	}
+	if params.TemplateID != "" {
+		requestParams["template_id"] = params.TemplateID
+	}
+
+	resp, err := s.client.Post("direct-message/create", requestParams)
+	if err != nil {
+		return nil, err
+	}
+
+	var result SendDirectMessageResult
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("解析发送结果失败: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetMessageList 获取私聊消息列表
+func (s *DirectMessageService) GetMessageList(params GetDirectMessageListParams) (*DirectMessageListResponse, error) {
+	if params.TargetID == "" && params.ChatCode == "" {
+		return nil, fmt.Errorf("目标用户ID和私聊会话code不能同时为空")
+	}
+
+	query := make(map[string]string)
+	if params.ChatCode != "" {
+		query["chat_code"] = params.ChatCode
+	} else {
+		query["target_id"] = params.TargetID
+	}
+
+	// 添加查询参数
+	if params.MsgID != "" {
+		query["msg_id"] = params.MsgID
+	}
+	if params.Flag != "" {
+		query["flag"] = params.Flag
+	}
+	if params.PageSize > 0 && params.PageSize <= 100 {
+		query["page_size"] = strconv.Itoa(params.PageSize)
+	}
+
+	resp, err := s.client.Get("direct-message/list", query)
+	if err != nil {
+		return nil, err
+	}
+
+	var result DirectMessageListResponse
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("解析私聊消息列表失败: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetMessage 获取私聊消息详情
+func (s *DirectMessageService) GetMessage(chatCode, msgID string) (*DirectMessage, error) {
+	if chatCode == "" {
+		return nil, fmt.Errorf("私聊会话code不能为空")
+	}
+	if msgID == "" {
+		return nil, fmt.Errorf("消息ID不能为空")
+	}
+
+	query := map[string]string{
+		"chat_code": chatCode,
+		"msg_id":    msgID,
+	}
+
+	resp, err := s.client.Get("direct-message/view", query)
+	if err != nil {
+		return nil, err
+	}
+
+	var message DirectMessage
+	if err := json.Unmarshal(resp.Data, &message); err != nil {
+		return nil, fmt.Errorf("解析私聊消息失败: %w", err)
+	}
+
+	return &message, nil
+}
+
+// UpdateMessage 更新私聊消息，只能更新自己发送的消息
+func (s *DirectMessageService) UpdateMessage(params UpdateDirectMessageParams) error {
+	if params.MsgID == "" {
+		return fmt.Errorf("消息ID不能为空")
+	}
+	if params.Content == "" {
+		return fmt.Errorf("消息内容不能为空")
+	}
+
+	requestParams := map[string]interface{}{
+		"msg_id":  params.MsgID,
+		"content": params.Content,
+	}
+	if params.Quote != "" {
+		requestParams["quote"] = params.Quote
+	}
+	if params.TemplateID != "" {
+		requestParams["template_id"] = params.TemplateID
+	}
+
+	_, err := s.client.Post("direct-message/update", requestParams)
+	return err
+}
+
+// DeleteMessage 删除私聊消息，只能删除自己发送的消息
+func (s *DirectMessageService) DeleteMessage(msgID string) error {
+	if msgID == "" {
+		return fmt.Errorf("消息ID不能为空")
+	}
+
+	params := map[string]interface{}{
+		"msg_id": msgID,
+	}
+
+	_, err := s.client.Post("direct-message/delete", params)
+	return err
+}
+
+// AddReaction 给私聊消息添加回应
+func (s *DirectMessageService) AddReaction(msgID, emoji string) error {
+	if msgID == "" {
+		return fmt.Errorf("消息ID不能为空")
+	}
+	if emoji == "" {
+		return fmt.Errorf("表情不能为空")
+	}
+
+	params := map[string]interface{}{
+		"msg_id": msgID,
+		"emoji":  emoji,
+	}
+
+	_, err := s.client.Post("direct-message/add-reaction", params)
+	return err
+}
+
+// DeleteReaction 删除自己在私聊消息上的回应
+func (s *DirectMessageService) DeleteReaction(msgID, emoji string) error {
+	if msgID == "" {
+		return fmt.Errorf("消息ID不能为空")
+	}
+	if emoji == "" {
+		return fmt.Errorf("表情不能为空")
+	}
+
+	params := map[string]interface{}{
+		"msg_id": msgID,
+		"emoji":  emoji,
+	}
+
+	_, err := s.client.Post("direct-message/delete-reaction", params)
+	return err
+}
+
+// GetReactionUserList 获取私聊消息某个回应的用户列表
+func (s *DirectMessageService) GetReactionUserList(msgID, emoji string) ([]ReactionUser, error) {
+	if msgID == "" {
+		return nil, fmt.Errorf("消息ID不能为空")
+	}
+	if emoji == "" {
+		return nil, fmt.Errorf("表情不能为空")
+	}
+
+	query := map[string]string{
+		"msg_id": msgID,
+		"emoji":  emoji,
+	}
+
+	resp, err := s.client.Get("direct-message/reaction-list", query)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []ReactionUser
+	if err := json.Unmarshal(resp.Data, &users); err != nil {
+		return nil, fmt.Errorf("解析用户列表失败: %w", err)
+	}
+
+	return users, nil
+}
@@ -0,0 +1,86 @@
+package kook
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 事件来源
+const (
+	EventSourceGateway  = "gateway"   // WebSocket 网关
+	EventSourceWebhook  = "webhook"   // Webhook 回调
+	EventSourceAuditLog = "audit_log" // 审计日志轮询
+)
+
+// EventDispatcher 可注册事件处理器的事件源，WebSocketClient、WebhookHandler 和 AuditLogWatcher 均实现了该接口
+type EventDispatcher interface {
+	OnEvent(eventType int, handler EventHandler)
+}
+
+// dispatchEvent 异步调用事件处理器
+//
+// 为事件创建追踪片段并写入 event 的上下文，片段在所有处理器返回后结束。
+// done 不为 nil 时在所有处理器返回后调用。
+func dispatchEvent(client *Client, logger Logger, source string, handlers []EventHandler, event *Event, done func()) {
+	eventType := strconv.Itoa(event.Type)
+	client.metrics.IncCounter(MetricEventsReceived, Labels{"source": source, "event_type": eventType})
+
+	ctx, span := client.tracer.Start(client.Context(), "kook.event "+eventType,
+		F(TraceAttrSource, source),
+		F(TraceAttrEventType, event.Type),
+		F(TraceAttrChannelType, event.ChannelType),
+		F(TraceAttrTargetID, event.TargetID),
+		F(TraceAttrMsgID, event.MsgID),
+	)
+	event.ctx = ctx
+
+	if len(handlers) == 0 {
+		span.End()
+		if done != nil {
+			done()
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(handlers))
+	for _, handler := range handlers {
+		go func(h EventHandler) {
+			defer wg.Done()
+			runEventHandler(client, logger, source, eventType, h, event)
+		}(handler)
+	}
+
+	go func() {
+		wg.Wait()
+		span.End()
+		if done != nil {
+			done()
+		}
+	}()
+}
+
+// runEventHandler 执行单个事件处理器，捕获panic并上报耗时
+func runEventHandler(client *Client, logger Logger, source, eventType string, handler EventHandler, event *Event) {
+	labels := Labels{"source": source, "event_type": eventType}
+	start := time.Now()
+
+	// 每个处理器使用独立的事件副本，其上下文携带处理器自身的片段
+	ctx, span := client.tracer.Start(event.Context(), "kook.event.handler", F(TraceAttrEventType, event.Type))
+	handlerEvent := *event
+	handlerEvent.ctx = ctx
+
+	defer func() {
+		client.metrics.ObserveDuration(MetricHandlerDuration, time.Since(start), labels)
+		if r := recover(); r != nil {
+			client.metrics.IncCounter(MetricHandlerPanics, labels)
+			span.RecordError(fmt.Errorf("事件处理器发生panic: %v", r))
+			logger.Error("事件处理器发生panic", F("panic", r), F("event_type", event.Type))
+		}
+		span.End()
+	}()
+
+	handler(&handlerEvent)
+}
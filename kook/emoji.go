@@ -69,6 +69,35 @@ func (s *EmojiService) CreateEmoji(name, guildID string, emoji interface{}) (*Em
 	return &result, nil
 }
 
+// UploadEmoji 上传图片创建表情，content 为图片内容
+func (s *EmojiService) UploadEmoji(guildID, name, fileName string, content []byte) (*Emoji, error) {
+	if guildID == "" {
+		return nil, fmt.Errorf("服务器ID不能为空")
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("表情图片不能为空")
+	}
+
+	fields := map[string]string{
+		"guild_id": guildID,
+	}
+	if name != "" {
+		fields["name"] = name
+	}
+
+	resp, err := s.client.postMultipart("emoji/create", fields, "emoji", fileName, content)
+	if err != nil {
+		return nil, err
+	}
+
+	var result Emoji
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("解析表情信息失败: %w", err)
+	}
+
+	return &result, nil
+}
+
 // UpdateEmoji 更新表情
 func (s *EmojiService) UpdateEmoji(id, name string) (*Emoji, error) {
 	if id == "" {
@@ -0,0 +1,252 @@
+package kook
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// EventTypeSystem 系统事件类型，具体事件由 extra.type 区分
+const EventTypeSystem = 255
+
+// 系统事件子类型（extra.type）
+const (
+	// 频道相关
+	SystemEventAddedReaction   = "added_reaction"   // 频道内用户添加回应
+	SystemEventDeletedReaction = "deleted_reaction" // 频道内用户取消回应
+	SystemEventUpdatedMessage  = "updated_message"  // 频道消息更新
+	SystemEventDeletedMessage  = "deleted_message"  // 频道消息被删除
+	SystemEventAddedChannel    = "added_channel"    // 新增频道
+	SystemEventUpdatedChannel  = "updated_channel"  // 修改频道信息
+	SystemEventDeletedChannel  = "deleted_channel"  // 删除频道
+	SystemEventPinnedMessage   = "pinned_message"   // 新增频道置顶消息
+	SystemEventUnpinnedMessage = "unpinned_message" // 取消频道置顶消息
+
+	// 私聊相关
+	SystemEventUpdatedPrivateMessage  = "updated_private_message"  // 私聊消息更新
+	SystemEventDeletedPrivateMessage  = "deleted_private_message"  // 私聊消息被删除
+	SystemEventPrivateAddedReaction   = "private_added_reaction"   // 私聊内用户添加回应
+	SystemEventPrivateDeletedReaction = "private_deleted_reaction" // 私聊内用户取消回应
+
+	// 服务器成员相关
+	SystemEventJoinedGuild        = "joined_guild"         // 新成员加入服务器
+	SystemEventExitedGuild        = "exited_guild"         // 服务器成员退出
+	SystemEventUpdatedGuildMember = "updated_guild_member" // 服务器成员信息更新
+	SystemEventGuildMemberOnline  = "guild_member_online"  // 服务器成员上线
+	SystemEventGuildMemberOffline = "guild_member_offline" // 服务器成员下线
+
+	// 服务器角色相关
+	SystemEventAddedRole   = "added_role"   // 服务器角色增加
+	SystemEventDeletedRole = "deleted_role" // 服务器角色删除
+	SystemEventUpdatedRole = "updated_role" // 服务器角色更新
+
+	// 服务器相关
+	SystemEventUpdatedGuild     = "updated_guild"      // 服务器信息更新
+	SystemEventDeletedGuild     = "deleted_guild"      // 服务器删除
+	SystemEventAddedBlockList   = "added_block_list"   // 服务器封禁用户
+	SystemEventDeletedBlockList = "deleted_block_list" // 服务器取消封禁用户
+	SystemEventAddedEmoji       = "added_emoji"        // 服务器添加新表情
+	SystemEventRemovedEmoji     = "removed_emoji"      // 服务器删除表情
+	SystemEventUpdatedEmoji     = "updated_emoji"      // 服务器更新表情
+
+	// 用户相关
+	SystemEventJoinedChannel   = "joined_channel"    // 用户加入语音频道
+	SystemEventExitedChannel   = "exited_channel"    // 用户退出语音频道
+	SystemEventUserUpdated     = "user_updated"      // 用户信息更新
+	SystemEventSelfJoinedGuild = "self_joined_guild" // 自己新加入服务器
+	SystemEventSelfExitedGuild = "self_exited_guild" // 自己退出服务器
+	SystemEventMessageBtnClick = "message_btn_click" // 卡片消息中的按钮点击
+)
+
+// SystemEvent 系统事件的 extra 字段
+type SystemEvent struct {
+	Type string          `json:"type"` // 事件子类型
+	Body json.RawMessage `json:"body"` // 事件数据
+}
+
+// DecodeBody 将事件数据解析到 v
+func (s *SystemEvent) DecodeBody(v interface{}) error {
+	if err := json.Unmarshal(s.Body, v); err != nil {
+		return fmt.Errorf("解析系统事件 %s 失败: %w", s.Type, err)
+	}
+	return nil
+}
+
+// MessageExtra 消息事件的 extra 字段
+type MessageExtra struct {
+	Type         int         `json:"type"`          // 消息类型
+	GuildID      string      `json:"guild_id"`      // 服务器ID
+	ChannelName  string      `json:"channel_name"`  // 频道名称
+	Mention      []string    `json:"mention"`       // 提及的用户ID
+	MentionAll   bool        `json:"mention_all"`   // 是否提及全体成员
+	MentionRoles []int       `json:"mention_roles"` // 提及的角色ID
+	MentionHere  bool        `json:"mention_here"`  // 是否提及在线成员
+	Author       User        `json:"author"`        // 发送者
+	Code         string      `json:"code"`          // 私聊会话 chat_code
+	Quote        *Quote      `json:"quote"`         // 引用的消息
+	Attachments  *Attachment `json:"attachments"`   // 附件
+}
+
+// IsSystemEvent 判断是否为系统事件
+func (e *Event) IsSystemEvent() bool {
+	return e.Type == EventTypeSystem
+}
+
+// SystemEvent 解析系统事件的 extra 字段
+func (e *Event) SystemEvent() (*SystemEvent, error) {
+	if !e.IsSystemEvent() {
+		return nil, fmt.Errorf("事件类型 %d 不是系统事件", e.Type)
+	}
+
+	var extra SystemEvent
+	if err := e.decodeExtra(&extra); err != nil {
+		return nil, err
+	}
+	return &extra, nil
+}
+
+// MessageExtra 解析消息事件的 extra 字段
+func (e *Event) MessageExtra() (*MessageExtra, error) {
+	if e.IsSystemEvent() {
+		return nil, fmt.Errorf("系统事件没有消息附加信息")
+	}
+
+	var extra MessageExtra
+	if err := e.decodeExtra(&extra); err != nil {
+		return nil, err
+	}
+	return &extra, nil
+}
+
+// decodeExtra 将 extra 字段解析到 v
+func (e *Event) decodeExtra(v interface{}) error {
+	var data []byte
+	switch extra := e.Extra.(type) {
+	case nil:
+		return fmt.Errorf("事件没有附加信息")
+	case json.RawMessage:
+		data = extra
+	default:
+		var err error
+		data, err = json.Marshal(extra)
+		if err != nil {
+			return fmt.Errorf("序列化事件附加信息失败: %w", err)
+		}
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("解析事件附加信息失败: %w", err)
+	}
+	return nil
+}
+
+// 系统事件数据结构
+
+// ReactionEventBody 添加/取消回应事件数据
+type ReactionEventBody struct {
+	MsgID     string `json:"msg_id"`     // 消息ID
+	UserID    string `json:"user_id"`    // 用户ID
+	ChannelID string `json:"channel_id"` // 频道ID
+	ChatCode  string `json:"chat_code"`  // 私聊会话 chat_code
+	Emoji     Emoji  `json:"emoji"`      // 表情
+}
+
+// DeletedChannelBody 删除频道事件数据
+type DeletedChannelBody struct {
+	ID        string `json:"id"`         // 频道ID
+	DeletedAt int64  `json:"deleted_at"` // 删除时间
+}
+
+// GuildMemberJoinedBody 新成员加入服务器事件数据
+type GuildMemberJoinedBody struct {
+	UserID   string `json:"user_id"`   // 用户ID
+	JoinedAt int64  `json:"joined_at"` // 加入时间
+}
+
+// GuildMemberExitedBody 服务器成员退出事件数据
+type GuildMemberExitedBody struct {
+	UserID   string `json:"user_id"`   // 用户ID
+	ExitedAt int64  `json:"exited_at"` // 退出时间
+}
+
+// GuildMemberUpdatedBody 服务器成员信息更新事件数据
+type GuildMemberUpdatedBody struct {
+	UserID   string `json:"user_id"`  // 用户ID
+	Nickname string `json:"nickname"` // 昵称
+}
+
+// DeletedGuildBody 服务器删除事件数据
+type DeletedGuildBody struct {
+	ID string `json:"id"` // 服务器ID
+}
+
+// VoiceChannelEventBody 用户加入/退出语音频道事件数据
+type VoiceChannelEventBody struct {
+	UserID    string `json:"user_id"`    // 用户ID
+	ChannelID string `json:"channel_id"` // 频道ID
+	JoinedAt  int64  `json:"joined_at"`  // 加入时间
+	ExitedAt  int64  `json:"exited_at"`  // 退出时间
+}
+
+// MessageButtonClickBody 卡片按钮点击事件数据
+type MessageButtonClickBody struct {
+	MsgID    string `json:"msg_id"`    // 消息ID
+	UserID   string `json:"user_id"`   // 用户ID
+	Value    string `json:"value"`     // 按钮的 value
+	TargetID string `json:"target_id"` // 消息所在频道ID或私聊用户ID
+	GuildID  string `json:"guild_id"`  // 服务器ID，私聊时为空
+	UserInfo User   `json:"user_info"` // 用户信息
+}
+
+// eventLocation 系统事件数据中与位置相关的字段
+type eventLocation struct {
+	ChannelID string `json:"channel_id"`
+	TargetID  string `json:"target_id"`
+	GuildID   string `json:"guild_id"`
+}
+
+// location 解析系统事件数据中的频道与服务器ID
+func (e *Event) location() eventLocation {
+	var location eventLocation
+	if extra, err := e.SystemEvent(); err == nil {
+		json.Unmarshal(extra.Body, &location)
+	}
+	return location
+}
+
+// GuildID 获取事件所属的服务器ID，私聊事件返回空字符串
+func (e *Event) GuildID() string {
+	if e.IsSystemEvent() {
+		if e.ChannelType == "GROUP" {
+			return e.TargetID
+		}
+		return e.location().GuildID
+	}
+	if e.ChannelType != "GROUP" {
+		return ""
+	}
+	extra, err := e.MessageExtra()
+	if err != nil {
+		return ""
+	}
+	return extra.GuildID
+}
+
+// ChannelID 获取事件所在的频道ID，与频道无关的事件返回空字符串
+func (e *Event) ChannelID() string {
+	if !e.IsSystemEvent() {
+		if e.ChannelType == "GROUP" {
+			return e.TargetID
+		}
+		return ""
+	}
+
+	location := e.location()
+	if location.ChannelID != "" {
+		return location.ChannelID
+	}
+	// 按钮点击事件的 target_id 为消息所在频道
+	if location.GuildID != "" {
+		return location.TargetID
+	}
+	return ""
+}
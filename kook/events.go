@@ -53,33 +53,6 @@ const (
 	MessageTypeSystem = 255 // 系统消息
 )
 
-// 角色权限常量
-const (
-	PermissionViewChannel      = 1 << 0  // 查看频道
-	PermissionSendMessages     = 1 << 1  // 发送消息
-	PermissionManageMessages   = 1 << 2  // 管理消息
-	PermissionManageChannels   = 1 << 3  // 管理频道
-	PermissionConnectVoice     = 1 << 4  // 连接语音频道
-	PermissionSpeakVoice       = 1 << 5  // 语音频道中说话
-	PermissionMuteMembers      = 1 << 6  // 禁言成员
-	PermissionDeafenMembers    = 1 << 7  // 阻止成员听见
-	PermissionMoveMembers      = 1 << 8  // 移动成员
-	PermissionUseVoiceActivity = 1 << 9  // 使用按键说话
-	PermissionManageRoles      = 1 << 10 // 管理角色
-	PermissionManageGuild      = 1 << 11 // 管理服务器
-	PermissionCreateInvite     = 1 << 12 // 创建邀请
-	PermissionManageInvites    = 1 << 13 // 管理邀请
-	PermissionManageEmojis     = 1 << 14 // 管理表情
-	PermissionKickMembers      = 1 << 15 // 踢出成员
-	PermissionBanMembers       = 1 << 16 // 封禁成员
-	PermissionMentionEveryone  = 1 << 17 // 提及所有人
-	PermissionAddReactions     = 1 << 18 // 添加回应
-	PermissionUploadFiles      = 1 << 19 // 上传文件
-	PermissionUseSlashCommands = 1 << 20 // 使用斜杠命令
-	PermissionPlayMusic        = 1 << 21 // 播放音乐
-	PermissionAdministrator    = 1 << 22 // 管理员
-)
-
 // GetEventTypeName 获取事件类型名称
 func GetEventTypeName(eventType int) string {
 	switch eventType {
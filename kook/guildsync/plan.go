@@ -0,0 +1,207 @@
+package guildsync
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"kook-go-sdk/kook"
+)
+
+// 步骤动作
+const (
+	ActionCreate = "create" // 新建
+	ActionUpdate = "update" // 更新
+	ActionDelete = "delete" // 删除
+	ActionMove   = "move"   // 调整顺序
+)
+
+// 步骤对象
+const (
+	KindGuild      = "guild"      // 服务器设置
+	KindRole       = "role"       // 角色
+	KindCategory   = "category"   // 分组
+	KindChannel    = "channel"    // 频道
+	KindOverwrites = "overwrites" // 频道权限覆写
+)
+
+// Step 计划中的一个步骤
+type Step struct {
+	Action  string   // 动作
+	Kind    string   // 对象类型
+	Target  string   // 对象名称，频道为 "分组名/频道名"
+	Changes []string // 变更说明
+
+	apply func(*applyState) error
+}
+
+// String 返回步骤说明，如 "~ role 管理员"
+func (s Step) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s", actionSymbol(s.Action), s.Kind, s.Target)
+	for _, change := range s.Changes {
+		b.WriteString("\n    ")
+		b.WriteString(change)
+	}
+	return b.String()
+}
+
+func actionSymbol(action string) string {
+	switch action {
+	case ActionCreate:
+		return "+"
+	case ActionUpdate:
+		return "~"
+	case ActionDelete:
+		return "-"
+	default:
+		return ">"
+	}
+}
+
+// Plan 同步计划
+type Plan struct {
+	GuildID string // 服务器ID
+	Steps   []Step // 按执行顺序排列的步骤
+
+	// Unmanaged 删除模式下配置中不存在、但机器人无法管理而保留的角色
+	Unmanaged []string
+
+	roleIDs     map[string]int
+	categoryIDs map[string]string
+	channelIDs  map[string]string
+}
+
+// Empty 判断计划是否无需任何变更
+func (p *Plan) Empty() bool {
+	return len(p.Steps) == 0
+}
+
+// Count 统计指定动作的步骤数
+func (p *Plan) Count(action string) int {
+	n := 0
+	for _, step := range p.Steps {
+		if step.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// String 返回可读的计划说明
+func (p *Plan) String() string {
+	var b strings.Builder
+	if p.Empty() {
+		b.WriteString("服务器 " + p.GuildID + " 与配置一致，无需变更\n")
+	} else {
+		for _, step := range p.Steps {
+			b.WriteString(step.String())
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "共 %d 项变更：新建 %d，更新 %d，删除 %d，调整顺序 %d\n",
+			len(p.Steps), p.Count(ActionCreate), p.Count(ActionUpdate), p.Count(ActionDelete), p.Count(ActionMove))
+	}
+	for _, name := range p.Unmanaged {
+		fmt.Fprintf(&b, "! role %s 机器人无法管理，未删除\n", name)
+	}
+	return b.String()
+}
+
+// WriteTo 输出计划说明
+func (p *Plan) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, p.String())
+	return int64(n), err
+}
+
+// applyState 执行计划时的状态，记录新建对象的ID
+type applyState struct {
+	client      *kook.Client
+	guildID     string
+	roleIDs     map[string]int
+	categoryIDs map[string]string
+	channelIDs  map[string]string
+}
+
+func newApplyState(client *kook.Client, plan *Plan) *applyState {
+	state := &applyState{
+		client:      client,
+		guildID:     plan.GuildID,
+		roleIDs:     make(map[string]int, len(plan.roleIDs)),
+		categoryIDs: make(map[string]string, len(plan.categoryIDs)),
+		channelIDs:  make(map[string]string, len(plan.channelIDs)),
+	}
+	for k, v := range plan.roleIDs {
+		state.roleIDs[k] = v
+	}
+	for k, v := range plan.categoryIDs {
+		state.categoryIDs[k] = v
+	}
+	for k, v := range plan.channelIDs {
+		state.channelIDs[k] = v
+	}
+	return state
+}
+
+// categoryID 返回分组ID，category 为空时返回 "0" 表示不属于任何分组
+func (s *applyState) categoryID(category string) (string, error) {
+	if category == "" {
+		return "0", nil
+	}
+	id, ok := s.categoryIDs[category]
+	if !ok {
+		return "", fmt.Errorf("分组 %s 不存在", category)
+	}
+	return id, nil
+}
+
+// channelID 根据频道路径返回频道ID
+func (s *applyState) channelID(path string) (string, error) {
+	id, ok := s.channelIDs[path]
+	if !ok {
+		return "", fmt.Errorf("频道 %s 不存在", path)
+	}
+	return id, nil
+}
+
+// overwrites 将覆写配置转换为 API 使用的覆写
+func (s *applyState) overwrites(specs []OverwriteSpec) ([]kook.ChannelOverwrite, error) {
+	return resolveOverwrites(specs, s.roleIDs)
+}
+
+// resolveOverwrites 按角色名称解析覆写配置
+func resolveOverwrites(specs []OverwriteSpec, roleIDs map[string]int) ([]kook.ChannelOverwrite, error) {
+	overwrites := make([]kook.ChannelOverwrite, 0, len(specs))
+	for _, o := range specs {
+		if o.User != "" {
+			overwrites = append(overwrites, kook.UserOverwrite(o.User, o.Allow, o.Deny))
+			continue
+		}
+
+		roleID := 0
+		if o.Role != EveryoneRole {
+			id, ok := roleIDs[o.Role]
+			if !ok {
+				return nil, fmt.Errorf("角色 %s 不存在", o.Role)
+			}
+			roleID = id
+		}
+		overwrites = append(overwrites, kook.RoleOverwrite(roleID, o.Allow, o.Deny))
+	}
+	return overwrites, nil
+}
+
+// describeOverwrite 返回覆写的可读说明
+func describeOverwrite(state *liveState, o kook.ChannelOverwrite) string {
+	target := "user " + o.Value
+	if o.Type == kook.OverwriteTypeRole {
+		roleID, _ := strconv.Atoi(o.Value)
+		target = "role " + state.roleName(roleID)
+	}
+	return fmt.Sprintf("%s allow=%s deny=%s", target, o.Allow, o.Deny)
+}
+
+// fieldChange 返回字段变更说明
+func fieldChange(field string, from, to interface{}) string {
+	return fmt.Sprintf("%s: %v -> %v", field, from, to)
+}
@@ -0,0 +1,208 @@
+// Package guildsync 以声明式配置管理KOOK服务器结构
+//
+// 通过 YAML 或 JSON 描述服务器设置、角色、分组、频道和权限覆写，
+// 与服务器的实际状态对比生成执行计划，再按顺序调用 API 使服务器与配置一致。
+package guildsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"kook-go-sdk/kook"
+)
+
+// EveryoneRole 配置中表示全体成员角色的名称
+const EveryoneRole = "@everyone"
+
+// 频道类型
+const (
+	ChannelTypeText  = "text"  // 文字频道
+	ChannelTypeVoice = "voice" // 语音频道
+)
+
+// Spec 服务器配置
+type Spec struct {
+	Guild      *GuildSpec     `json:"guild,omitempty"`      // 服务器设置，为空时不管理
+	Roles      []RoleSpec     `json:"roles,omitempty"`      // 角色
+	Categories []CategorySpec `json:"categories,omitempty"` // 分组及其下的频道
+	Channels   []ChannelSpec  `json:"channels,omitempty"`   // 不属于任何分组的频道
+}
+
+// GuildSpec 服务器设置
+//
+// 频道以名称引用，分组下的频道写作 "分组名/频道名"。
+type GuildSpec struct {
+	Name           string `json:"name,omitempty"`            // 服务器名称
+	Region         string `json:"region,omitempty"`          // 服务器区域
+	DefaultChannel string `json:"default_channel,omitempty"` // 默认频道
+	WelcomeChannel string `json:"welcome_channel,omitempty"` // 欢迎频道
+	NotifyType     *int   `json:"notify_type,omitempty"`     // 通知类型
+}
+
+// RoleSpec 角色配置，按名称与服务器中的角色匹配
+type RoleSpec struct {
+	Name        string           `json:"name"`                  // 角色名称
+	Color       int              `json:"color,omitempty"`       // 角色色值
+	Hoist       bool             `json:"hoist,omitempty"`       // 是否在成员列表中单独显示
+	Mentionable bool             `json:"mentionable,omitempty"` // 是否可以被提及
+	Permissions kook.Permissions `json:"permissions,omitempty"` // 权限，支持数值、"a|b" 或名称列表
+}
+
+// CategorySpec 分组配置，按名称匹配
+type CategorySpec struct {
+	Name       string          `json:"name"`                 // 分组名称
+	Overwrites []OverwriteSpec `json:"overwrites,omitempty"` // 权限覆写
+	Channels   []ChannelSpec   `json:"channels,omitempty"`   // 分组下的频道
+}
+
+// ChannelSpec 频道配置，按所属分组与名称匹配
+type ChannelSpec struct {
+	Name         string          `json:"name"`                    // 频道名称
+	Type         string          `json:"type,omitempty"`          // 频道类型：text 或 voice，默认 text
+	Topic        string          `json:"topic,omitempty"`         // 频道主题
+	SlowMode     int             `json:"slow_mode,omitempty"`     // 慢速模式，与 UpdateChannelParams 一致
+	LimitAmount  int             `json:"limit_amount,omitempty"`  // 语音频道人数限制
+	VoiceQuality int             `json:"voice_quality,omitempty"` // 语音质量
+	Overwrites   []OverwriteSpec `json:"overwrites,omitempty"`    // 权限覆写
+}
+
+// OverwriteSpec 权限覆写配置，Role 与 User 二选一
+//
+// 频道或分组声明了 overwrites 时，以配置为准，多余的覆写会被删除；
+// 未声明时不管理该频道的权限。
+type OverwriteSpec struct {
+	Role  string           `json:"role,omitempty"`  // 角色名称，全体成员为 @everyone
+	User  string           `json:"user,omitempty"`  // 用户ID
+	Allow kook.Permissions `json:"allow,omitempty"` // 允许的权限
+	Deny  kook.Permissions `json:"deny,omitempty"`  // 拒绝的权限
+}
+
+// channelType 返回KOOK频道类型值
+func (c ChannelSpec) channelType() int {
+	if c.Type == ChannelTypeVoice {
+		return 2
+	}
+	return 1
+}
+
+// LoadSpec 从文件加载配置，根据扩展名判断格式，.json 以外均按 YAML 解析
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return ParseJSON(data)
+	}
+	return ParseYAML(data)
+}
+
+// ParseJSON 解析 JSON 格式的配置
+func ParseJSON(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// ParseYAML 解析 YAML 格式的配置
+//
+// YAML 先转换为 JSON 再解析，字段名与 JSON 格式相同。
+func ParseYAML(data []byte) (*Spec, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+
+	converted, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("转换配置失败: %w", err)
+	}
+	return ParseJSON(converted)
+}
+
+// Validate 校验配置，检查名称缺失与重复
+func (s *Spec) Validate() error {
+	roles := make(map[string]bool, len(s.Roles))
+	for _, role := range s.Roles {
+		if role.Name == "" {
+			return fmt.Errorf("角色名称不能为空")
+		}
+		if role.Name == EveryoneRole {
+			return fmt.Errorf("不能声明全体成员角色 %s", EveryoneRole)
+		}
+		if roles[role.Name] {
+			return fmt.Errorf("角色 %s 重复", role.Name)
+		}
+		roles[role.Name] = true
+	}
+
+	categories := make(map[string]bool, len(s.Categories))
+	for _, category := range s.Categories {
+		if category.Name == "" {
+			return fmt.Errorf("分组名称不能为空")
+		}
+		if categories[category.Name] {
+			return fmt.Errorf("分组 %s 重复", category.Name)
+		}
+		categories[category.Name] = true
+
+		if err := validateOverwrites(category.Name, category.Overwrites); err != nil {
+			return err
+		}
+		if err := validateChannels(category.Name, category.Channels); err != nil {
+			return err
+		}
+	}
+
+	return validateChannels("", s.Channels)
+}
+
+func validateChannels(category string, channels []ChannelSpec) error {
+	names := make(map[string]bool, len(channels))
+	for _, channel := range channels {
+		path := channelPath(category, channel.Name)
+		if channel.Name == "" {
+			return fmt.Errorf("频道名称不能为空: %s", path)
+		}
+		if names[channel.Name] {
+			return fmt.Errorf("频道 %s 重复", path)
+		}
+		names[channel.Name] = true
+
+		if channel.Type != "" && channel.Type != ChannelTypeText && channel.Type != ChannelTypeVoice {
+			return fmt.Errorf("频道 %s 的类型无效: %s", path, channel.Type)
+		}
+		if err := validateOverwrites(path, channel.Overwrites); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateOverwrites(path string, overwrites []OverwriteSpec) error {
+	for _, o := range overwrites {
+		if (o.Role == "") == (o.User == "") {
+			return fmt.Errorf("%s 的权限覆写必须且只能指定 role 或 user", path)
+		}
+	}
+	return nil
+}
+
+// channelPath 频道在配置中的路径
+func channelPath(category, name string) string {
+	if category == "" {
+		return name
+	}
+	return category + "/" + name
+}
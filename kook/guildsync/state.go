@@ -0,0 +1,143 @@
+package guildsync
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"kook-go-sdk/kook"
+)
+
+// liveState 服务器的实际状态
+type liveState struct {
+	guild      *kook.Guild
+	roles      map[string]kook.GuildRole // 按名称索引，不含全体成员角色
+	roleOrder  []kook.GuildRole          // 按位置排序的角色
+	categories map[string]kook.Channel   // 按名称索引
+	channels   map[string]kook.Channel   // 按路径索引
+	named      map[string][]kook.Channel // 非分组频道按名称索引，用于识别被移动到其他分组的频道
+	parents    map[string]string         // 分组ID到分组名称
+	ordered    []kook.Channel            // 按位置排序的全部频道
+
+	botRoles   map[int]bool // 机器人拥有的角色
+	botHighest int          // 机器人最高角色的位置，没有角色时为 math.MaxInt
+}
+
+// loadState 从 REST 接口加载服务器状态
+func loadState(client *kook.Client, guildID string) (*liveState, error) {
+	guild, err := client.Guild.GetGuildInfo(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &liveState{
+		guild:      guild,
+		roles:      make(map[string]kook.GuildRole),
+		categories: make(map[string]kook.Channel),
+		channels:   make(map[string]kook.Channel),
+		named:      make(map[string][]kook.Channel),
+		parents:    make(map[string]string),
+	}
+
+	for page := 1; ; page++ {
+		result, err := client.Role.GetRoleList(guildID, page, 50)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range result.Items {
+			if role.RoleID == 0 {
+				continue
+			}
+			// 同名角色只管理第一个
+			if _, ok := state.roles[role.Name]; !ok {
+				state.roles[role.Name] = role
+			}
+			state.roleOrder = append(state.roleOrder, role)
+		}
+		if page >= result.Meta.PageTotal {
+			break
+		}
+	}
+	sort.SliceStable(state.roleOrder, func(i, j int) bool {
+		return state.roleOrder[i].Position < state.roleOrder[j].Position
+	})
+
+	for page := 1; ; page++ {
+		result, err := client.Channel.GetChannelList(guildID, page, 50, "")
+		if err != nil {
+			return nil, err
+		}
+		state.ordered = append(state.ordered, result.Items...)
+		if page >= result.Meta.PageTotal {
+			break
+		}
+	}
+	sort.SliceStable(state.ordered, func(i, j int) bool {
+		return state.ordered[i].Level < state.ordered[j].Level
+	})
+
+	for _, channel := range state.ordered {
+		if !channel.IsCategory {
+			continue
+		}
+		state.parents[channel.ID] = channel.Name
+		if _, ok := state.categories[channel.Name]; !ok {
+			state.categories[channel.Name] = channel
+		}
+	}
+	for _, channel := range state.ordered {
+		if channel.IsCategory {
+			continue
+		}
+		state.named[channel.Name] = append(state.named[channel.Name], channel)
+		path := channelPath(state.parents[channel.ParentID], channel.Name)
+		if _, ok := state.channels[path]; !ok {
+			state.channels[path] = channel
+		}
+	}
+
+	return state, nil
+}
+
+// loadBot 加载机器人在服务器中的角色，用于判断哪些角色可以删除
+func (s *liveState) loadBot(client *kook.Client) error {
+	me, err := client.User.GetMe()
+	if err != nil {
+		return fmt.Errorf("获取机器人信息失败: %w", err)
+	}
+	bot, err := client.User.GetUser(me.ID, s.guild.ID)
+	if err != nil {
+		return fmt.Errorf("获取机器人角色失败: %w", err)
+	}
+
+	s.botRoles = make(map[int]bool, len(bot.Roles))
+	s.botHighest = math.MaxInt
+	for _, id := range bot.Roles {
+		s.botRoles[id] = true
+	}
+	for _, role := range s.roleOrder {
+		if s.botRoles[role.RoleID] && role.Position < s.botHighest {
+			s.botHighest = role.Position
+		}
+	}
+	return nil
+}
+
+// manageable 判断机器人能否管理角色：不能是机器人的角色，且必须排在机器人最高角色之后
+func (s *liveState) manageable(role kook.GuildRole) bool {
+	return role.Type != kook.RoleTypeBot && !s.botRoles[role.RoleID] && role.Position > s.botHighest
+}
+
+// roleName 根据角色ID返回配置中使用的名称
+func (s *liveState) roleName(roleID int) string {
+	if roleID == 0 {
+		return EveryoneRole
+	}
+	for _, role := range s.roleOrder {
+		if role.RoleID == roleID {
+			return role.Name
+		}
+	}
+	return strconv.Itoa(roleID)
+}
@@ -0,0 +1,723 @@
+package guildsync
+
+import (
+	"fmt"
+	"sort"
+
+	"kook-go-sdk/kook"
+)
+
+// Syncer 服务器配置同步器
+type Syncer struct {
+	client *kook.Client
+	logger kook.Logger
+	prune  bool
+	dryRun bool
+}
+
+// Option 同步器配置选项
+type Option func(*Syncer)
+
+// WithPrune 设置是否删除配置中不存在的角色、分组和频道，默认不删除
+func WithPrune(prune bool) Option {
+	return func(s *Syncer) {
+		s.prune = prune
+	}
+}
+
+// WithDryRun 设置是否只生成计划而不执行
+func WithDryRun(dryRun bool) Option {
+	return func(s *Syncer) {
+		s.dryRun = dryRun
+	}
+}
+
+// WithLogger 设置日志器
+func WithLogger(logger kook.Logger) Option {
+	return func(s *Syncer) {
+		s.logger = logger
+	}
+}
+
+// New 创建同步器
+func New(client *kook.Client, opts ...Option) *Syncer {
+	s := &Syncer{client: client}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.logger == nil {
+		s.logger = client.Logger("guildsync")
+	}
+	return s
+}
+
+// Sync 生成计划并执行，dry-run 模式下只返回计划
+func (s *Syncer) Sync(guildID string, spec *Spec) (*Plan, error) {
+	plan, err := s.Plan(guildID, spec)
+	if err != nil {
+		return nil, err
+	}
+	if s.dryRun || plan.Empty() {
+		return plan, nil
+	}
+	return plan, s.Apply(plan)
+}
+
+// Apply 按顺序执行计划，遇到错误时立即停止
+func (s *Syncer) Apply(plan *Plan) error {
+	state := newApplyState(s.client, plan)
+	for i, step := range plan.Steps {
+		s.logger.Info("执行同步步骤",
+			kook.F("guild_id", plan.GuildID),
+			kook.F("step", i+1),
+			kook.F("action", step.Action),
+			kook.F("kind", step.Kind),
+			kook.F("target", step.Target))
+
+		if err := step.apply(state); err != nil {
+			return fmt.Errorf("第 %d 步 %s %s %s 失败: %w", i+1, step.Action, step.Kind, step.Target, err)
+		}
+	}
+	return nil
+}
+
+// Plan 对比配置与服务器实际状态，生成同步计划
+func (s *Syncer) Plan(guildID string, spec *Spec) (*Plan, error) {
+	if guildID == "" {
+		return nil, fmt.Errorf("服务器ID不能为空")
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	state, err := loadState(s.client, guildID)
+	if err != nil {
+		return nil, err
+	}
+	if s.prune {
+		if err := state.loadBot(s.client); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &planner{
+		client:  s.client,
+		spec:    spec,
+		state:   state,
+		prune:   s.prune,
+		claimed: make(map[string]bool),
+		plan: &Plan{
+			GuildID:     guildID,
+			roleIDs:     make(map[string]int),
+			categoryIDs: make(map[string]string),
+			channelIDs:  make(map[string]string),
+		},
+	}
+	if err := p.build(); err != nil {
+		return nil, err
+	}
+	return p.plan, nil
+}
+
+// planner 生成计划
+type planner struct {
+	client  *kook.Client
+	spec    *Spec
+	state   *liveState
+	prune   bool
+	claimed map[string]bool // 已匹配到配置的频道ID
+	plan    *Plan
+
+	roles      []Step
+	categories []Step
+	channels   []Step
+	overwrites []Step
+	moves      []Step
+	guild      []Step
+	deletes    []Step
+}
+
+func (p *planner) build() error {
+	p.planRoles()
+	p.planCategories()
+
+	desired := p.desiredPaths()
+	for _, category := range p.spec.Categories {
+		if err := p.planChannels(category.Name, category.Channels, desired); err != nil {
+			return err
+		}
+	}
+	if err := p.planChannels("", p.spec.Channels, desired); err != nil {
+		return err
+	}
+
+	for _, category := range p.spec.Categories {
+		category := category
+		if err := p.planOverwrites(category.Name, p.plan.categoryIDs[category.Name], category.Overwrites, func(s *applyState) (string, error) {
+			return s.categoryID(category.Name)
+		}); err != nil {
+			return err
+		}
+		for _, channel := range category.Channels {
+			if err := p.planChannelOverwrites(channelPath(category.Name, channel.Name), channel.Overwrites); err != nil {
+				return err
+			}
+		}
+	}
+	for _, channel := range p.spec.Channels {
+		if err := p.planChannelOverwrites(channel.Name, channel.Overwrites); err != nil {
+			return err
+		}
+	}
+
+	p.planMove()
+	p.planGuild()
+	if p.prune {
+		p.planPrune()
+	}
+
+	for _, steps := range [][]Step{p.roles, p.categories, p.channels, p.overwrites, p.moves, p.guild, p.deletes} {
+		p.plan.Steps = append(p.plan.Steps, steps...)
+	}
+	return nil
+}
+
+// desiredPaths 配置中全部频道的路径
+func (p *planner) desiredPaths() map[string]bool {
+	paths := make(map[string]bool)
+	for _, category := range p.spec.Categories {
+		for _, channel := range category.Channels {
+			paths[channelPath(category.Name, channel.Name)] = true
+		}
+	}
+	for _, channel := range p.spec.Channels {
+		paths[channel.Name] = true
+	}
+	return paths
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func roleParams(role RoleSpec) kook.UpdateRoleParams {
+	return kook.UpdateRoleParams{
+		Name:        role.Name,
+		Color:       role.Color,
+		Hoist:       boolInt(role.Hoist),
+		Mentionable: boolInt(role.Mentionable),
+		Permissions: &role.Permissions,
+	}
+}
+
+func (p *planner) planRoles() {
+	for _, role := range p.spec.Roles {
+		role := role
+		existing, ok := p.state.roles[role.Name]
+		if !ok {
+			p.roles = append(p.roles, Step{
+				Action: ActionCreate,
+				Kind:   KindRole,
+				Target: role.Name,
+				Changes: []string{
+					fmt.Sprintf("color: %d", role.Color),
+					fmt.Sprintf("hoist: %t", role.Hoist),
+					fmt.Sprintf("mentionable: %t", role.Mentionable),
+					fmt.Sprintf("permissions: %s", role.Permissions),
+				},
+				apply: func(s *applyState) error {
+					created, err := s.client.Role.CreateRole(s.guildID, role.Name)
+					if err != nil {
+						return err
+					}
+					s.roleIDs[role.Name] = created.RoleID
+					_, err = s.client.Role.UpdateRole(s.guildID, created.RoleID, roleParams(role))
+					return err
+				},
+			})
+			continue
+		}
+
+		p.plan.roleIDs[role.Name] = existing.RoleID
+
+		var changes []string
+		if existing.Color != role.Color {
+			changes = append(changes, fieldChange("color", existing.Color, role.Color))
+		}
+		if existing.Hoist != boolInt(role.Hoist) {
+			changes = append(changes, fieldChange("hoist", existing.Hoist == 1, role.Hoist))
+		}
+		if existing.Mentionable != boolInt(role.Mentionable) {
+			changes = append(changes, fieldChange("mentionable", existing.Mentionable == 1, role.Mentionable))
+		}
+		if existing.Permissions != role.Permissions {
+			changes = append(changes, fieldChange("permissions", existing.Permissions, role.Permissions))
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		roleID := existing.RoleID
+		p.roles = append(p.roles, Step{
+			Action:  ActionUpdate,
+			Kind:    KindRole,
+			Target:  role.Name,
+			Changes: changes,
+			apply: func(s *applyState) error {
+				_, err := s.client.Role.UpdateRole(s.guildID, roleID, roleParams(role))
+				return err
+			},
+		})
+	}
+}
+
+func (p *planner) planCategories() {
+	for _, category := range p.spec.Categories {
+		name := category.Name
+		if existing, ok := p.state.categories[name]; ok {
+			p.plan.categoryIDs[name] = existing.ID
+			p.claimed[existing.ID] = true
+			continue
+		}
+
+		p.categories = append(p.categories, Step{
+			Action: ActionCreate,
+			Kind:   KindCategory,
+			Target: name,
+			apply: func(s *applyState) error {
+				created, err := s.client.Channel.CreateChannel(s.guildID, kook.CreateChannelParams{
+					Name:       name,
+					IsCategory: true,
+				})
+				if err != nil {
+					return err
+				}
+				s.categoryIDs[name] = created.ID
+				return nil
+			},
+		})
+	}
+}
+
+// findChannel 查找与配置对应的频道，找不到同路径的频道时，
+// 匹配同名且不在配置中其他位置的频道，视为被移动到了其他分组
+func (p *planner) findChannel(path, name string, desired map[string]bool) (kook.Channel, bool) {
+	if existing, ok := p.state.channels[path]; ok && !p.claimed[existing.ID] {
+		return existing, true
+	}
+	for _, candidate := range p.state.named[name] {
+		if p.claimed[candidate.ID] {
+			continue
+		}
+		if desired[channelPath(p.state.parents[candidate.ParentID], candidate.Name)] {
+			continue
+		}
+		return candidate, true
+	}
+	return kook.Channel{}, false
+}
+
+func (p *planner) planChannels(category string, specs []ChannelSpec, desired map[string]bool) error {
+	for _, channel := range specs {
+		channel := channel
+		path := channelPath(category, channel.Name)
+
+		existing, ok := p.findChannel(path, channel.Name, desired)
+		if !ok {
+			changes := []string{"type: " + channelTypeName(channel.channelType())}
+			if channel.Topic != "" {
+				changes = append(changes, "topic: "+channel.Topic)
+			}
+			if channel.SlowMode != 0 {
+				changes = append(changes, fmt.Sprintf("slow_mode: %d", channel.SlowMode))
+			}
+			p.channels = append(p.channels, Step{
+				Action:  ActionCreate,
+				Kind:    KindChannel,
+				Target:  path,
+				Changes: changes,
+				apply: func(s *applyState) error {
+					params := kook.CreateChannelParams{
+						Name: channel.Name,
+						Type: channel.channelType(),
+					}
+					if category != "" {
+						parentID, err := s.categoryID(category)
+						if err != nil {
+							return err
+						}
+						params.ParentID = parentID
+					}
+					if channel.Type == ChannelTypeVoice {
+						params.LimitAmount = channel.LimitAmount
+						params.VoiceQuality = channel.VoiceQuality
+					}
+
+					created, err := s.client.Channel.CreateChannel(s.guildID, params)
+					if err != nil {
+						return err
+					}
+					s.channelIDs[path] = created.ID
+
+					if channel.Topic == "" && channel.SlowMode == 0 {
+						return nil
+					}
+					_, err = s.client.Channel.UpdateChannel(created.ID, kook.UpdateChannelParams{
+						Topic:    channel.Topic,
+						SlowMode: channel.SlowMode,
+					})
+					return err
+				},
+			})
+			continue
+		}
+
+		p.claimed[existing.ID] = true
+		p.plan.channelIDs[path] = existing.ID
+
+		if existing.Type != channel.channelType() {
+			return fmt.Errorf("频道 %s 的类型为 %s，配置为 %s，需要手动处理",
+				path, channelTypeName(existing.Type), channelTypeName(channel.channelType()))
+		}
+
+		var changes []string
+		currentCategory := p.state.parents[existing.ParentID]
+		moved := currentCategory != category
+		if moved {
+			changes = append(changes, fieldChange("category", displayCategory(currentCategory), displayCategory(category)))
+		}
+		if channel.Topic != "" && existing.Topic != channel.Topic {
+			changes = append(changes, fieldChange("topic", existing.Topic, channel.Topic))
+		}
+		if existing.SlowMode != channel.SlowMode {
+			changes = append(changes, fieldChange("slow_mode", existing.SlowMode, channel.SlowMode))
+		}
+		if channel.Type == ChannelTypeVoice {
+			if channel.LimitAmount > 0 && existing.LimitAmount != channel.LimitAmount {
+				changes = append(changes, fieldChange("limit_amount", existing.LimitAmount, channel.LimitAmount))
+			}
+			if channel.VoiceQuality > 0 && existing.VoiceQuality != channel.VoiceQuality {
+				changes = append(changes, fieldChange("voice_quality", existing.VoiceQuality, channel.VoiceQuality))
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		channelID := existing.ID
+		p.channels = append(p.channels, Step{
+			Action:  ActionUpdate,
+			Kind:    KindChannel,
+			Target:  path,
+			Changes: changes,
+			apply: func(s *applyState) error {
+				params := kook.UpdateChannelParams{
+					Topic:    channel.Topic,
+					SlowMode: channel.SlowMode,
+				}
+				if channel.Type == ChannelTypeVoice {
+					params.LimitAmount = channel.LimitAmount
+					params.VoiceQuality = channel.VoiceQuality
+				}
+				if moved {
+					parentID, err := s.categoryID(category)
+					if err != nil {
+						return err
+					}
+					params.ParentID = parentID
+				}
+				_, err := s.client.Channel.UpdateChannel(channelID, params)
+				return err
+			},
+		})
+	}
+	return nil
+}
+
+func channelTypeName(channelType int) string {
+	if channelType == 2 {
+		return ChannelTypeVoice
+	}
+	return ChannelTypeText
+}
+
+func displayCategory(category string) string {
+	if category == "" {
+		return "(无)"
+	}
+	return category
+}
+
+func (p *planner) planChannelOverwrites(path string, specs []OverwriteSpec) error {
+	return p.planOverwrites(path, p.plan.channelIDs[path], specs, func(s *applyState) (string, error) {
+		return s.channelID(path)
+	})
+}
+
+// planOverwrites 生成频道或分组的权限覆写步骤，existingID 为空表示尚未创建
+func (p *planner) planOverwrites(target, existingID string, specs []OverwriteSpec, resolveID func(*applyState) (string, error)) error {
+	if specs == nil {
+		return nil
+	}
+
+	step := Step{
+		Action: ActionUpdate,
+		Kind:   KindOverwrites,
+		Target: target,
+		apply: func(s *applyState) error {
+			channelID, err := resolveID(s)
+			if err != nil {
+				return err
+			}
+			desired, err := s.overwrites(specs)
+			if err != nil {
+				return err
+			}
+			_, err = s.client.ChannelRole.Apply(channelID, desired, true)
+			return err
+		},
+	}
+
+	desired, err := resolveOverwrites(specs, p.plan.roleIDs)
+	if err != nil || existingID == "" {
+		// 引用了尚未创建的角色或频道，执行时再计算具体变更
+		for _, o := range specs {
+			step.Changes = append(step.Changes, fmt.Sprintf("%s allow=%s deny=%s", overwriteTarget(o), o.Allow, o.Deny))
+		}
+		p.overwrites = append(p.overwrites, step)
+		return nil
+	}
+
+	current, err := p.client.ChannelRole.List(existingID)
+	if err != nil {
+		return err
+	}
+	changes := kook.DiffOverwrites(current.Overwrites(), desired, true)
+	if len(changes) == 0 {
+		return nil
+	}
+	for _, change := range changes {
+		step.Changes = append(step.Changes, change.Action+" "+describeOverwrite(p.state, change.Overwrite))
+	}
+	p.overwrites = append(p.overwrites, step)
+	return nil
+}
+
+func overwriteTarget(o OverwriteSpec) string {
+	if o.User != "" {
+		return "user " + o.User
+	}
+	return "role " + o.Role
+}
+
+// orderItem 参与排序的分组或频道
+type orderItem struct {
+	category bool
+	path     string // 分组名称或频道路径
+}
+
+// id 返回计划阶段已知的ID
+func (o orderItem) id(plan *Plan) (string, bool) {
+	if o.category {
+		id, ok := plan.categoryIDs[o.path]
+		return id, ok
+	}
+	id, ok := plan.channelIDs[o.path]
+	return id, ok
+}
+
+// planMove 检查分组与频道的顺序，不一致时生成调整顺序的步骤
+func (p *planner) planMove() {
+	// 配置中的顺序：分组依次排列，每个分组后跟其下的频道，最后是不属于分组的频道
+	var order []orderItem
+	var groups [][]orderItem
+	var categories, topLevel []orderItem
+	for _, category := range p.spec.Categories {
+		item := orderItem{category: true, path: category.Name}
+		order = append(order, item)
+		categories = append(categories, item)
+
+		var children []orderItem
+		for _, channel := range category.Channels {
+			child := orderItem{path: channelPath(category.Name, channel.Name)}
+			order = append(order, child)
+			children = append(children, child)
+		}
+		groups = append(groups, children)
+	}
+	for _, channel := range p.spec.Channels {
+		item := orderItem{path: channel.Name}
+		order = append(order, item)
+		topLevel = append(topLevel, item)
+	}
+	groups = append(groups, categories, topLevel)
+
+	position := make(map[string]int, len(p.state.ordered))
+	for i, channel := range p.state.ordered {
+		position[channel.ID] = i
+	}
+
+	// 按实际位置排序后与配置比较，新建的对象会排在所在分组末尾
+	needMove := false
+	for _, items := range groups {
+		actual := append([]orderItem(nil), items...)
+		sort.SliceStable(actual, func(i, j int) bool {
+			idI, okI := actual[i].id(p.plan)
+			idJ, okJ := actual[j].id(p.plan)
+			if okI != okJ {
+				return okI
+			}
+			return okI && position[idI] < position[idJ]
+		})
+		for i := range items {
+			if items[i] != actual[i] {
+				needMove = true
+			}
+		}
+	}
+	if !needMove {
+		return
+	}
+
+	p.moves = append(p.moves, Step{
+		Action:  ActionMove,
+		Kind:    KindChannel,
+		Target:  "*",
+		Changes: []string{"按配置中的顺序排列分组与频道"},
+		apply: func(s *applyState) error {
+			channelIDs := make([]string, 0, len(order))
+			for _, item := range order {
+				var id string
+				var err error
+				if item.category {
+					id, err = s.categoryID(item.path)
+				} else {
+					id, err = s.channelID(item.path)
+				}
+				if err != nil {
+					return err
+				}
+				channelIDs = append(channelIDs, id)
+			}
+			return s.client.Channel.MoveChannel(s.guildID, channelIDs)
+		},
+	})
+}
+
+func (p *planner) planGuild() {
+	spec := p.spec.Guild
+	if spec == nil {
+		return
+	}
+	guild := p.state.guild
+
+	var changes []string
+	if spec.Name != "" && guild.Name != spec.Name {
+		changes = append(changes, fieldChange("name", guild.Name, spec.Name))
+	}
+	if spec.Region != "" && guild.Region != spec.Region {
+		changes = append(changes, fieldChange("region", guild.Region, spec.Region))
+	}
+	if spec.NotifyType != nil && guild.NotifyType != *spec.NotifyType {
+		changes = append(changes, fieldChange("notify_type", guild.NotifyType, *spec.NotifyType))
+	}
+	if spec.DefaultChannel != "" && guild.DefaultChannelID != p.plan.channelIDs[spec.DefaultChannel] {
+		changes = append(changes, fieldChange("default_channel", guild.DefaultChannelID, spec.DefaultChannel))
+	}
+	if spec.WelcomeChannel != "" && guild.WelcomeChannelID != p.plan.channelIDs[spec.WelcomeChannel] {
+		changes = append(changes, fieldChange("welcome_channel", guild.WelcomeChannelID, spec.WelcomeChannel))
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	p.guild = append(p.guild, Step{
+		Action:  ActionUpdate,
+		Kind:    KindGuild,
+		Target:  guild.Name,
+		Changes: changes,
+		apply: func(s *applyState) error {
+			params := kook.UpdateGuildParams{
+				Name:       spec.Name,
+				Region:     spec.Region,
+				NotifyType: -1,
+			}
+			if spec.NotifyType != nil {
+				params.NotifyType = *spec.NotifyType
+			}
+			if spec.DefaultChannel != "" {
+				id, err := s.channelID(spec.DefaultChannel)
+				if err != nil {
+					return err
+				}
+				params.DefaultChannelID = id
+			}
+			if spec.WelcomeChannel != "" {
+				id, err := s.channelID(spec.WelcomeChannel)
+				if err != nil {
+					return err
+				}
+				params.WelcomeChannelID = id
+			}
+			_, err := s.client.Guild.UpdateGuild(s.guildID, params)
+			return err
+		},
+	})
+}
+
+// planPrune 删除配置中不存在的频道、分组和角色
+//
+// 机器人自己的角色、其他机器人的角色以及不低于机器人最高角色的角色无法删除，
+// 这些角色不生成步骤，记录在 Plan.Unmanaged 中。
+func (p *planner) planPrune() {
+	var categories []Step
+	for _, channel := range p.state.ordered {
+		if p.claimed[channel.ID] {
+			continue
+		}
+		channelID := channel.ID
+		step := Step{
+			Action: ActionDelete,
+			Kind:   KindChannel,
+			Target: channelPath(p.state.parents[channel.ParentID], channel.Name),
+			apply: func(s *applyState) error {
+				return s.client.Channel.DeleteChannel(channelID)
+			},
+		}
+		if channel.IsCategory {
+			step.Kind = KindCategory
+			step.Target = channel.Name
+			categories = append(categories, step)
+			continue
+		}
+		p.deletes = append(p.deletes, step)
+	}
+	// 先删除频道再删除分组
+	p.deletes = append(p.deletes, categories...)
+
+	managed := make(map[string]bool, len(p.spec.Roles))
+	for _, role := range p.spec.Roles {
+		managed[role.Name] = true
+	}
+	for _, role := range p.state.roleOrder {
+		if managed[role.Name] && p.plan.roleIDs[role.Name] == role.RoleID {
+			continue
+		}
+		if !p.state.manageable(role) {
+			p.plan.Unmanaged = append(p.plan.Unmanaged, role.Name)
+			continue
+		}
+		roleID := role.RoleID
+		p.deletes = append(p.deletes, Step{
+			Action: ActionDelete,
+			Kind:   KindRole,
+			Target: role.Name,
+			apply: func(s *applyState) error {
+				return s.client.Role.DeleteRole(s.guildID, roleID)
+			},
+		})
+	}
+}
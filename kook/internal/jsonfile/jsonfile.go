@@ -0,0 +1,162 @@
+// Package jsonfile 以单个JSON文件保存数据，供各模块的文件存储使用
+package jsonfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// File 保存一个 T 类型值的JSON文件，同一个 File 上的操作互斥执行
+type File[T any] struct {
+	path string
+	name string
+	mu   sync.Mutex
+}
+
+// New 创建文件，name 为数据名称，用于错误信息，如 "任务"
+func New[T any](path, name string) *File[T] {
+	return &File[T]{path: path, name: name}
+}
+
+// Read 读取文件，文件不存在时返回零值
+func (f *File[T]) Read() (T, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.read()
+}
+
+// Write 写入文件
+func (f *File[T]) Write(v T) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.write(v)
+}
+
+// Update 读取文件并交给 fn 修改，fn 返回 true 时写回
+func (f *File[T]) Update(fn func(v *T) bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	v, err := f.read()
+	if err != nil {
+		return err
+	}
+	if !fn(&v) {
+		return nil
+	}
+	return f.write(v)
+}
+
+// Remove 删除文件，文件不存在时不报错
+func (f *File[T]) Remove() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("删除%s文件失败: %w", f.name, err)
+	}
+	return nil
+}
+
+func (f *File[T]) read() (T, error) {
+	var v T
+
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return v, fmt.Errorf("读取%s文件失败: %w", f.name, err)
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("解析%s文件失败: %w", f.name, err)
+	}
+	return v, nil
+}
+
+// write 先写入临时文件再重命名，避免进程退出时留下不完整的文件
+func (f *File[T]) write(v T) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化%s失败: %w", f.name, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("创建%s文件失败: %w", f.name, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入%s文件失败: %w", f.name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入%s文件失败: %w", f.name, err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("保存%s文件失败: %w", f.name, err)
+	}
+	return nil
+}
+
+// Map 以键值对形式保存记录的JSON文件
+type Map[V any] struct {
+	file *File[map[string]*V]
+}
+
+// NewMap 创建键值对文件，name 为记录名称，用于错误信息
+func NewMap[V any](path, name string) *Map[V] {
+	return &Map[V]{file: New[map[string]*V](path, name)}
+}
+
+// Load 读取记录，不存在时返回 nil, nil
+func (m *Map[V]) Load(key string) (*V, error) {
+	records, err := m.file.Read()
+	if err != nil {
+		return nil, err
+	}
+	return records[key], nil
+}
+
+// Save 保存记录，已存在时覆盖
+func (m *Map[V]) Save(key string, v *V) error {
+	return m.file.Update(func(records *map[string]*V) bool {
+		if *records == nil {
+			*records = make(map[string]*V)
+		}
+		(*records)[key] = v
+		return true
+	})
+}
+
+// Delete 删除记录，不存在时不报错
+func (m *Map[V]) Delete(key string) error {
+	return m.file.Update(func(records *map[string]*V) bool {
+		if _, ok := (*records)[key]; !ok {
+			return false
+		}
+		delete(*records, key)
+		return true
+	})
+}
+
+// List 列出所有记录，顺序不固定
+func (m *Map[V]) List() ([]*V, error) {
+	records, err := m.file.Read()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*V, 0, len(records))
+	for _, v := range records {
+		list = append(list, v)
+	}
+	return list, nil
+}
@@ -0,0 +1,77 @@
+package jsonfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type record struct {
+	Name string `json:"name"`
+}
+
+func TestFileMissingReturnsZero(t *testing.T) {
+	f := New[*record](filepath.Join(t.TempDir(), "missing.json"), "记录")
+
+	v, err := f.Read()
+	require.NoError(t, err)
+	assert.Nil(t, v)
+	assert.NoError(t, f.Remove())
+}
+
+func TestFileWriteReadRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "record.json")
+	f := New[*record](path, "记录")
+
+	require.NoError(t, f.Write(&record{Name: "a"}))
+	v, err := f.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "a", v.Name)
+
+	// 不留下临时文件
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	require.NoError(t, f.Remove())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFileCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "record.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+
+	_, err := New[*record](path, "记录").Read()
+	assert.ErrorContains(t, err, "解析记录文件失败")
+}
+
+func TestMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.json")
+	m := NewMap[record](path, "记录")
+
+	// 删除不存在的记录不创建文件
+	require.NoError(t, m.Delete("a"))
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, m.Save("a", &record{Name: "a"}))
+	require.NoError(t, m.Save("b", &record{Name: "b"}))
+
+	v, err := NewMap[record](path, "记录").Load("a")
+	require.NoError(t, err)
+	assert.Equal(t, "a", v.Name)
+
+	missing, err := m.Load("c")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	require.NoError(t, m.Delete("a"))
+	list, err := m.List()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "b", list[0].Name)
+}
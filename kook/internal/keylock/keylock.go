@@ -0,0 +1,45 @@
+// Package keylock 按键加锁，同一个键上的操作依次执行，不同键之间互不影响
+package keylock
+
+import "sync"
+
+// Locker 按键加锁的互斥锁集合，零值可直接使用
+//
+// 每个键的锁在没有持有者时即被移除，键的数量不会无限增长。
+type Locker struct {
+	mu    sync.Mutex
+	locks map[string]*entry
+}
+
+// entry 单个键的锁
+type entry struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// Lock 锁定键，返回解锁函数
+func (l *Locker) Lock(key string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*entry)
+	}
+	e, ok := l.locks[key]
+	if !ok {
+		e = &entry{}
+		l.locks[key] = e
+	}
+	e.refs++
+	l.mu.Unlock()
+
+	e.mu.Lock()
+	return func() {
+		e.mu.Unlock()
+
+		l.mu.Lock()
+		e.refs--
+		if e.refs == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
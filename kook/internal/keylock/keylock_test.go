@@ -0,0 +1,37 @@
+package keylock
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockerSerializesKey(t *testing.T) {
+	var l Locker
+	var wg sync.WaitGroup
+	count := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := l.Lock("a")
+			defer unlock()
+			count++
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 50, count)
+	assert.Empty(t, l.locks)
+}
+
+func TestLockerIndependentKeys(t *testing.T) {
+	var l Locker
+	unlockA := l.Lock("a")
+	defer unlockA()
+
+	// 其他键不受已持有的锁影响
+	unlockB := l.Lock("b")
+	unlockB()
+}
@@ -0,0 +1,194 @@
+package kook
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"kook-go-sdk/kook/internal/jsonfile"
+)
+
+// Job 定时任务
+//
+// 任务只保存处理器名称与参数，执行时按 Name 查找通过 Scheduler.Handle 注册的处理器，
+// 因此可以持久化并在重启后继续执行。
+type Job struct {
+	ID        string          `json:"id"`                   // 任务ID
+	Name      string          `json:"name"`                 // 处理器名称
+	Spec      string          `json:"spec,omitempty"`       // cron表达式，为空时为一次性任务
+	Payload   json.RawMessage `json:"payload,omitempty"`    // 任务参数
+	RunAt     time.Time       `json:"run_at"`               // 下次执行时间
+	Attempt   int             `json:"attempt,omitempty"`    // 本次执行已重试的次数
+	LastRun   time.Time       `json:"last_run"`             // 上次执行时间
+	LastError string          `json:"last_error,omitempty"` // 上次执行的错误
+	CreatedAt time.Time       `json:"created_at"`           // 创建时间
+}
+
+// Recurring 是否为周期任务
+func (j *Job) Recurring() bool {
+	return j.Spec != ""
+}
+
+// Decode 将任务参数解析到 v
+func (j *Job) Decode(v interface{}) error {
+	if len(j.Payload) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return fmt.Errorf("解析任务参数失败: %w", err)
+	}
+	return nil
+}
+
+// copyJob 复制任务，避免调用方修改存储中的数据
+func copyJob(j *Job) *Job {
+	copied := *j
+	copied.Payload = append(json.RawMessage(nil), j.Payload...)
+	return &copied
+}
+
+// JobStore 定时任务存储
+//
+// 调度器启动时加载全部任务，之后每次任务变化都会写入存储。
+type JobStore interface {
+	// Load 读取任务，不存在时返回 nil, nil
+	Load(id string) (*Job, error)
+	// Save 保存任务，已存在时覆盖
+	Save(job *Job) error
+	// Delete 删除任务，不存在时不报错
+	Delete(id string) error
+	// List 列出所有任务
+	List() ([]*Job, error)
+}
+
+// MemoryJobStore 内存任务存储，只在进程内有效
+type MemoryJobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// NewMemoryJobStore 创建内存任务存储
+func NewMemoryJobStore() *MemoryJobStore {
+	return &MemoryJobStore{jobs: make(map[string]*Job)}
+}
+
+// Load 读取任务
+func (s *MemoryJobStore) Load(id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyJob(job), nil
+}
+
+// Save 保存任务
+func (s *MemoryJobStore) Save(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[job.ID] = copyJob(job)
+	return nil
+}
+
+// Delete 删除任务
+func (s *MemoryJobStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.jobs, id)
+	return nil
+}
+
+// List 列出所有任务，按执行时间排序
+func (s *MemoryJobStore) List() ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, copyJob(job))
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+// FileJobStore 文件任务存储，所有任务按执行时间排序后以JSON数组保存在同一个文件中，进程重启后仍可恢复
+type FileJobStore struct {
+	file *jsonfile.File[[]*Job]
+}
+
+// NewFileJobStore 创建文件任务存储
+func NewFileJobStore(path string) *FileJobStore {
+	return &FileJobStore{file: jsonfile.New[[]*Job](path, "任务")}
+}
+
+// Load 读取任务
+func (s *FileJobStore) Load(id string) (*Job, error) {
+	jobs, err := s.file.Read()
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if job.ID == id {
+			return job, nil
+		}
+	}
+	return nil, nil
+}
+
+// Save 保存任务
+func (s *FileJobStore) Save(job *Job) error {
+	return s.file.Update(func(jobs *[]*Job) bool {
+		*jobs = append(removeJob(*jobs, job.ID), copyJob(job))
+		sortJobs(*jobs)
+		return true
+	})
+}
+
+// Delete 删除任务
+func (s *FileJobStore) Delete(id string) error {
+	return s.file.Update(func(jobs *[]*Job) bool {
+		n := len(*jobs)
+		*jobs = removeJob(*jobs, id)
+		return len(*jobs) != n
+	})
+}
+
+// List 列出所有任务，按执行时间排序
+func (s *FileJobStore) List() ([]*Job, error) {
+	jobs, err := s.file.Read()
+	if err != nil {
+		return nil, err
+	}
+	if jobs == nil {
+		jobs = []*Job{}
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+// removeJob 移除指定ID的任务
+func removeJob(jobs []*Job, id string) []*Job {
+	kept := jobs[:0]
+	for _, job := range jobs {
+		if job.ID != id {
+			kept = append(kept, job)
+		}
+	}
+	return kept
+}
+
+// sortJobs 按执行时间排序，时间相同时按ID排序
+func sortJobs(jobs []*Job) {
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].RunAt.Equal(jobs[j].RunAt) {
+			return jobs[i].RunAt.Before(jobs[j].RunAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+}
@@ -0,0 +1,275 @@
+package kook
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogLevel 日志级别
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota // 调试
+	LogLevelInfo                  // 信息
+	LogLevelWarn                  // 警告
+	LogLevelError                 // 错误
+	LogLevelOff                   // 关闭日志
+)
+
+// String 返回日志级别名称
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	case LogLevelOff:
+		return "off"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// 日志子系统
+const (
+	LogSubsystemHTTP      = "http"      // REST API 请求
+	LogSubsystemGateway   = "gateway"   // WebSocket 网关
+	LogSubsystemWebhook   = "webhook"   // Webhook 回调
+	LogSubsystemScheduler = "scheduler" // 定时任务
+	LogSubsystemAuditLog  = "audit_log" // 审计日志轮询
+)
+
+// Field 结构化日志字段
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F 创建日志字段
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// ErrField 创建错误日志字段
+func ErrField(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+// Logger 日志接口
+//
+// SDK 内部所有日志都通过该接口输出，可使用 NewSlogLogger、NewLogrusLogger
+// 适配已有的日志库，或自行实现。
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+	// With 返回附带固定字段的子日志器
+	With(fields ...Field) Logger
+}
+
+// leveledLogger 按级别过滤的日志器
+type leveledLogger struct {
+	logger Logger
+	level  LogLevel
+}
+
+// NewLeveledLogger 创建只输出指定级别及以上日志的日志器
+func NewLeveledLogger(logger Logger, level LogLevel) Logger {
+	return &leveledLogger{logger: logger, level: level}
+}
+
+// Debug 输出调试日志
+func (l *leveledLogger) Debug(msg string, fields ...Field) {
+	if l.level <= LogLevelDebug {
+		l.logger.Debug(msg, fields...)
+	}
+}
+
+// Info 输出信息日志
+func (l *leveledLogger) Info(msg string, fields ...Field) {
+	if l.level <= LogLevelInfo {
+		l.logger.Info(msg, fields...)
+	}
+}
+
+// Warn 输出警告日志
+func (l *leveledLogger) Warn(msg string, fields ...Field) {
+	if l.level <= LogLevelWarn {
+		l.logger.Warn(msg, fields...)
+	}
+}
+
+// Error 输出错误日志
+func (l *leveledLogger) Error(msg string, fields ...Field) {
+	if l.level <= LogLevelError {
+		l.logger.Error(msg, fields...)
+	}
+}
+
+// With 返回附带固定字段的子日志器
+func (l *leveledLogger) With(fields ...Field) Logger {
+	return &leveledLogger{logger: l.logger.With(fields...), level: l.level}
+}
+
+// nopLogger 丢弃所有日志
+type nopLogger struct{}
+
+// NewNopLogger 创建丢弃所有日志的日志器
+func NewNopLogger() Logger {
+	return nopLogger{}
+}
+
+func (nopLogger) Debug(string, ...Field) {}
+func (nopLogger) Info(string, ...Field)  {}
+func (nopLogger) Warn(string, ...Field)  {}
+func (nopLogger) Error(string, ...Field) {}
+func (n nopLogger) With(...Field) Logger { return n }
+
+// slogLogger log/slog 适配器
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger 使用 log/slog 创建日志器
+func NewSlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &slogLogger{logger: logger}
+}
+
+func (l *slogLogger) log(level slog.Level, msg string, fields []Field) {
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	l.logger.LogAttrs(ctx, level, msg, slogAttrs(fields)...)
+}
+
+// Debug 输出调试日志
+func (l *slogLogger) Debug(msg string, fields ...Field) { l.log(slog.LevelDebug, msg, fields) }
+
+// Info 输出信息日志
+func (l *slogLogger) Info(msg string, fields ...Field) { l.log(slog.LevelInfo, msg, fields) }
+
+// Warn 输出警告日志
+func (l *slogLogger) Warn(msg string, fields ...Field) { l.log(slog.LevelWarn, msg, fields) }
+
+// Error 输出错误日志
+func (l *slogLogger) Error(msg string, fields ...Field) { l.log(slog.LevelError, msg, fields) }
+
+// With 返回附带固定字段的子日志器
+func (l *slogLogger) With(fields ...Field) Logger {
+	args := make([]any, 0, len(fields))
+	for _, attr := range slogAttrs(fields) {
+		args = append(args, attr)
+	}
+	return &slogLogger{logger: l.logger.With(args...)}
+}
+
+func slogAttrs(fields []Field) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		attrs = append(attrs, slog.Any(f.Key, f.Value))
+	}
+	return attrs
+}
+
+// logrusLogger logrus 适配器
+type logrusLogger struct {
+	entry *logrus.Entry
+}
+
+// NewLogrusLogger 使用 logrus 创建日志器
+func NewLogrusLogger(logger *logrus.Logger) Logger {
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	return &logrusLogger{entry: logrus.NewEntry(logger)}
+}
+
+func (l *logrusLogger) withFields(fields []Field) *logrus.Entry {
+	if len(fields) == 0 {
+		return l.entry
+	}
+	data := make(logrus.Fields, len(fields))
+	for _, f := range fields {
+		if f.Key == "error" {
+			data[logrus.ErrorKey] = f.Value
+			continue
+		}
+		data[f.Key] = f.Value
+	}
+	return l.entry.WithFields(data)
+}
+
+// Debug 输出调试日志
+func (l *logrusLogger) Debug(msg string, fields ...Field) { l.withFields(fields).Debug(msg) }
+
+// Info 输出信息日志
+func (l *logrusLogger) Info(msg string, fields ...Field) { l.withFields(fields).Info(msg) }
+
+// Warn 输出警告日志
+func (l *logrusLogger) Warn(msg string, fields ...Field) { l.withFields(fields).Warn(msg) }
+
+// Error 输出错误日志
+func (l *logrusLogger) Error(msg string, fields ...Field) { l.withFields(fields).Error(msg) }
+
+// With 返回附带固定字段的子日志器
+func (l *logrusLogger) With(fields ...Field) Logger {
+	return &logrusLogger{entry: l.withFields(fields)}
+}
+
+// newDefaultLogger 创建默认日志器
+func newDefaultLogger() Logger {
+	logger := logrus.New()
+	logger.SetLevel(logrus.DebugLevel)
+	logger.SetFormatter(&logrus.TextFormatter{
+		TimestampFormat: "2006-01-02 15:04:05",
+		FullTimestamp:   true,
+	})
+	return NewLogrusLogger(logger)
+}
+
+// 日志脱敏
+
+const redactedValue = "[REDACTED]"
+
+// redactHeaders 复制请求头并脱敏鉴权信息
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	if auth := redacted.Get("Authorization"); auth != "" {
+		if scheme, _, ok := strings.Cut(auth, " "); ok {
+			redacted.Set("Authorization", scheme+" "+redactedValue)
+		} else {
+			redacted.Set("Authorization", redactedValue)
+		}
+	}
+	return redacted
+}
+
+// redactURL 脱敏URL查询参数中的Token
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	if q.Get("token") == "" {
+		return rawURL
+	}
+	q.Set("token", redactedValue)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
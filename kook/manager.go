@@ -0,0 +1,369 @@
+package kook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// botContextKey 事件上下文中机器人名称的键
+type botContextKey struct{}
+
+// BotNameFromContext 返回收到事件的机器人名称
+//
+// 通过 GatewayManager 收到的事件，其 event.Context() 中带有机器人名称，
+// 在处理器中调用 BotNameFromContext(event.Context()) 即可区分来源。
+func BotNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(botContextKey{}).(string)
+	return name
+}
+
+// BotConfig 机器人配置
+type BotConfig struct {
+	Name          string         // 机器人名称，在管理器中唯一
+	Token         string         // 机器人令牌
+	Compress      bool           // 网关是否启用压缩
+	ClientOptions []ClientOption // 该机器人额外的客户端选项
+	// GatewayOptions 该机器人的网关选项，如 WithSessionStore
+	GatewayOptions []WebSocketOption
+}
+
+// Bot 管理器中的机器人
+type Bot struct {
+	Name    string           // 机器人名称
+	Client  *Client          // 该机器人的API客户端
+	Gateway *WebSocketClient // 该机器人的网关连接
+
+	mu          sync.Mutex
+	connectedAt time.Time
+	lastError   error
+}
+
+// BotHealth 单个机器人的健康状态
+type BotHealth struct {
+	Name        string    `json:"name"`                   // 机器人名称
+	Connected   bool      `json:"connected"`              // 网关是否已连接
+	ConnectedAt time.Time `json:"connected_at,omitempty"` // 最近一次连接成功的时间
+	LastError   string    `json:"last_error,omitempty"`   // 最近一次连接错误
+}
+
+// ManagerHealth 管理器的汇总健康状态
+type ManagerHealth struct {
+	Total     int         `json:"total"`     // 机器人总数
+	Connected int         `json:"connected"` // 已连接的机器人数
+	Bots      []BotHealth `json:"bots"`      // 各机器人的状态，按名称排序
+}
+
+// Healthy 判断是否所有机器人都已连接
+func (h ManagerHealth) Healthy() bool {
+	return h.Total > 0 && h.Connected == h.Total
+}
+
+// GatewayManager 多机器人网关管理器
+//
+// 管理多个机器人的 Client 与 WebSocketClient，将所有机器人收到的事件汇总到同一组处理器，
+// 连接时按固定间隔错开，支持运行中添加和移除机器人。
+type GatewayManager struct {
+	logger          Logger
+	clientOptions   []ClientOption
+	connectInterval time.Duration
+
+	mu          sync.Mutex
+	bots        map[string]*Bot
+	handlers    map[int][]EventHandler
+	streamSlot  hubSlot
+	started     bool
+	closed      bool
+	nextConnect time.Time
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// GatewayManagerOption 网关管理器配置选项
+type GatewayManagerOption func(*GatewayManager)
+
+// WithConnectInterval 设置相邻两个机器人连接网关的最小间隔，默认5秒
+func WithConnectInterval(interval time.Duration) GatewayManagerOption {
+	return func(m *GatewayManager) {
+		m.connectInterval = interval
+	}
+}
+
+// WithBotClientOptions 设置所有机器人共用的客户端选项
+func WithBotClientOptions(options ...ClientOption) GatewayManagerOption {
+	return func(m *GatewayManager) {
+		m.clientOptions = append(m.clientOptions, options...)
+	}
+}
+
+// WithManagerLogger 设置管理器的日志器
+func WithManagerLogger(logger Logger) GatewayManagerOption {
+	return func(m *GatewayManager) {
+		m.logger = logger
+	}
+}
+
+// NewGatewayManager 创建多机器人网关管理器
+func NewGatewayManager(options ...GatewayManagerOption) *GatewayManager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m := &GatewayManager{
+		connectInterval: 5 * time.Second,
+		bots:            make(map[string]*Bot),
+		handlers:        make(map[int][]EventHandler),
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+	for _, option := range options {
+		option(m)
+	}
+	if m.logger == nil {
+		m.logger = NewLeveledLogger(newDefaultLogger(), LogLevelInfo)
+	}
+	return m
+}
+
+// OnEvent 注册事件处理器，所有机器人收到的事件都会调用该处理器
+func (m *GatewayManager) OnEvent(eventType int, handler EventHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.handlers[eventType] = append(m.handlers[eventType], handler)
+	for _, bot := range m.bots {
+		bot.Gateway.OnEvent(eventType, handler)
+	}
+}
+
+// streamHub 实现 hubSource，事件流共用同一组处理器
+func (m *GatewayManager) streamHub() *streamHub {
+	return m.streamSlot.get(m)
+}
+
+// Add 添加机器人，管理器已启动时会立即（按连接间隔）连接网关，管理器关闭后不能再添加
+func (m *GatewayManager) Add(config BotConfig) (*Bot, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("机器人名称不能为空")
+	}
+	if config.Token == "" {
+		return nil, fmt.Errorf("机器人令牌不能为空")
+	}
+
+	options := append(append([]ClientOption(nil), m.clientOptions...), config.ClientOptions...)
+	client := NewClient(config.Token, options...)
+	client.logger = client.logger.With(F("bot", config.Name))
+	client = client.WithContext(context.WithValue(client.Context(), botContextKey{}, config.Name))
+
+	bot := &Bot{
+		Name:    config.Name,
+		Client:  client,
+		Gateway: NewWebSocketClient(client, config.Compress, config.GatewayOptions...),
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("网关管理器已关闭")
+	}
+	if _, exists := m.bots[config.Name]; exists {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("机器人 %s 已存在", config.Name)
+	}
+	for eventType, handlers := range m.handlers {
+		for _, handler := range handlers {
+			bot.Gateway.OnEvent(eventType, handler)
+		}
+	}
+	m.bots[config.Name] = bot
+	started := m.started
+	m.mu.Unlock()
+
+	m.logger.Info("添加机器人", F("bot", config.Name))
+
+	if started {
+		go m.connect(bot, m.reserveSlot())
+	}
+	return bot, nil
+}
+
+// Remove 移除机器人并关闭其网关连接
+func (m *GatewayManager) Remove(name string) error {
+	m.mu.Lock()
+	bot, ok := m.bots[name]
+	delete(m.bots, name)
+	m.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("机器人 %s 不存在", name)
+	}
+
+	m.logger.Info("移除机器人", F("bot", name))
+	return bot.Gateway.Close()
+}
+
+// Bot 根据名称获取机器人
+func (m *GatewayManager) Bot(name string) (*Bot, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bot, ok := m.bots[name]
+	return bot, ok
+}
+
+// Bots 获取所有机器人，按名称排序
+func (m *GatewayManager) Bots() []*Bot {
+	m.mu.Lock()
+	bots := make([]*Bot, 0, len(m.bots))
+	for _, bot := range m.bots {
+		bots = append(bots, bot)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(bots, func(i, j int) bool {
+		return bots[i].Name < bots[j].Name
+	})
+	return bots
+}
+
+// Start 按连接间隔错开连接所有机器人的网关，等待全部连接结束后返回连接失败的错误汇总
+//
+// 各机器人在各自的协程中连接，单个机器人连接失败或重试不影响其他机器人，
+// 之后添加的机器人会自动连接。
+func (m *GatewayManager) Start() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return fmt.Errorf("网关管理器已关闭")
+	}
+	if m.started {
+		m.mu.Unlock()
+		return fmt.Errorf("网关管理器已启动")
+	}
+	m.started = true
+	m.mu.Unlock()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, bot := range m.Bots() {
+		bot, slot := bot, m.reserveSlot()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.connect(bot, slot); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("机器人 %s: %w", bot.Name, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// reserveSlot 分配下一个连接时间，相邻两次至少间隔 connectInterval
+func (m *GatewayManager) reserveSlot() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	slot := m.nextConnect
+	if now := time.Now(); slot.Before(now) {
+		slot = now
+	}
+	m.nextConnect = slot.Add(m.connectInterval)
+	return slot
+}
+
+// connect 等到 slot 时连接机器人的网关
+func (m *GatewayManager) connect(bot *Bot, slot time.Time) error {
+	if wait := time.Until(slot); wait > 0 {
+		select {
+		case <-m.ctx.Done():
+			return m.ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+
+	// 等待期间可能已被移除
+	if current, ok := m.Bot(bot.Name); !ok || current != bot {
+		return nil
+	}
+
+	if err := m.ctx.Err(); err != nil {
+		return err
+	}
+
+	m.logger.Info("连接机器人网关", F("bot", bot.Name))
+	err := bot.Gateway.Connect()
+	if err == nil && m.ctx.Err() != nil {
+		// 连接期间管理器已关闭
+		bot.Gateway.Close()
+		return m.ctx.Err()
+	}
+
+	bot.mu.Lock()
+	bot.lastError = err
+	if err == nil {
+		bot.connectedAt = time.Now()
+	}
+	bot.mu.Unlock()
+
+	if err != nil {
+		m.logger.Error("机器人网关连接失败", F("bot", bot.Name), ErrField(err))
+	}
+	return err
+}
+
+// Health 获取所有机器人的健康状态
+func (m *GatewayManager) Health() ManagerHealth {
+	bots := m.Bots()
+	health := ManagerHealth{
+		Total: len(bots),
+		Bots:  make([]BotHealth, 0, len(bots)),
+	}
+
+	for _, bot := range bots {
+		status := bot.Health()
+		if status.Connected {
+			health.Connected++
+		}
+		health.Bots = append(health.Bots, status)
+	}
+	return health
+}
+
+// Health 获取机器人的健康状态
+func (b *Bot) Health() BotHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BotHealth{
+		Name:        b.Name,
+		Connected:   b.Gateway.IsConnected(),
+		ConnectedAt: b.connectedAt,
+	}
+	if b.lastError != nil {
+		status.LastError = b.lastError.Error()
+	}
+	return status
+}
+
+// Close 关闭所有机器人的网关连接，关闭后不能再添加或启动
+func (m *GatewayManager) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+	m.cancel()
+
+	var errs []error
+	for _, bot := range m.Bots() {
+		if err := bot.Gateway.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("机器人 %s: %w", bot.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
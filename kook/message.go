@@ -12,6 +12,9 @@ type MessageService struct {
 }
 
 // SendMessage 发送消息
+//
+// Type 为 "private" 时发送私聊消息；需要 chat_code 寻址或模板消息时请使用 DirectMessageService。
+// 返回的消息只包含 ID 与 CreateAt。
 func (s *MessageService) SendMessage(params SendMessageParams) (*Message, error) {
 	var endpoint string
 	requestParams := make(map[string]interface{})
@@ -63,6 +66,16 @@ func (s *MessageService) SendMessage(params SendMessageParams) (*Message, error)
 		return nil, fmt.Errorf("解析消息失败: %w", err)
 	}
 
+	// 发送接口只返回 msg_id 与 msg_timestamp
+	var created struct {
+		MsgID        string `json:"msg_id"`
+		MsgTimestamp int64  `json:"msg_timestamp"`
+	}
+	if err := json.Unmarshal(resp.Data, &created); err == nil && message.ID == "" {
+		message.ID = created.MsgID
+		message.CreateAt = created.MsgTimestamp
+	}
+
 	return &message, nil
 }
 
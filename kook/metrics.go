@@ -0,0 +1,335 @@
+package kook
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 指标名称常量
+const (
+	MetricAPIRequests        = "kook_api_requests_total"                // API请求次数
+	MetricAPIErrors          = "kook_api_errors_total"                  // API错误次数（按错误码）
+	MetricAPIRequestDuration = "kook_api_request_duration_seconds"      // API请求耗时
+	MetricAPIRetries         = "kook_api_retries_total"                 // API重试次数
+	MetricRateLimitWait      = "kook_ratelimit_wait_seconds"            // 速率限制等待时间
+	MetricGatewayConnects    = "kook_gateway_connects_total"            // 网关连接次数
+	MetricGatewayReconnects  = "kook_gateway_reconnects_total"          // 网关重连次数
+	MetricGatewayConnected   = "kook_gateway_connected"                 // 网关连接状态
+	MetricGatewayHeartbeat   = "kook_gateway_heartbeat_latency_seconds" // 心跳往返延迟
+	MetricEventsReceived     = "kook_events_received_total"             // 收到的事件数
+	MetricHandlerDuration    = "kook_event_handler_duration_seconds"    // 事件处理器耗时
+	MetricHandlerPanics      = "kook_event_handler_panics_total"        // 事件处理器panic次数
+)
+
+// metricHelp 指标说明
+var metricHelp = map[string]string{
+	MetricAPIRequests:        "Total number of KOOK API requests.",
+	MetricAPIErrors:          "Total number of KOOK API errors by code.",
+	MetricAPIRequestDuration: "KOOK API request latency in seconds.",
+	MetricAPIRetries:         "Total number of KOOK API request retries.",
+	MetricRateLimitWait:      "Time spent waiting for the client side rate limiter in seconds.",
+	MetricGatewayConnects:    "Total number of gateway connection attempts.",
+	MetricGatewayReconnects:  "Total number of gateway reconnect attempts.",
+	MetricGatewayConnected:   "Whether the gateway connection is established (1) or not (0).",
+	MetricGatewayHeartbeat:   "Gateway heartbeat round trip latency in seconds.",
+	MetricEventsReceived:     "Total number of events received.",
+	MetricHandlerDuration:    "Event handler execution time in seconds.",
+	MetricHandlerPanics:      "Total number of event handler panics.",
+}
+
+// Labels 指标标签
+type Labels map[string]string
+
+// Metrics 指标收集接口
+//
+// SDK 在 HTTP 请求、重试、速率限制、网关连接和事件分发处上报指标。
+// 内置 PrometheusMetrics 和 ExpvarMetrics 两种实现，也可自行适配其他监控系统。
+type Metrics interface {
+	// IncCounter 计数器加一
+	IncCounter(name string, labels Labels)
+	// SetGauge 设置仪表值
+	SetGauge(name string, value float64, labels Labels)
+	// ObserveDuration 记录一次耗时
+	ObserveDuration(name string, d time.Duration, labels Labels)
+}
+
+// nopMetrics 丢弃所有指标
+type nopMetrics struct{}
+
+// NewNopMetrics 创建丢弃所有指标的收集器
+func NewNopMetrics() Metrics {
+	return nopMetrics{}
+}
+
+func (nopMetrics) IncCounter(string, Labels)                     {}
+func (nopMetrics) SetGauge(string, float64, Labels)              {}
+func (nopMetrics) ObserveDuration(string, time.Duration, Labels) {}
+
+// seriesKey 生成带排序标签的指标序列标识，如 name{a="1",b="2"}
+func seriesKey(name string, labels Labels) string {
+	return name + formatLabels(labels)
+}
+
+// formatLabels 按Prometheus文本格式输出标签
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(labels[k]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
+
+// DefaultDurationBuckets 默认耗时直方图分桶（秒）
+var DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// 指标类型
+const (
+	promCounter   = "counter"
+	promGauge     = "gauge"
+	promHistogram = "histogram"
+)
+
+// promSeries 单个指标序列
+type promSeries struct {
+	labels  Labels
+	value   float64
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+// promFamily 同名指标集合
+type promFamily struct {
+	kind   string
+	series map[string]*promSeries
+}
+
+// PrometheusMetrics Prometheus 文本格式指标导出器
+//
+// 实现了 http.Handler，可直接挂载到 /metrics 供 Prometheus 抓取。
+type PrometheusMetrics struct {
+	mu       sync.Mutex
+	families map[string]*promFamily
+	buckets  []float64
+}
+
+// NewPrometheusMetrics 创建Prometheus指标导出器
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return NewPrometheusMetricsWithBuckets(DefaultDurationBuckets)
+}
+
+// NewPrometheusMetricsWithBuckets 使用自定义直方图分桶创建Prometheus指标导出器
+func NewPrometheusMetricsWithBuckets(buckets []float64) *PrometheusMetrics {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &PrometheusMetrics{
+		families: make(map[string]*promFamily),
+		buckets:  sorted,
+	}
+}
+
+// series 获取或创建指标序列，调用方需持有锁
+func (m *PrometheusMetrics) series(name, kind string, labels Labels) *promSeries {
+	family, ok := m.families[name]
+	if !ok {
+		family = &promFamily{kind: kind, series: make(map[string]*promSeries)}
+		m.families[name] = family
+	}
+
+	key := formatLabels(labels)
+	s, ok := family.series[key]
+	if !ok {
+		copied := make(Labels, len(labels))
+		for k, v := range labels {
+			copied[k] = v
+		}
+		s = &promSeries{labels: copied}
+		if kind == promHistogram {
+			s.buckets = make([]uint64, len(m.buckets))
+		}
+		family.series[key] = s
+	}
+	return s
+}
+
+// IncCounter 计数器加一
+func (m *PrometheusMetrics) IncCounter(name string, labels Labels) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.series(name, promCounter, labels).value++
+}
+
+// SetGauge 设置仪表值
+func (m *PrometheusMetrics) SetGauge(name string, value float64, labels Labels) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.series(name, promGauge, labels).value = value
+}
+
+// ObserveDuration 记录一次耗时
+func (m *PrometheusMetrics) ObserveDuration(name string, d time.Duration, labels Labels) {
+	seconds := d.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.series(name, promHistogram, labels)
+	for i, upper := range m.buckets {
+		if seconds <= upper {
+			s.buckets[i]++
+		}
+	}
+	s.sum += seconds
+	s.count++
+}
+
+// WriteTo 以Prometheus文本格式输出所有指标
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.families))
+	for name := range m.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		family := m.families[name]
+		if help, ok := metricHelp[name]; ok {
+			fmt.Fprintf(&b, "# HELP %s %s\n", name, help)
+		}
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, family.kind)
+
+		keys := make([]string, 0, len(family.series))
+		for key := range family.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := family.series[key]
+			if family.kind != promHistogram {
+				fmt.Fprintf(&b, "%s%s %s\n", name, key, formatFloat(s.value))
+				continue
+			}
+
+			for i, upper := range m.buckets {
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", name, withLabel(s.labels, "le", formatFloat(upper)), s.buckets[i])
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", name, withLabel(s.labels, "le", "+Inf"), s.count)
+			fmt.Fprintf(&b, "%s_sum%s %s\n", name, key, formatFloat(s.sum))
+			fmt.Fprintf(&b, "%s_count%s %d\n", name, key, s.count)
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP 实现 http.Handler
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+func withLabel(labels Labels, key, value string) string {
+	merged := make(Labels, len(labels)+1)
+	for k, v := range labels {
+		merged[k] = v
+	}
+	merged[key] = value
+	return formatLabels(merged)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// ExpvarMetrics expvar 指标导出器
+//
+// 所有指标以 name{labels} 为键发布到同一个 expvar.Map 中，
+// 耗时指标拆分为 _count 与 _sum 两个键，可通过 /debug/vars 查看。
+type ExpvarMetrics struct {
+	vars *expvar.Map
+	mu   sync.Mutex
+}
+
+// NewExpvarMetrics 创建expvar指标导出器，name 为发布的变量名
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	if name == "" {
+		name = "kook"
+	}
+
+	// expvar 不允许重复发布同名变量
+	if existing, ok := expvar.Get(name).(*expvar.Map); ok {
+		return &ExpvarMetrics{vars: existing}
+	}
+	return &ExpvarMetrics{vars: expvar.NewMap(name)}
+}
+
+// IncCounter 计数器加一
+func (m *ExpvarMetrics) IncCounter(name string, labels Labels) {
+	m.vars.Add(seriesKey(name, labels), 1)
+}
+
+// SetGauge 设置仪表值
+func (m *ExpvarMetrics) SetGauge(name string, value float64, labels Labels) {
+	key := seriesKey(name, labels)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	gauge, ok := m.vars.Get(key).(*expvar.Float)
+	if !ok {
+		gauge = new(expvar.Float)
+		m.vars.Set(key, gauge)
+	}
+	gauge.Set(value)
+}
+
+// ObserveDuration 记录一次耗时
+func (m *ExpvarMetrics) ObserveDuration(name string, d time.Duration, labels Labels) {
+	m.vars.Add(seriesKey(name+"_count", labels), 1)
+	m.vars.AddFloat(seriesKey(name+"_sum", labels), d.Seconds())
+}
@@ -0,0 +1,106 @@
+package modlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// card 卡片消息
+type card struct {
+	Type    string       `json:"type"`
+	Theme   string       `json:"theme"`
+	Size    string       `json:"size"`
+	Modules []cardModule `json:"modules"`
+}
+
+// cardModule 卡片模块
+type cardModule struct {
+	Type     string     `json:"type"`
+	Text     *cardText  `json:"text,omitempty"`
+	Elements []cardText `json:"elements,omitempty"`
+}
+
+// cardText 卡片文本元素，paragraph 时使用 Cols 与 Fields
+type cardText struct {
+	Type    string     `json:"type"`
+	Content string     `json:"content,omitempty"`
+	Cols    int        `json:"cols,omitempty"`
+	Fields  []cardText `json:"fields,omitempty"`
+}
+
+// theme 按操作的严重程度选择卡片颜色
+func (a Action) theme() string {
+	switch a {
+	case ActionBan, ActionBlacklist, ActionKick:
+		return "danger"
+	case ActionMute, ActionWarn, ActionDelete:
+		return "warning"
+	case ActionUnban, ActionUnblacklist, ActionUnmute:
+		return "success"
+	}
+	return "info"
+}
+
+// kmd 生成 KMarkdown 文本元素
+func kmd(format string, args ...interface{}) cardText {
+	return cardText{Type: "kmarkdown", Content: fmt.Sprintf(format, args...)}
+}
+
+// escapeKMD 转义 KMarkdown 的特殊字符，避免原因中的提及与格式生效
+func escapeKMD(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\', '*', '~', '[', ']', '(', ')', '>', '-', '`', '_', ':':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// cardContent 生成案例在管理日志频道中的卡片消息
+func (c *Case) cardContent() string {
+	moderator := "系统"
+	if c.ModeratorID != "" {
+		moderator = fmt.Sprintf("(met)%s(met)", c.ModeratorID)
+	}
+	fields := []cardText{
+		kmd("**用户**\n(met)%s(met)", c.TargetID),
+		kmd("**管理员**\n%s", moderator),
+	}
+	switch c.Action {
+	case ActionMute, ActionBan, ActionBlacklist:
+		duration := "永久"
+		if c.Duration > 0 {
+			duration = c.Duration.String()
+		}
+		fields = append(fields, kmd("**时长**\n%s", duration))
+	}
+
+	reason := "未填写"
+	if c.Reason != "" {
+		reason = escapeKMD(c.Reason)
+	}
+
+	footer := fmt.Sprintf("来源：%s　时间：%s", c.Source, c.CreatedAt.Format("2006-01-02 15:04:05"))
+	if c.UpdatedAt.After(c.CreatedAt) {
+		footer += "　已编辑"
+	}
+
+	content := card{
+		Type:  "card",
+		Theme: c.Action.theme(),
+		Size:  "lg",
+		Modules: []cardModule{
+			{Type: "header", Text: &cardText{Type: "plain-text", Content: fmt.Sprintf("案例 #%d | %s", c.Number, c.Action)}},
+			{Type: "section", Text: &cardText{Type: "paragraph", Cols: len(fields), Fields: fields}},
+			{Type: "section", Text: &cardText{Type: "kmarkdown", Content: "**原因**\n" + reason}},
+			{Type: "context", Elements: []cardText{{Type: "plain-text", Content: footer}}},
+		},
+	}
+
+	data, _ := json.Marshal([]card{content})
+	return string(data)
+}
@@ -0,0 +1,120 @@
+// Package modlog 管理日志与案例：为每次管理操作分配服务器内递增的案例编号，
+// 记录管理员、对象、原因与时长，并以卡片形式发送到管理日志频道。
+//
+// 案例可来自通过本包执行的操作、限时处罚服务、自动管理引擎，以及服务器审计日志中由人工执行的操作。
+package modlog
+
+import (
+	"fmt"
+	"time"
+
+	"kook-go-sdk/kook"
+)
+
+// Action 管理操作类型
+type Action string
+
+const (
+	ActionWarn        Action = "warn"        // 警告
+	ActionDelete      Action = "delete"      // 删除消息
+	ActionMute        Action = "mute"        // 禁言
+	ActionUnmute      Action = "unmute"      // 解除禁言
+	ActionKick        Action = "kick"        // 踢出
+	ActionBan         Action = "ban"         // 封禁
+	ActionUnban       Action = "unban"       // 解封
+	ActionBlacklist   Action = "blacklist"   // 加入黑名单
+	ActionUnblacklist Action = "unblacklist" // 移出黑名单
+)
+
+// String 返回操作名称
+func (a Action) String() string {
+	switch a {
+	case ActionWarn:
+		return "警告"
+	case ActionDelete:
+		return "删除消息"
+	case ActionMute:
+		return "禁言"
+	case ActionUnmute:
+		return "解除禁言"
+	case ActionKick:
+		return "踢出"
+	case ActionBan:
+		return "封禁"
+	case ActionUnban:
+		return "解封"
+	case ActionBlacklist:
+		return "加入黑名单"
+	case ActionUnblacklist:
+		return "移出黑名单"
+	}
+	return string(a)
+}
+
+// Source 案例来源
+type Source string
+
+const (
+	SourceManual     Source = "manual"     // 通过本包或 Record 记录
+	SourcePunishment Source = "punishment" // 限时处罚服务
+	SourceAutomod    Source = "automod"    // 自动管理引擎
+	SourceAuditLog   Source = "audit_log"  // 服务器审计日志
+)
+
+// String 返回来源名称
+func (s Source) String() string {
+	switch s {
+	case SourceManual:
+		return "手动"
+	case SourcePunishment:
+		return "限时处罚"
+	case SourceAutomod:
+		return "自动管理"
+	case SourceAuditLog:
+		return "审计日志"
+	}
+	return string(s)
+}
+
+// auditActions 审计日志中记录为案例的操作类型
+var auditActions = map[int]Action{
+	kook.AuditLogActionMemberKick:  ActionKick,
+	kook.AuditLogActionMemberBan:   ActionBan,
+	kook.AuditLogActionMemberUnban: ActionUnban,
+}
+
+// Case 一条管理案例
+type Case struct {
+	Number      int           `json:"number"`                 // 服务器内的案例编号，从1开始
+	GuildID     string        `json:"guild_id"`               // 服务器ID
+	Action      Action        `json:"action"`                 // 操作类型
+	TargetID    string        `json:"target_id"`              // 被处理的用户ID
+	ModeratorID string        `json:"moderator_id,omitempty"` // 管理员ID，为空表示由系统执行
+	Reason      string        `json:"reason,omitempty"`       // 原因
+	Duration    time.Duration `json:"duration,omitempty"`     // 时长，为0表示永久或不适用
+	Source      Source        `json:"source"`                 // 来源
+	Reference   string        `json:"reference,omitempty"`    // 来源中的记录ID，如处罚ID或审计日志ID
+	MsgID       string        `json:"msg_id,omitempty"`       // 管理日志频道中的卡片消息ID
+	CreatedAt   time.Time     `json:"created_at"`             // 创建时间
+	UpdatedAt   time.Time     `json:"updated_at"`             // 最后修改时间
+}
+
+// validate 检查必填字段
+func (c *Case) validate() error {
+	if c.GuildID == "" {
+		return fmt.Errorf("服务器ID不能为空")
+	}
+	if c.TargetID == "" {
+		return fmt.Errorf("用户ID不能为空")
+	}
+	if c.Action == "" {
+		return fmt.Errorf("操作类型不能为空")
+	}
+	return nil
+}
+
+// copyCase 复制案例，避免调用方修改存储中的数据
+func copyCase(c *Case) *Case {
+	copied := *c
+	return &copied
+}
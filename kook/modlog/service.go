@@ -0,0 +1,545 @@
+package modlog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"kook-go-sdk/kook"
+	"kook-go-sdk/kook/automod"
+	"kook-go-sdk/kook/punishment"
+)
+
+// Service 管理日志服务
+type Service struct {
+	client *kook.Client
+	logger kook.Logger
+	store  Store
+	now    func() time.Time
+
+	mu          sync.Mutex
+	botID       string
+	channels    map[string]string
+	punishments []*punishment.Service
+	onCase      []func(*Case)
+}
+
+// Option 管理日志服务配置选项
+type Option func(*Service)
+
+// WithStore 设置案例存储，默认使用内存存储
+func WithStore(store Store) Option {
+	return func(s *Service) {
+		s.store = store
+	}
+}
+
+// WithLogger 设置日志器
+func WithLogger(logger kook.Logger) Option {
+	return func(s *Service) {
+		s.logger = logger
+	}
+}
+
+// WithBotID 设置机器人的用户ID，默认首次同步审计日志时通过 UserService.GetMe 获取
+func WithBotID(botID string) Option {
+	return func(s *Service) {
+		s.botID = botID
+	}
+}
+
+// WithLogChannel 设置服务器的管理日志频道，可多次使用为不同服务器设置
+func WithLogChannel(guildID, channelID string) Option {
+	return func(s *Service) {
+		s.channels[guildID] = channelID
+	}
+}
+
+// New 创建管理日志服务
+func New(client *kook.Client, opts ...Option) *Service {
+	s := &Service{
+		client:   client,
+		now:      time.Now,
+		channels: make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.store == nil {
+		s.store = NewMemoryStore()
+	}
+	if s.logger == nil {
+		s.logger = client.Logger("modlog")
+	}
+	return s
+}
+
+// SetLogChannel 设置服务器的管理日志频道，channelID 为空时不再发送卡片
+func (s *Service) SetLogChannel(guildID, channelID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if channelID == "" {
+		delete(s.channels, guildID)
+		return
+	}
+	s.channels[guildID] = channelID
+}
+
+// OnCase 注册新案例记录后的回调
+func (s *Service) OnCase(handler func(c *Case)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onCase = append(s.onCase, handler)
+}
+
+// Record 记录案例并发送到管理日志频道
+//
+// 编号、创建时间与卡片消息ID由服务填写，Source 为空时记为 SourceManual。
+// 卡片发送失败只记录日志，案例仍会保存。
+func (s *Service) Record(c *Case) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	if c.Source == "" {
+		c.Source = SourceManual
+	}
+
+	s.mu.Lock()
+	err := s.create(c)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	s.publish(c)
+	return nil
+}
+
+// create 分配编号并保存案例，调用方需持有锁
+func (s *Service) create(c *Case) error {
+	last, err := s.store.LastNumber(c.GuildID)
+	if err != nil {
+		return fmt.Errorf("读取案例编号失败: %w", err)
+	}
+	c.Number = last + 1
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = s.now()
+	}
+	c.UpdatedAt = c.CreatedAt
+	c.MsgID = ""
+
+	if err := s.store.Save(c); err != nil {
+		return fmt.Errorf("保存案例失败: %w", err)
+	}
+	s.logger.Info("记录管理案例",
+		kook.F("guild_id", c.GuildID),
+		kook.F("number", c.Number),
+		kook.F("action", string(c.Action)),
+		kook.F("target_id", c.TargetID),
+	)
+	return nil
+}
+
+// publish 发送案例卡片并通知回调
+func (s *Service) publish(c *Case) {
+	s.mu.Lock()
+	channelID := s.channels[c.GuildID]
+	handlers := append([]func(*Case){}, s.onCase...)
+	s.mu.Unlock()
+
+	if channelID != "" {
+		message, err := s.client.Message.SendMessage(kook.SendMessageParams{
+			TargetID: channelID,
+			Content:  c.cardContent(),
+			MsgType:  kook.MessageTypeCard,
+		})
+		if err != nil {
+			s.logger.Error("发送案例卡片失败", kook.F("number", c.Number), kook.ErrField(err))
+		} else if message.ID != "" {
+			s.mu.Lock()
+			// 只更新消息ID，避免覆盖发送期间修改的原因
+			if stored, err := s.store.Load(c.GuildID, c.Number); err == nil && stored != nil {
+				stored.MsgID = message.ID
+				err = s.store.Save(stored)
+				if err != nil {
+					s.logger.Error("保存案例失败", kook.F("number", c.Number), kook.ErrField(err))
+				}
+			}
+			s.mu.Unlock()
+			c.MsgID = message.ID
+		}
+	}
+
+	for _, handler := range handlers {
+		handler(copyCase(c))
+	}
+}
+
+// EditReason 修改案例原因，已发送的卡片会同步更新
+func (s *Service) EditReason(guildID string, number int, reason string) (*Case, error) {
+	s.mu.Lock()
+	c, err := s.store.Load(guildID, number)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("读取案例失败: %w", err)
+	}
+	if c == nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("案例 #%d 不存在", number)
+	}
+	c.Reason = reason
+	c.UpdatedAt = s.now()
+	err = s.store.Save(c)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("保存案例失败: %w", err)
+	}
+
+	if c.MsgID != "" {
+		if _, err := s.client.Message.UpdateMessage(c.MsgID, c.cardContent(), "", ""); err != nil {
+			return c, fmt.Errorf("更新案例卡片失败: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// Case 获取案例，不存在时返回 nil
+func (s *Service) Case(guildID string, number int) (*Case, error) {
+	return s.store.Load(guildID, number)
+}
+
+// Cases 按编号顺序列出服务器的所有案例
+func (s *Service) Cases(guildID string) ([]*Case, error) {
+	return s.store.List(guildID)
+}
+
+// History 按编号顺序列出用户在服务器内被处理的案例
+func (s *Service) History(guildID, userID string) ([]*Case, error) {
+	cases, err := s.store.List(guildID)
+	if err != nil {
+		return nil, fmt.Errorf("读取案例失败: %w", err)
+	}
+
+	var history []*Case
+	for _, c := range cases {
+		if c.TargetID == userID {
+			history = append(history, c)
+		}
+	}
+	return history, nil
+}
+
+// ActionParams 通过管理日志服务执行操作的参数
+type ActionParams struct {
+	GuildID     string // 服务器ID
+	UserID      string // 被处理的用户ID
+	ModeratorID string // 管理员ID
+	Reason      string // 原因
+	DelMsgDays  int    // 封禁时删除最近几天的消息
+}
+
+// Warn 私聊警告用户并记录案例，私聊发送失败时仍会记录
+func (s *Service) Warn(params ActionParams) (*Case, error) {
+	content := "你在服务器中收到一次警告"
+	if params.Reason != "" {
+		content += "，原因：" + params.Reason
+	}
+	if _, err := s.client.DirectMessage.SendMessage(kook.SendDirectMessageParams{
+		TargetID: params.UserID,
+		Content:  content,
+	}); err != nil {
+		s.logger.Warn("私聊警告失败", kook.F("user_id", params.UserID), kook.ErrField(err))
+	}
+	return s.recordAction(ActionWarn, params)
+}
+
+// Kick 将用户踢出服务器并记录案例
+func (s *Service) Kick(params ActionParams) (*Case, error) {
+	if err := s.client.Guild.KickGuildMember(params.GuildID, params.UserID); err != nil {
+		return nil, fmt.Errorf("踢出用户失败: %w", err)
+	}
+	return s.recordAction(ActionKick, params)
+}
+
+// Ban 永久封禁用户并记录案例，限时封禁请使用 punishment 包并通过 TrackPunishments 记录
+func (s *Service) Ban(params ActionParams) (*Case, error) {
+	if err := s.client.Admin.BanUser(params.GuildID, params.UserID, params.Reason, params.DelMsgDays); err != nil {
+		return nil, fmt.Errorf("封禁用户失败: %w", err)
+	}
+	return s.recordAction(ActionBan, params)
+}
+
+// Unban 解封用户并记录案例
+func (s *Service) Unban(params ActionParams) (*Case, error) {
+	if err := s.client.Admin.UnbanUser(params.GuildID, params.UserID); err != nil {
+		return nil, fmt.Errorf("解封用户失败: %w", err)
+	}
+	return s.recordAction(ActionUnban, params)
+}
+
+// recordAction 记录已执行的操作
+func (s *Service) recordAction(action Action, params ActionParams) (*Case, error) {
+	c := &Case{
+		GuildID:     params.GuildID,
+		Action:      action,
+		TargetID:    params.UserID,
+		ModeratorID: params.ModeratorID,
+		Reason:      params.Reason,
+	}
+	if err := s.Record(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// TrackPunishments 记录限时处罚服务执行与撤销的处罚
+func (s *Service) TrackPunishments(p *punishment.Service) {
+	s.mu.Lock()
+	s.punishments = append(s.punishments, p)
+	s.mu.Unlock()
+
+	p.OnApply(func(record *punishment.Punishment) {
+		c := &Case{
+			GuildID:     record.GuildID,
+			Action:      punishmentAction(record.Kind, false),
+			TargetID:    record.UserID,
+			ModeratorID: record.ModeratorID,
+			Reason:      record.Reason,
+			Duration:    record.Duration(),
+			Source:      SourcePunishment,
+			Reference:   record.ID,
+			CreatedAt:   record.CreatedAt,
+		}
+		if err := s.Record(c); err != nil {
+			s.logger.Error("记录处罚案例失败", kook.F("id", record.ID), kook.ErrField(err))
+		}
+	})
+
+	p.OnRevert(func(record *punishment.Punishment) {
+		reason := "提前撤销"
+		if !record.Permanent() && !s.now().Before(record.ExpiresAt) {
+			reason = "处罚到期"
+		}
+		if number := s.findReference(record.GuildID, SourcePunishment, record.ID); number > 0 {
+			reason = fmt.Sprintf("案例 #%d %s", number, reason)
+		}
+
+		c := &Case{
+			GuildID:   record.GuildID,
+			Action:    punishmentAction(record.Kind, true),
+			TargetID:  record.UserID,
+			Reason:    reason,
+			Source:    SourcePunishment,
+			Reference: record.ID,
+		}
+		if err := s.Record(c); err != nil {
+			s.logger.Error("记录撤销案例失败", kook.F("id", record.ID), kook.ErrField(err))
+		}
+	})
+}
+
+// punishmentAction 处罚类型对应的操作
+func punishmentAction(kind punishment.Kind, revert bool) Action {
+	switch kind {
+	case punishment.KindMute:
+		if revert {
+			return ActionUnmute
+		}
+		return ActionMute
+	case punishment.KindBan:
+		if revert {
+			return ActionUnban
+		}
+		return ActionBan
+	case punishment.KindBlacklist:
+		if revert {
+			return ActionUnblacklist
+		}
+		return ActionBlacklist
+	}
+	return Action(kind)
+}
+
+// findReference 查找来源记录对应的第一个案例编号，找不到时返回0
+func (s *Service) findReference(guildID string, source Source, reference string) int {
+	cases, err := s.store.List(guildID)
+	if err != nil {
+		return 0
+	}
+	for _, c := range cases {
+		if c.Source == source && c.Reference == reference {
+			return c.Number
+		}
+	}
+	return 0
+}
+
+// TrackAutomod 记录自动管理引擎处理的违规，按执行的最重处罚记为一个案例
+//
+// 引擎通过 punishment.Service.Muter 禁言且该处罚服务已用 TrackPunishments 记录时，
+// 禁言已由处罚服务记录，违规案例改记次重的处罚。
+func (s *Service) TrackAutomod(engine *automod.Engine) {
+	engine.OnViolation(func(v *automod.Violation) {
+		action, ok := violationAction(v.Action, s.mutesTracked(engine))
+		if !ok {
+			return
+		}
+		c := &Case{
+			GuildID:  v.GuildID,
+			Action:   action,
+			TargetID: v.UserID,
+			Reason:   fmt.Sprintf("[%s] %s", v.Rule, v.Reason),
+			Source:   SourceAutomod,
+		}
+		if action == ActionMute {
+			c.Duration = v.Duration
+		}
+		if err := s.Record(c); err != nil {
+			s.logger.Error("记录自动管理案例失败", kook.F("user_id", v.UserID), kook.ErrField(err))
+		}
+	})
+}
+
+// mutesTracked 判断引擎的禁言是否已由记录中的处罚服务执行
+func (s *Service) mutesTracked(engine *automod.Engine) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.punishments {
+		if p.OwnsMuter(engine.Muter()) {
+			return true
+		}
+	}
+	return false
+}
+
+// violationAction 从自动管理的处罚中选出最重的一项
+func violationAction(action automod.Action, skipMute bool) (Action, bool) {
+	switch {
+	case action.Has(automod.ActionBlacklist):
+		return ActionBlacklist, true
+	case action.Has(automod.ActionKick):
+		return ActionKick, true
+	case action.Has(automod.ActionMute) && !skipMute:
+		return ActionMute, true
+	case action.Has(automod.ActionWarn):
+		return ActionWarn, true
+	case action.Has(automod.ActionDelete):
+		return ActionDelete, true
+	}
+	return "", false
+}
+
+// RecordAuditEntry 将审计日志条目记录为案例
+//
+// 只记录踢出、封禁与解封操作；机器人自身执行的操作已由本服务记录，会被跳过。
+// 已记录过的条目不会重复记录。未记录时返回 nil, nil。
+func (s *Service) RecordAuditEntry(guildID string, entry *kook.AuditLogEntry) (*Case, error) {
+	action, ok := auditActions[entry.ActionType]
+	if !ok || entry.TargetID == "" {
+		return nil, nil
+	}
+	botID, err := s.botUserID()
+	if err != nil {
+		return nil, err
+	}
+	if entry.UserID == botID {
+		return nil, nil
+	}
+
+	c := &Case{
+		GuildID:     guildID,
+		Action:      action,
+		TargetID:    entry.TargetID,
+		ModeratorID: entry.UserID,
+		Reason:      entry.Reason,
+		Source:      SourceAuditLog,
+		Reference:   entry.ID,
+	}
+	if entry.CreatedAt > 0 {
+		c.CreatedAt = time.UnixMilli(entry.CreatedAt)
+	}
+
+	s.mu.Lock()
+	if s.findReference(guildID, SourceAuditLog, entry.ID) > 0 {
+		s.mu.Unlock()
+		return nil, nil
+	}
+	err = s.create(c)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(c)
+	return c, nil
+}
+
+// Attach 在事件源上注册审计日志事件处理器，通常为 kook.AuditLogWatcher
+func (s *Service) Attach(dispatcher kook.EventDispatcher) {
+	dispatcher.OnEvent(kook.EventTypeSystem, s.HandleEvent)
+}
+
+// HandleEvent 将审计日志事件记录为案例，其他事件忽略
+func (s *Service) HandleEvent(event *kook.Event) {
+	entry, err := event.AuditLogEntry()
+	if err != nil {
+		return
+	}
+	if _, err := s.RecordAuditEntry(event.GuildID(), entry); err != nil {
+		s.logger.Error("记录审计日志案例失败", kook.F("id", entry.ID), kook.ErrField(err))
+	}
+}
+
+// SyncAuditLog 拉取服务器 since 之后的审计日志并记录其中人工执行的管理操作，返回新记录的案例数
+func (s *Service) SyncAuditLog(guildID string, since time.Time) (int, error) {
+	var entries []kook.AuditLogEntry
+	for page := 1; ; page++ {
+		result, err := s.client.Admin.GetAuditLog(guildID, "", "", 0, page, 50)
+		if err != nil {
+			return 0, fmt.Errorf("获取审计日志失败: %w", err)
+		}
+
+		older := false
+		for _, entry := range result.Items {
+			if time.UnixMilli(entry.CreatedAt).Before(since) {
+				older = true
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		// 审计日志按时间倒序返回，出现更早的条目后不再翻页
+		if older || page >= result.Meta.PageTotal {
+			break
+		}
+	}
+
+	recorded := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		c, err := s.RecordAuditEntry(guildID, &entries[i])
+		if err != nil {
+			return recorded, err
+		}
+		if c != nil {
+			recorded++
+		}
+	}
+	return recorded, nil
+}
+
+// botUserID 获取机器人的用户ID
+func (s *Service) botUserID() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.botID != "" {
+		return s.botID, nil
+	}
+	me, err := s.client.User.GetMe()
+	if err != nil {
+		return "", fmt.Errorf("获取机器人信息失败: %w", err)
+	}
+	s.botID = me.ID
+	return s.botID, nil
+}
@@ -0,0 +1,154 @@
+package modlog
+
+import (
+	"sort"
+	"sync"
+
+	"kook-go-sdk/kook/internal/jsonfile"
+)
+
+// Store 案例存储
+//
+// 案例按服务器与编号保存，编号在服务器内从1开始递增。
+type Store interface {
+	// Load 读取案例，没有时返回 nil, nil
+	Load(guildID string, number int) (*Case, error)
+	// Save 保存案例，编号已存在时覆盖
+	Save(c *Case) error
+	// List 按编号顺序列出服务器的所有案例
+	List(guildID string) ([]*Case, error)
+	// LastNumber 返回服务器最大的案例编号，没有案例时返回0
+	LastNumber(guildID string) (int, error)
+}
+
+// MemoryStore 内存存储，进程退出后案例丢失
+type MemoryStore struct {
+	mu    sync.Mutex
+	cases map[string][]*Case
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{cases: make(map[string][]*Case)}
+}
+
+// Load 读取案例
+func (s *MemoryStore) Load(guildID string, number int) (*Case, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c := findCase(s.cases[guildID], number); c != nil {
+		return copyCase(c), nil
+	}
+	return nil, nil
+}
+
+// Save 保存案例
+func (s *MemoryStore) Save(c *Case) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cases[c.GuildID] = putCase(s.cases[c.GuildID], copyCase(c))
+	return nil
+}
+
+// List 列出服务器的所有案例
+func (s *MemoryStore) List(guildID string) ([]*Case, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cases := make([]*Case, 0, len(s.cases[guildID]))
+	for _, c := range s.cases[guildID] {
+		cases = append(cases, copyCase(c))
+	}
+	return cases, nil
+}
+
+// LastNumber 返回服务器最大的案例编号
+func (s *MemoryStore) LastNumber(guildID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return lastNumber(s.cases[guildID]), nil
+}
+
+// FileStore 文件存储，所有案例以JSON格式保存在一个文件中
+type FileStore struct {
+	file *jsonfile.File[map[string][]*Case]
+}
+
+// NewFileStore 创建文件存储
+func NewFileStore(path string) *FileStore {
+	return &FileStore{file: jsonfile.New[map[string][]*Case](path, "案例")}
+}
+
+// Load 读取案例
+func (s *FileStore) Load(guildID string, number int) (*Case, error) {
+	cases, err := s.file.Read()
+	if err != nil {
+		return nil, err
+	}
+	return findCase(cases[guildID], number), nil
+}
+
+// Save 保存案例
+func (s *FileStore) Save(c *Case) error {
+	return s.file.Update(func(cases *map[string][]*Case) bool {
+		if *cases == nil {
+			*cases = make(map[string][]*Case)
+		}
+		(*cases)[c.GuildID] = putCase((*cases)[c.GuildID], copyCase(c))
+		return true
+	})
+}
+
+// List 列出服务器的所有案例
+func (s *FileStore) List(guildID string) ([]*Case, error) {
+	cases, err := s.file.Read()
+	if err != nil {
+		return nil, err
+	}
+	if cases[guildID] == nil {
+		return []*Case{}, nil
+	}
+	return cases[guildID], nil
+}
+
+// LastNumber 返回服务器最大的案例编号
+func (s *FileStore) LastNumber(guildID string) (int, error) {
+	cases, err := s.file.Read()
+	if err != nil {
+		return 0, err
+	}
+	return lastNumber(cases[guildID]), nil
+}
+
+// findCase 按编号查找案例
+func findCase(cases []*Case, number int) *Case {
+	i := sort.Search(len(cases), func(i int) bool { return cases[i].Number >= number })
+	if i < len(cases) && cases[i].Number == number {
+		return cases[i]
+	}
+	return nil
+}
+
+// putCase 按编号插入或替换案例，保持有序
+func putCase(cases []*Case, c *Case) []*Case {
+	i := sort.Search(len(cases), func(i int) bool { return cases[i].Number >= c.Number })
+	if i < len(cases) && cases[i].Number == c.Number {
+		cases[i] = c
+		return cases
+	}
+	cases = append(cases, nil)
+	copy(cases[i+1:], cases[i:])
+	cases[i] = c
+	return cases
+}
+
+// lastNumber 返回最大的案例编号
+func lastNumber(cases []*Case) int {
+	if len(cases) == 0 {
+		return 0
+	}
+	return cases[len(cases)-1].Number
+}
@@ -0,0 +1,92 @@
+// Package oteltrace 将 kook.Tracer 适配到 OpenTelemetry
+//
+// 使用方法:
+//
+//	client := kook.NewClient(token, kook.WithTracer(oteltrace.New(otel.GetTracerProvider())))
+package oteltrace
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"kook-go-sdk/kook"
+)
+
+// InstrumentationName 追踪器名称
+const InstrumentationName = "kook-go-sdk"
+
+// Tracer OpenTelemetry 追踪器适配
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// New 使用指定的 TracerProvider 创建追踪器，为 nil 时使用全局 TracerProvider
+func New(provider trace.TracerProvider) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return &Tracer{tracer: provider.Tracer(InstrumentationName)}
+}
+
+// Start 开始一个新的片段
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...kook.Field) (context.Context, kook.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(convertAttrs(attrs)...))
+	return ctx, &Span{span: span}
+}
+
+// Span OpenTelemetry 片段适配
+type Span struct {
+	span trace.Span
+}
+
+// SetAttributes 设置属性
+func (s *Span) SetAttributes(attrs ...kook.Field) {
+	s.span.SetAttributes(convertAttrs(attrs)...)
+}
+
+// RecordError 记录错误并将片段标记为失败
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End 结束片段
+func (s *Span) End() {
+	s.span.End()
+}
+
+// convertAttrs 将日志字段转换为 OpenTelemetry 属性
+func convertAttrs(fields []kook.Field) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(fields))
+	for _, f := range fields {
+		key := attribute.Key(f.Key)
+		switch v := f.Value.(type) {
+		case string:
+			attrs = append(attrs, key.String(v))
+		case int:
+			attrs = append(attrs, key.Int(v))
+		case int64:
+			attrs = append(attrs, key.Int64(v))
+		case bool:
+			attrs = append(attrs, key.Bool(v))
+		case float64:
+			attrs = append(attrs, key.Float64(v))
+		case time.Duration:
+			attrs = append(attrs, key.Int64(v.Milliseconds()))
+		case fmt.Stringer:
+			attrs = append(attrs, key.String(v.String()))
+		default:
+			attrs = append(attrs, key.String(fmt.Sprint(v)))
+		}
+	}
+	return attrs
+}
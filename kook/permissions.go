@@ -0,0 +1,436 @@
+package kook
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Permissions 权限位集合
+type Permissions uint64
+
+// 权限常量，与KOOK权限表的位定义一致
+const (
+	PermissionAdministrator    Permissions = 1 << 0  // 管理员
+	PermissionManageGuild      Permissions = 1 << 1  // 管理服务器
+	PermissionViewAuditLog     Permissions = 1 << 2  // 查看管理日志
+	PermissionCreateInvite     Permissions = 1 << 3  // 创建服务器邀请
+	PermissionManageInvites    Permissions = 1 << 4  // 管理邀请
+	PermissionManageChannels   Permissions = 1 << 5  // 频道管理
+	PermissionKickMembers      Permissions = 1 << 6  // 踢出用户
+	PermissionBanMembers       Permissions = 1 << 7  // 封禁用户
+	PermissionManageEmojis     Permissions = 1 << 8  // 管理自定义表情
+	PermissionChangeNickname   Permissions = 1 << 9  // 修改服务器昵称
+	PermissionManageRoles      Permissions = 1 << 10 // 管理角色权限
+	PermissionViewChannel      Permissions = 1 << 11 // 查看文字、语音频道
+	PermissionSendMessages     Permissions = 1 << 12 // 发布消息
+	PermissionManageMessages   Permissions = 1 << 13 // 管理消息
+	PermissionUploadFiles      Permissions = 1 << 14 // 上传文件
+	PermissionConnectVoice     Permissions = 1 << 15 // 语音连接
+	PermissionManageVoice      Permissions = 1 << 16 // 语音管理
+	PermissionMentionEveryone  Permissions = 1 << 17 // 提及@全体成员
+	PermissionAddReactions     Permissions = 1 << 18 // 添加反应
+	PermissionFollowReactions  Permissions = 1 << 19 // 跟随添加反应
+	PermissionPassiveConnect   Permissions = 1 << 20 // 被动连接语音频道
+	PermissionPushToTalkOnly   Permissions = 1 << 21 // 仅使用按键说话
+	PermissionUseVoiceActivity Permissions = 1 << 22 // 使用自由麦
+	PermissionSpeakVoice       Permissions = 1 << 23 // 说话
+	PermissionDeafenMembers    Permissions = 1 << 24 // 服务器静音
+	PermissionMuteMembers      Permissions = 1 << 25 // 服务器闭麦
+	PermissionManageNicknames  Permissions = 1 << 26 // 修改他人昵称
+	PermissionPlayMusic        Permissions = 1 << 27 // 播放伴奏
+	PermissionScreenShare      Permissions = 1 << 28 // 屏幕分享
+	PermissionReplyToPost      Permissions = 1 << 29 // 回复帖子
+
+	// PermissionMoveMembers 移动成员，KOOK中由语音管理权限控制
+	//
+	// Deprecated: 使用 PermissionManageVoice。
+	PermissionMoveMembers = PermissionManageVoice
+
+	// PermissionUseSlashCommands 使用斜杠命令，KOOK权限表中已无该权限，值为 0，不授予也不要求任何权限
+	//
+	// Deprecated: KOOK 不再区分该权限，检查时可直接去掉。
+	PermissionUseSlashCommands Permissions = 0
+)
+
+// PermissionAll 全部权限
+const PermissionAll = PermissionReplyToPost<<1 - 1
+
+// permissionNames 权限名称，下标为权限位
+var permissionNames = [...]string{
+	"administrator",
+	"manage_guild",
+	"view_audit_log",
+	"create_invite",
+	"manage_invites",
+	"manage_channels",
+	"kick_members",
+	"ban_members",
+	"manage_emojis",
+	"change_nickname",
+	"manage_roles",
+	"view_channel",
+	"send_messages",
+	"manage_messages",
+	"upload_files",
+	"connect_voice",
+	"manage_voice",
+	"mention_everyone",
+	"add_reactions",
+	"follow_reactions",
+	"passive_connect",
+	"push_to_talk_only",
+	"use_voice_activity",
+	"speak_voice",
+	"deafen_members",
+	"mute_members",
+	"manage_nicknames",
+	"play_music",
+	"screen_share",
+	"reply_to_post",
+}
+
+// Has 判断是否包含全部指定权限
+func (p Permissions) Has(required Permissions) bool {
+	return p&required == required
+}
+
+// HasAny 判断是否包含任一指定权限
+func (p Permissions) HasAny(perms Permissions) bool {
+	return p&perms != 0
+}
+
+// Add 返回加上指定权限后的权限集合
+func (p Permissions) Add(perms ...Permissions) Permissions {
+	for _, perm := range perms {
+		p |= perm
+	}
+	return p
+}
+
+// Remove 返回移除指定权限后的权限集合
+func (p Permissions) Remove(perms ...Permissions) Permissions {
+	for _, perm := range perms {
+		p &^= perm
+	}
+	return p
+}
+
+// Missing 返回 required 中未包含的权限
+func (p Permissions) Missing(required Permissions) Permissions {
+	return required &^ p
+}
+
+// Names 返回包含的权限名称列表，未知的权限位以 bit_N 表示
+func (p Permissions) Names() []string {
+	names := make([]string, 0, bits.OnesCount64(uint64(p)))
+	for bit := 0; bit < 64; bit++ {
+		if p&(1<<bit) == 0 {
+			continue
+		}
+		if bit < len(permissionNames) {
+			names = append(names, permissionNames[bit])
+		} else {
+			names = append(names, "bit_"+strconv.Itoa(bit))
+		}
+	}
+	return names
+}
+
+// String 返回以 | 分隔的权限名称
+func (p Permissions) String() string {
+	if p == 0 {
+		return "none"
+	}
+	return strings.Join(p.Names(), "|")
+}
+
+// MarshalJSON 序列化为数值
+func (p Permissions) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatUint(uint64(p), 10)), nil
+}
+
+// UnmarshalJSON 支持数值、数值字符串以及权限名称数组
+func (p *Permissions) UnmarshalJSON(data []byte) error {
+	var number uint64
+	if err := json.Unmarshal(data, &number); err == nil {
+		*p = Permissions(number)
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		if text == "" {
+			*p = 0
+			return nil
+		}
+		if number, err := strconv.ParseUint(text, 10, 64); err == nil {
+			*p = Permissions(number)
+			return nil
+		}
+		parsed, err := ParsePermissions(text)
+		if err != nil {
+			return err
+		}
+		*p = parsed
+		return nil
+	}
+
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("无法解析权限值: %s", string(data))
+	}
+	parsed, err := PermissionsFromNames(names...)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// PermissionsFromNames 根据权限名称构造权限集合
+func PermissionsFromNames(names ...string) (Permissions, error) {
+	var perms Permissions
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		perm, ok := permissionByName(name)
+		if !ok {
+			return 0, fmt.Errorf("未知的权限名称: %s", name)
+		}
+		perms |= perm
+	}
+	return perms, nil
+}
+
+// ParsePermissions 解析以逗号或 | 分隔的权限名称，如 "view_channel|send_messages"
+func ParsePermissions(s string) (Permissions, error) {
+	if s == "none" {
+		return 0, nil
+	}
+	return PermissionsFromNames(strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '|'
+	})...)
+}
+
+func permissionByName(name string) (Permissions, bool) {
+	if name == "all" {
+		return PermissionAll, true
+	}
+	for bit, n := range permissionNames {
+		if n == name {
+			return 1 << bit, true
+		}
+	}
+	if rest, ok := strings.CutPrefix(name, "bit_"); ok {
+		if bit, err := strconv.Atoi(rest); err == nil && bit >= 0 && bit < 64 {
+			return 1 << bit, true
+		}
+	}
+	return 0, false
+}
+
+// everyoneRoleID 全体成员角色ID
+const everyoneRoleID = 0
+
+// ComputePermissions 计算成员在频道中的最终权限
+//
+// 计算顺序与KOOK一致：
+//  1. 服务器所有者拥有全部权限；
+//  2. 合并全体成员角色与成员所有角色的权限，拥有管理员权限时直接返回全部权限；
+//  3. 依次应用频道中全体成员角色的覆写、成员所属角色覆写的合集、成员个人覆写，
+//     每一层先移除 deny 再加上 allow。
+//
+// roles 为服务器全部角色（包含ID为0的全体成员角色），channel 为 nil 时只计算服务器级权限。
+func ComputePermissions(ownerID string, roles []GuildRole, userID string, userRoles []int, channel *Channel) Permissions {
+	if ownerID != "" && ownerID == userID {
+		return PermissionAll
+	}
+
+	memberRoles := make(map[int]bool, len(userRoles)+1)
+	memberRoles[everyoneRoleID] = true
+	for _, roleID := range userRoles {
+		memberRoles[roleID] = true
+	}
+
+	var perms Permissions
+	for _, role := range roles {
+		if memberRoles[role.RoleID] {
+			perms |= role.Permissions
+		}
+	}
+
+	if perms.Has(PermissionAdministrator) {
+		return PermissionAll
+	}
+
+	if channel == nil {
+		return perms
+	}
+
+	// 全体成员角色覆写
+	for _, overwrite := range channel.PermissionOverwrites {
+		if overwrite.RoleID == everyoneRoleID {
+			perms = perms&^overwrite.Deny | overwrite.Allow
+		}
+	}
+
+	// 成员所属角色覆写
+	var allow, deny Permissions
+	for _, overwrite := range channel.PermissionOverwrites {
+		if overwrite.RoleID != everyoneRoleID && memberRoles[overwrite.RoleID] {
+			allow |= overwrite.Allow
+			deny |= overwrite.Deny
+		}
+	}
+	perms = perms&^deny | allow
+
+	// 成员个人覆写
+	for _, overwrite := range channel.PermissionUsers {
+		if overwrite.User.ID == userID {
+			perms = perms&^overwrite.Deny | overwrite.Allow
+		}
+	}
+
+	return perms
+}
+
+// CanView 判断是否可以查看频道
+func CanView(perms Permissions) bool {
+	return perms.Has(PermissionViewChannel)
+}
+
+// CanSend 判断是否可以在频道发送消息
+func CanSend(perms Permissions) bool {
+	return perms.Has(PermissionViewChannel | PermissionSendMessages)
+}
+
+// CanManageMessages 判断是否可以管理频道消息
+func CanManageMessages(perms Permissions) bool {
+	return perms.Has(PermissionViewChannel | PermissionManageMessages)
+}
+
+// CanManageRoles 判断是否可以管理角色
+func CanManageRoles(perms Permissions) bool {
+	return perms.Has(PermissionManageRoles)
+}
+
+// CanConnectVoice 判断是否可以连接语音频道
+func CanConnectVoice(perms Permissions) bool {
+	return perms.Has(PermissionViewChannel | PermissionConnectVoice)
+}
+
+// PermissionChecker 权限检查器
+//
+// 在执行可能因权限不足（40300）失败的操作前预先检查。
+// 设置了 StateCache 时优先从缓存读取服务器、角色、成员和频道信息。
+type PermissionChecker struct {
+	client *Client
+	cache  *StateCache
+}
+
+// NewPermissionChecker 创建权限检查器，cache 可以为 nil
+func NewPermissionChecker(client *Client, cache *StateCache) *PermissionChecker {
+	return &PermissionChecker{client: client, cache: cache}
+}
+
+// Permissions 计算成员在频道中的最终权限，channelID 为空时只计算服务器级权限
+func (pc *PermissionChecker) Permissions(guildID, channelID, userID string) (Permissions, error) {
+	guild, err := pc.guild(guildID)
+	if err != nil {
+		return 0, err
+	}
+
+	roles, err := pc.roles(guild)
+	if err != nil {
+		return 0, err
+	}
+
+	member, err := pc.member(guildID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var channel *Channel
+	if channelID != "" {
+		channel, err = pc.channel(channelID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return ComputePermissions(guild.UserID, roles, userID, member.Roles, channel), nil
+}
+
+// Check 检查成员是否拥有全部指定权限，缺少权限时返回错误码为 40300 的 KOOKError
+func (pc *PermissionChecker) Check(guildID, channelID, userID string, required Permissions) error {
+	perms, err := pc.Permissions(guildID, channelID, userID)
+	if err != nil {
+		return err
+	}
+
+	if missing := perms.Missing(required); missing != 0 {
+		return NewKOOKError(int(ErrorCodeForbidden), fmt.Sprintf("缺少权限: %s", missing)).
+			WithDetails(map[string]interface{}{
+				"guild_id":   guildID,
+				"channel_id": channelID,
+				"user_id":    userID,
+				"missing":    missing,
+			})
+	}
+
+	return nil
+}
+
+func (pc *PermissionChecker) guild(guildID string) (*Guild, error) {
+	if pc.cache != nil {
+		return pc.cache.GetGuild(guildID)
+	}
+	return pc.client.Guild.GetGuildInfo(guildID)
+}
+
+func (pc *PermissionChecker) roles(guild *Guild) ([]GuildRole, error) {
+	if pc.cache != nil {
+		return pc.cache.GetRoles(guild.ID)
+	}
+
+	// guild/view 已返回角色列表时无需再请求
+	if len(guild.Roles) > 0 {
+		roles := make([]GuildRole, 0, len(guild.Roles))
+		for _, role := range guild.Roles {
+			roles = append(roles, GuildRole(role))
+		}
+		return roles, nil
+	}
+
+	var roles []GuildRole
+	for page := 1; ; page++ {
+		result, err := pc.client.Role.GetRoleList(guild.ID, page, 50)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, result.Items...)
+		if page >= result.Meta.PageTotal {
+			break
+		}
+	}
+	return roles, nil
+}
+
+func (pc *PermissionChecker) member(guildID, userID string) (*GuildMember, error) {
+	if pc.cache != nil {
+		return pc.cache.GetMember(guildID, userID)
+	}
+	return pc.client.Guild.GetGuildMember(guildID, userID)
+}
+
+func (pc *PermissionChecker) channel(channelID string) (*Channel, error) {
+	if pc.cache != nil {
+		return pc.cache.GetChannel(channelID)
+	}
+	return pc.client.Channel.GetChannelInfo(channelID)
+}
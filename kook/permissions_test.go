@@ -0,0 +1,101 @@
+package kook
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputePermissions(t *testing.T) {
+	roles := []GuildRole{
+		{RoleID: 0, Permissions: PermissionViewChannel | PermissionSendMessages},
+		{RoleID: 1, Permissions: PermissionManageMessages},
+		{RoleID: 2, Permissions: PermissionAdministrator},
+		{RoleID: 3, Permissions: PermissionConnectVoice},
+	}
+	base := PermissionViewChannel | PermissionSendMessages
+
+	tests := []struct {
+		name      string
+		userRoles []int
+		channel   *Channel
+		want      Permissions
+	}{
+		{"只有全体成员角色", nil, nil, base},
+		{"合并所有角色", []int{1, 3}, nil, base | PermissionManageMessages | PermissionConnectVoice},
+		{"管理员拥有全部权限", []int{2}, nil, PermissionAll},
+		{"管理员忽略频道覆写", []int{2}, &Channel{
+			PermissionOverwrites: []PermissionOverwrite{{RoleID: 0, Deny: PermissionViewChannel}},
+		}, PermissionAll},
+		{"全体成员覆写先拒绝再允许", nil, &Channel{
+			PermissionOverwrites: []PermissionOverwrite{{RoleID: 0, Allow: PermissionConnectVoice, Deny: PermissionSendMessages}},
+		}, PermissionViewChannel | PermissionConnectVoice},
+		{"全体成员覆写同时允许与拒绝时允许生效", nil, &Channel{
+			PermissionOverwrites: []PermissionOverwrite{{RoleID: 0, Allow: PermissionSendMessages, Deny: PermissionSendMessages}},
+		}, base},
+		{"角色覆写覆盖全体成员覆写", []int{1}, &Channel{
+			PermissionOverwrites: []PermissionOverwrite{
+				{RoleID: 1, Allow: PermissionSendMessages},
+				{RoleID: 0, Deny: PermissionSendMessages},
+			},
+		}, base | PermissionManageMessages},
+		{"角色覆写合并后允许优先", []int{1, 3}, &Channel{
+			PermissionOverwrites: []PermissionOverwrite{
+				{RoleID: 1, Deny: PermissionSendMessages},
+				{RoleID: 3, Allow: PermissionSendMessages},
+			},
+		}, base | PermissionManageMessages | PermissionConnectVoice},
+		{"不属于的角色覆写不生效", nil, &Channel{
+			PermissionOverwrites: []PermissionOverwrite{{RoleID: 1, Deny: PermissionViewChannel}},
+		}, base},
+		{"个人覆写覆盖角色覆写", []int{1}, &Channel{
+			PermissionOverwrites: []PermissionOverwrite{{RoleID: 1, Deny: PermissionSendMessages}},
+			PermissionUsers:      []PermissionUser{{User: User{ID: "u"}, Allow: PermissionSendMessages}},
+		}, base | PermissionManageMessages},
+		{"个人覆写拒绝", nil, &Channel{
+			PermissionUsers: []PermissionUser{{User: User{ID: "u"}, Deny: PermissionViewChannel}},
+		}, PermissionSendMessages},
+		{"其他用户的个人覆写不生效", nil, &Channel{
+			PermissionUsers: []PermissionUser{{User: User{ID: "other"}, Deny: PermissionViewChannel}},
+		}, base},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ComputePermissions("owner", roles, "u", tt.userRoles, tt.channel))
+		})
+	}
+}
+
+func TestComputePermissionsOwner(t *testing.T) {
+	assert.Equal(t, PermissionAll, ComputePermissions("u", nil, "u", nil, &Channel{
+		PermissionUsers: []PermissionUser{{User: User{ID: "u"}, Deny: PermissionAll}},
+	}))
+}
+
+func TestPermissionCheckerFromCache(t *testing.T) {
+	client := NewClient("x", WithLogLevel(LogLevelOff))
+	cache := NewStateCache(client, CachePolicy{Guilds: true, Channels: true, Roles: true, Members: true})
+	cache.storeGuild(Guild{ID: "g", UserID: "owner"})
+	cache.storeRoles("g", []GuildRole{
+		{RoleID: 0, Permissions: PermissionViewChannel},
+		{RoleID: 1, Permissions: PermissionSendMessages},
+	})
+	cache.storeMember("g", GuildMember{ID: "u", Username: "user", Roles: []int{1}})
+	cache.storeChannel(Channel{
+		ID:                   "c",
+		GuildID:              "g",
+		PermissionOverwrites: []PermissionOverwrite{{RoleID: 1, Deny: PermissionSendMessages}},
+	})
+
+	checker := NewPermissionChecker(client, cache)
+	require.NoError(t, checker.Check("g", "", "u", PermissionSendMessages))
+
+	err := checker.Check("g", "c", "u", PermissionViewChannel|PermissionSendMessages)
+	var kookErr *KOOKError
+	require.True(t, errors.As(err, &kookErr))
+	assert.Equal(t, int(ErrorCodeForbidden), kookErr.Code)
+	assert.Equal(t, PermissionSendMessages, kookErr.Details.(map[string]interface{})["missing"])
+}
@@ -0,0 +1,97 @@
+// Package punishment 限时处罚：记录处罚、通过现有服务执行，并在到期时自动撤销
+//
+// 禁言通过授予禁言角色实现，到期调用 RoleService.RevokeRole；封禁到期调用 AdminService.UnbanUser；
+// 黑名单到期调用 BlacklistService.DeleteBlacklistUser。处罚记录保存在 Store 中，
+// 到期撤销由 kook.Scheduler 执行。Start 时按记录重新安排撤销任务，进程重启后仍会按时撤销，
+// 重启期间已过期的处罚会立即撤销。
+package punishment
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Kind 处罚类型
+type Kind string
+
+const (
+	KindMute      Kind = "mute"      // 禁言（授予禁言角色）
+	KindBan       Kind = "ban"       // 封禁
+	KindBlacklist Kind = "blacklist" // 加入黑名单
+)
+
+// String 返回处罚类型名称
+func (k Kind) String() string {
+	switch k {
+	case KindMute:
+		return "禁言"
+	case KindBan:
+		return "封禁"
+	case KindBlacklist:
+		return "黑名单"
+	}
+	return string(k)
+}
+
+// Punishment 一条处罚记录
+type Punishment struct {
+	ID          string    `json:"id"`                     // 记录ID
+	Kind        Kind      `json:"kind"`                   // 处罚类型
+	GuildID     string    `json:"guild_id"`               // 服务器ID
+	UserID      string    `json:"user_id"`                // 被处罚用户ID
+	RoleID      int       `json:"role_id,omitempty"`      // 禁言角色ID
+	Reason      string    `json:"reason,omitempty"`       // 原因
+	ModeratorID string    `json:"moderator_id,omitempty"` // 执行处罚的管理员ID
+	CreatedAt   time.Time `json:"created_at"`             // 执行时间
+	ExpiresAt   time.Time `json:"expires_at,omitempty"`   // 到期时间，零值表示永久
+}
+
+// Permanent 判断是否为永久处罚
+func (p *Punishment) Permanent() bool {
+	return p.ExpiresAt.IsZero()
+}
+
+// Duration 获取处罚时长，永久处罚返回0
+func (p *Punishment) Duration() time.Duration {
+	if p.Permanent() {
+		return 0
+	}
+	return p.ExpiresAt.Sub(p.CreatedAt)
+}
+
+// Params 处罚参数
+type Params struct {
+	GuildID     string        // 服务器ID
+	UserID      string        // 用户ID
+	RoleID      int           // 禁言角色ID，仅禁言使用
+	Duration    time.Duration // 处罚时长，0表示永久
+	Reason      string        // 原因
+	ModeratorID string        // 执行处罚的管理员ID
+	DelMsgDays  int           // 删除最近几天的消息，仅封禁与黑名单使用
+}
+
+// validate 检查参数
+func (p *Params) validate(kind Kind) error {
+	if p.GuildID == "" {
+		return fmt.Errorf("服务器ID不能为空")
+	}
+	if p.UserID == "" {
+		return fmt.Errorf("用户ID不能为空")
+	}
+	if p.Duration < 0 {
+		return fmt.Errorf("处罚时长不能为负数")
+	}
+	if kind == KindMute && p.RoleID == 0 {
+		return fmt.Errorf("禁言角色ID不能为空")
+	}
+	return nil
+}
+
+// newID 生成记录ID
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
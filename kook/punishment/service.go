@@ -0,0 +1,393 @@
+package punishment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"kook-go-sdk/kook"
+	"kook-go-sdk/kook/automod"
+)
+
+// JobName 到期撤销任务的处理器名称，与其他模块共用调度器时注意不要重复
+const JobName = "punishment.expire"
+
+// Service 限时处罚服务
+type Service struct {
+	client        *kook.Client
+	logger        kook.Logger
+	store         Store
+	scheduler     *kook.Scheduler
+	ownsScheduler bool
+	retryDelay    time.Duration
+	now           func() time.Time
+
+	mu       sync.Mutex
+	onApply  []func(*Punishment)
+	onRevert []func(*Punishment)
+}
+
+// Option 处罚服务配置选项
+type Option func(*Service)
+
+// WithStore 设置记录存储，默认使用内存存储
+func WithStore(store Store) Option {
+	return func(s *Service) {
+		s.store = store
+	}
+}
+
+// WithLogger 设置日志器
+func WithLogger(logger kook.Logger) Option {
+	return func(s *Service) {
+		s.logger = logger
+	}
+}
+
+// WithScheduler 设置执行到期撤销的调度器，可与网关共用同一个调度器
+//
+// 默认创建使用内存任务存储的调度器，由 Start 与 Stop 启停；到期时间始终以处罚记录为准，
+// Start 时会按记录重新安排撤销任务。
+func WithScheduler(scheduler *kook.Scheduler) Option {
+	return func(s *Service) {
+		s.scheduler = scheduler
+	}
+}
+
+// WithRetryDelay 设置到期撤销失败后的重试间隔，默认1分钟
+func WithRetryDelay(delay time.Duration) Option {
+	return func(s *Service) {
+		s.retryDelay = delay
+	}
+}
+
+// New 创建处罚服务
+func New(client *kook.Client, opts ...Option) *Service {
+	s := &Service{
+		client:     client,
+		retryDelay: time.Minute,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.store == nil {
+		s.store = NewMemoryStore()
+	}
+	if s.logger == nil {
+		s.logger = client.Logger("punishment")
+	}
+	if s.scheduler == nil {
+		s.scheduler = kook.NewScheduler(client, kook.WithJobStore(kook.NewMemoryJobStore()))
+		s.ownsScheduler = true
+	}
+	s.scheduler.Handle(JobName, s.expire)
+	return s
+}
+
+// Start 从存储加载生效中的处罚并安排到期撤销，已过期的立即撤销，随后启动调度器
+func (s *Service) Start() error {
+	records, err := s.store.List()
+	if err != nil {
+		return fmt.Errorf("加载处罚记录失败: %w", err)
+	}
+
+	for _, p := range records {
+		if err := s.schedule(p); err != nil {
+			return err
+		}
+	}
+	if err := s.scheduler.Start(); err != nil {
+		return err
+	}
+	s.logger.Info("处罚服务已启动", kook.F("active", len(records)))
+	return nil
+}
+
+// Stop 停止默认创建的调度器，记录保留在存储中，下次 Start 时继续
+//
+// 通过 WithScheduler 传入的调度器由调用方停止。
+func (s *Service) Stop() {
+	if s.ownsScheduler {
+		s.scheduler.Stop(context.Background())
+	}
+}
+
+// OnApply 注册处罚执行后的回调
+func (s *Service) OnApply(handler func(p *Punishment)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onApply = append(s.onApply, handler)
+}
+
+// OnRevert 注册处罚撤销后的回调，包括到期撤销与手动撤销
+func (s *Service) OnRevert(handler func(p *Punishment)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onRevert = append(s.onRevert, handler)
+}
+
+// Mute 授予禁言角色，到期后移除
+func (s *Service) Mute(params Params) (*Punishment, error) {
+	return s.apply(KindMute, params)
+}
+
+// Ban 封禁用户，到期后解封
+func (s *Service) Ban(params Params) (*Punishment, error) {
+	return s.apply(KindBan, params)
+}
+
+// Blacklist 将用户加入黑名单，到期后移出
+func (s *Service) Blacklist(params Params) (*Punishment, error) {
+	return s.apply(KindBlacklist, params)
+}
+
+// Revert 立即撤销处罚并删除记录，撤销失败时仍按原到期时间撤销
+func (s *Service) Revert(id string) error {
+	p, err := s.store.Load(id)
+	if err != nil {
+		return fmt.Errorf("读取处罚记录失败: %w", err)
+	}
+	if p == nil {
+		return fmt.Errorf("处罚记录 %s 不存在", id)
+	}
+
+	s.cancel(id)
+	if err := s.revert(p); err != nil {
+		if scheduleErr := s.schedule(p); scheduleErr != nil {
+			s.logger.Error("重新安排到期撤销失败", kook.F("id", id), kook.ErrField(scheduleErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// Get 获取处罚记录，不存在时返回 nil
+func (s *Service) Get(id string) (*Punishment, error) {
+	return s.store.Load(id)
+}
+
+// Active 获取用户在服务器内生效中的处罚
+func (s *Service) Active(guildID, userID string) ([]*Punishment, error) {
+	records, err := s.store.List()
+	if err != nil {
+		return nil, fmt.Errorf("读取处罚记录失败: %w", err)
+	}
+
+	var active []*Punishment
+	for _, p := range records {
+		if p.GuildID == guildID && p.UserID == userID {
+			active = append(active, p)
+		}
+	}
+	return active, nil
+}
+
+// apply 执行处罚并保存记录，同一用户已有同类处罚时以新处罚替换
+func (s *Service) apply(kind Kind, params Params) (*Punishment, error) {
+	if err := params.validate(kind); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch kind {
+	case KindMute:
+		_, err = s.client.Role.GrantRole(params.GuildID, params.UserID, params.RoleID)
+	case KindBan:
+		err = s.client.Admin.BanUser(params.GuildID, params.UserID, params.Reason, params.DelMsgDays)
+	case KindBlacklist:
+		err = s.client.Blacklist.CreateBlacklistUser(params.GuildID, params.UserID, params.Reason, params.DelMsgDays)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("执行%s失败: %w", kind, err)
+	}
+
+	now := s.now()
+	p := &Punishment{
+		ID:          newID(),
+		Kind:        kind,
+		GuildID:     params.GuildID,
+		UserID:      params.UserID,
+		RoleID:      params.RoleID,
+		Reason:      params.Reason,
+		ModeratorID: params.ModeratorID,
+		CreatedAt:   now,
+	}
+	if params.Duration > 0 {
+		p.ExpiresAt = now.Add(params.Duration)
+	}
+
+	// 替换同类处罚，旧记录不再撤销
+	existing, err := s.Active(params.GuildID, params.UserID)
+	if err != nil {
+		return nil, err
+	}
+	for _, old := range existing {
+		if old.Kind == kind && old.RoleID == p.RoleID {
+			s.cancel(old.ID)
+			if err := s.store.Delete(old.ID); err != nil {
+				return nil, fmt.Errorf("删除处罚记录失败: %w", err)
+			}
+		}
+	}
+
+	if err := s.store.Save(p); err != nil {
+		return nil, fmt.Errorf("保存处罚记录失败: %w", err)
+	}
+	// 处罚已生效，安排失败时只记录日志，下次 Start 时按记录重新安排
+	if err := s.schedule(p); err != nil {
+		s.logger.Error("安排到期撤销失败", kook.F("id", p.ID), kook.ErrField(err))
+	}
+
+	s.logger.Info("执行处罚",
+		kook.F("id", p.ID),
+		kook.F("kind", string(kind)),
+		kook.F("guild_id", p.GuildID),
+		kook.F("user_id", p.UserID),
+		kook.F("duration", params.Duration.String()),
+	)
+
+	s.mu.Lock()
+	handlers := append([]func(*Punishment){}, s.onApply...)
+	s.mu.Unlock()
+	for _, handler := range handlers {
+		handler(p)
+	}
+	return p, nil
+}
+
+// revert 调用对应接口撤销处罚并删除记录
+func (s *Service) revert(p *Punishment) error {
+	var err error
+	switch p.Kind {
+	case KindMute:
+		_, err = s.client.Role.RevokeRole(p.GuildID, p.UserID, p.RoleID)
+	case KindBan:
+		err = s.client.Admin.UnbanUser(p.GuildID, p.UserID)
+	case KindBlacklist:
+		err = s.client.Blacklist.DeleteBlacklistUser(p.GuildID, p.UserID)
+	default:
+		err = fmt.Errorf("未知的处罚类型: %s", p.Kind)
+	}
+	if err != nil {
+		return fmt.Errorf("撤销%s失败: %w", p.Kind, err)
+	}
+
+	if err := s.store.Delete(p.ID); err != nil {
+		return fmt.Errorf("删除处罚记录失败: %w", err)
+	}
+	s.logger.Info("撤销处罚",
+		kook.F("id", p.ID),
+		kook.F("kind", string(p.Kind)),
+		kook.F("guild_id", p.GuildID),
+		kook.F("user_id", p.UserID),
+	)
+
+	s.mu.Lock()
+	handlers := append([]func(*Punishment){}, s.onRevert...)
+	s.mu.Unlock()
+	for _, handler := range handlers {
+		handler(p)
+	}
+	return nil
+}
+
+// expirePayload 到期撤销任务的参数
+type expirePayload struct {
+	ID string `json:"id"`
+}
+
+// jobID 返回处罚对应的到期撤销任务ID，重复安排时替换原任务
+func jobID(id string) string {
+	return "punishment:" + id
+}
+
+// schedule 安排到期撤销，永久处罚不安排
+func (s *Service) schedule(p *Punishment) error {
+	if p.Permanent() {
+		return nil
+	}
+	return s.scheduleAt(p.ID, p.ExpiresAt)
+}
+
+// scheduleAt 在 at 时撤销处罚
+func (s *Service) scheduleAt(id string, at time.Time) error {
+	payload, err := json.Marshal(expirePayload{ID: id})
+	if err != nil {
+		return fmt.Errorf("序列化任务参数失败: %w", err)
+	}
+	return s.scheduler.Add(&kook.Job{
+		ID:      jobID(id),
+		Name:    JobName,
+		RunAt:   at,
+		Payload: payload,
+	})
+}
+
+// cancel 取消到期撤销
+func (s *Service) cancel(id string) {
+	if err := s.scheduler.Cancel(jobID(id)); err != nil {
+		s.logger.Error("取消到期撤销失败", kook.F("id", id), kook.ErrField(err))
+	}
+}
+
+// expire 到期撤销处罚，失败时在 retryDelay 后重试，直到撤销成功或记录被删除
+func (s *Service) expire(ctx context.Context, job *kook.Job) error {
+	var payload expirePayload
+	if err := job.Decode(&payload); err != nil {
+		return err
+	}
+	id := payload.ID
+
+	p, err := s.store.Load(id)
+	if err == nil && p == nil {
+		return nil
+	}
+	if err == nil {
+		err = s.revert(p)
+	}
+	if err == nil {
+		return nil
+	}
+
+	s.logger.Error("到期撤销处罚失败，稍后重试",
+		kook.F("id", id),
+		kook.F("retry_delay", s.retryDelay.String()),
+		kook.ErrField(err),
+	)
+	// 以同一任务ID重新添加，替换当前任务
+	if scheduleErr := s.scheduleAt(id, s.now().Add(s.retryDelay)); scheduleErr != nil {
+		return fmt.Errorf("%w; 重新安排失败: %v", err, scheduleErr)
+	}
+	return nil
+}
+
+// muter 将处罚服务作为自动管理的禁言实现
+type muter struct {
+	s *Service
+}
+
+// Mute 实现 automod.Muter
+func (m muter) Mute(guildID, userID string, roleID int, duration time.Duration, reason string) error {
+	_, err := m.s.Mute(Params{
+		GuildID:  guildID,
+		UserID:   userID,
+		RoleID:   roleID,
+		Duration: duration,
+		Reason:   reason,
+	})
+	return err
+}
+
+// Muter 返回基于本服务的 automod.Muter，使自动管理的禁言在重启后仍会按时解除
+func (s *Service) Muter() automod.Muter {
+	return muter{s: s}
+}
+
+// OwnsMuter 判断禁言实现是否为本服务的 Muter，即禁言是否由本服务执行并记录
+func (s *Service) OwnsMuter(m automod.Muter) bool {
+	owned, ok := m.(muter)
+	return ok && owned.s == s
+}
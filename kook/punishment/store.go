@@ -0,0 +1,110 @@
+package punishment
+
+import (
+	"sync"
+
+	"kook-go-sdk/kook/internal/jsonfile"
+)
+
+// Store 处罚记录存储
+//
+// 只保存生效中的处罚，撤销后删除。
+type Store interface {
+	// Load 读取处罚记录，没有时返回 nil, nil
+	Load(id string) (*Punishment, error)
+	// Save 保存处罚记录
+	Save(record *Punishment) error
+	// Delete 删除处罚记录
+	Delete(id string) error
+	// List 列出所有处罚记录
+	List() ([]*Punishment, error)
+}
+
+// MemoryStore 内存存储，进程退出后记录丢失
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Punishment
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]*Punishment)}
+}
+
+// Load 读取处罚记录
+func (s *MemoryStore) Load(id string) (*Punishment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyPunishment(record), nil
+}
+
+// Save 保存处罚记录
+func (s *MemoryStore) Save(record *Punishment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[record.ID] = copyPunishment(record)
+	return nil
+}
+
+// Delete 删除处罚记录
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, id)
+	return nil
+}
+
+// List 列出所有处罚记录
+func (s *MemoryStore) List() ([]*Punishment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]*Punishment, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, copyPunishment(record))
+	}
+	return records, nil
+}
+
+// copyPunishment 复制记录，避免调用方修改存储中的数据
+func copyPunishment(p *Punishment) *Punishment {
+	copied := *p
+	return &copied
+}
+
+// FileStore 文件存储，所有记录以JSON格式保存在一个文件中
+type FileStore struct {
+	file *jsonfile.Map[Punishment]
+}
+
+// NewFileStore 创建文件存储
+func NewFileStore(path string) *FileStore {
+	return &FileStore{file: jsonfile.NewMap[Punishment](path, "处罚记录")}
+}
+
+// Load 读取处罚记录
+func (s *FileStore) Load(id string) (*Punishment, error) {
+	return s.file.Load(id)
+}
+
+// Save 保存处罚记录
+func (s *FileStore) Save(record *Punishment) error {
+	return s.file.Save(record.ID, record)
+}
+
+// Delete 删除处罚记录
+func (s *FileStore) Delete(id string) error {
+	return s.file.Delete(id)
+}
+
+// List 列出所有处罚记录
+func (s *FileStore) List() ([]*Punishment, error) {
+	return s.file.List()
+}
@@ -0,0 +1,352 @@
+package reactionrole
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"kook-go-sdk/kook"
+	"kook-go-sdk/kook/internal/keylock"
+)
+
+// Manager 反应角色管理器
+type Manager struct {
+	client *kook.Client
+	logger kook.Logger
+	store  Store
+
+	mu    sync.Mutex
+	botID string
+
+	// locks 同一用户的回应事件按顺序处理
+	locks keylock.Locker
+}
+
+// Option 反应角色管理器配置选项
+type Option func(*Manager)
+
+// WithStore 设置绑定存储，默认使用内存存储
+func WithStore(store Store) Option {
+	return func(m *Manager) {
+		m.store = store
+	}
+}
+
+// WithLogger 设置日志器
+func WithLogger(logger kook.Logger) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
+
+// WithBotID 设置机器人的用户ID，默认首次使用时通过 UserService.GetMe 获取
+func WithBotID(botID string) Option {
+	return func(m *Manager) {
+		m.botID = botID
+	}
+}
+
+// NewManager 创建反应角色管理器
+func NewManager(client *kook.Client, opts ...Option) *Manager {
+	m := &Manager{
+		client: client,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.store == nil {
+		m.store = NewMemoryStore()
+	}
+	if m.logger == nil {
+		m.logger = client.Logger("reactionrole")
+	}
+	return m
+}
+
+// Attach 在事件源上注册系统事件处理器
+func (m *Manager) Attach(dispatcher kook.EventDispatcher) {
+	dispatcher.OnEvent(kook.EventTypeSystem, m.HandleEvent)
+}
+
+// Bind 为已有消息绑定反应角色，已有绑定时会被替换
+//
+// 绑定前检查机器人是否有管理角色权限，以及各角色是否低于机器人的最高角色。
+func (m *Manager) Bind(menu *Menu) error {
+	if menu.MsgID == "" {
+		return fmt.Errorf("消息ID不能为空")
+	}
+	if err := menu.validate(); err != nil {
+		return err
+	}
+	if err := m.CheckRoles(menu.GuildID, menu.roleIDs()...); err != nil {
+		return err
+	}
+
+	if err := m.store.Save(copyMenu(menu)); err != nil {
+		return fmt.Errorf("保存反应角色失败: %w", err)
+	}
+	m.logger.Info("绑定反应角色", kook.F("msg_id", menu.MsgID), kook.F("entries", len(menu.Entries)))
+	return nil
+}
+
+// Publish 在频道中发送消息、添加各选项的回应并绑定反应角色
+//
+// title 为消息标题，消息正文由各选项的表情与说明生成；发送成功后 menu.MsgID 与 ChannelID 会被设置。
+func (m *Manager) Publish(channelID, title string, menu *Menu) error {
+	if channelID == "" {
+		return fmt.Errorf("频道ID不能为空")
+	}
+	if err := menu.validate(); err != nil {
+		return err
+	}
+	if err := m.CheckRoles(menu.GuildID, menu.roleIDs()...); err != nil {
+		return err
+	}
+
+	message, err := m.client.Message.SendMessage(kook.SendMessageParams{
+		TargetID: channelID,
+		Content:  menu.content(title),
+		MsgType:  kook.MessageTypeKMD,
+	})
+	if err != nil {
+		return fmt.Errorf("发送反应角色消息失败: %w", err)
+	}
+	if message.ID == "" {
+		return fmt.Errorf("发送反应角色消息失败: 未返回消息ID")
+	}
+	menu.MsgID = message.ID
+	menu.ChannelID = channelID
+
+	for _, entry := range menu.Entries {
+		if err := m.client.Message.AddReaction(menu.MsgID, entry.Emoji); err != nil {
+			return fmt.Errorf("添加回应 %s 失败: %w", entry.Emoji, err)
+		}
+	}
+
+	if err := m.store.Save(copyMenu(menu)); err != nil {
+		return fmt.Errorf("保存反应角色失败: %w", err)
+	}
+	m.logger.Info("发布反应角色", kook.F("msg_id", menu.MsgID), kook.F("channel_id", channelID))
+	return nil
+}
+
+// Unbind 解除消息上的反应角色，不影响成员已有的角色
+func (m *Manager) Unbind(msgID string) error {
+	if err := m.store.Delete(msgID); err != nil {
+		return fmt.Errorf("删除反应角色失败: %w", err)
+	}
+	return nil
+}
+
+// Menu 获取消息上的反应角色，没有时返回 nil
+func (m *Manager) Menu(msgID string) (*Menu, error) {
+	return m.store.Load(msgID)
+}
+
+// Menus 列出所有反应角色
+func (m *Manager) Menus() ([]*Menu, error) {
+	return m.store.List()
+}
+
+// CheckRoles 检查机器人能否授予指定角色
+//
+// 机器人需要管理角色权限，且角色必须排在机器人的最高角色之后（position 越小越靠前）。
+func (m *Manager) CheckRoles(guildID string, roleIDs ...int) error {
+	botID, err := m.botUserID()
+	if err != nil {
+		return err
+	}
+	bot, err := m.client.User.GetUser(botID, guildID)
+	if err != nil {
+		return fmt.Errorf("获取机器人角色失败: %w", err)
+	}
+
+	roles := make(map[int]kook.GuildRole)
+	for page := 1; ; page++ {
+		result, err := m.client.Role.GetRoleList(guildID, page, 50)
+		if err != nil {
+			return fmt.Errorf("获取角色列表失败: %w", err)
+		}
+		for _, role := range result.Items {
+			roles[role.RoleID] = role
+		}
+		if page >= result.Meta.PageTotal {
+			break
+		}
+	}
+
+	var perms kook.Permissions
+	highest := math.MaxInt
+	for _, id := range bot.Roles {
+		role, ok := roles[id]
+		if !ok {
+			continue
+		}
+		perms = perms.Add(role.Permissions)
+		if role.Position < highest {
+			highest = role.Position
+		}
+	}
+	if !perms.HasAny(kook.PermissionAdministrator | kook.PermissionManageRoles) {
+		return fmt.Errorf("机器人缺少管理角色权限")
+	}
+
+	for _, id := range roleIDs {
+		role, ok := roles[id]
+		if !ok {
+			return fmt.Errorf("角色 %d 不存在", id)
+		}
+		if role.Position <= highest {
+			return fmt.Errorf("角色 %s 不低于机器人的最高角色，无法授予", role.Name)
+		}
+	}
+	return nil
+}
+
+// botUserID 获取机器人的用户ID
+func (m *Manager) botUserID() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.botID != "" {
+		return m.botID, nil
+	}
+	me, err := m.client.User.GetMe()
+	if err != nil {
+		return "", fmt.Errorf("获取机器人信息失败: %w", err)
+	}
+	m.botID = me.ID
+	return m.botID, nil
+}
+
+// HandleEvent 处理添加/取消回应事件，消息被删除时解除绑定
+func (m *Manager) HandleEvent(event *kook.Event) {
+	system, err := event.SystemEvent()
+	if err != nil {
+		return
+	}
+
+	switch system.Type {
+	case kook.SystemEventAddedReaction, kook.SystemEventDeletedReaction:
+		var body kook.ReactionEventBody
+		if err := system.DecodeBody(&body); err != nil {
+			m.logger.Warn("解析回应事件失败", kook.ErrField(err))
+			return
+		}
+		added := system.Type == kook.SystemEventAddedReaction
+		if err := m.handleReaction(&body, added); err != nil {
+			m.logger.Error("处理反应角色失败",
+				kook.F("msg_id", body.MsgID),
+				kook.F("user_id", body.UserID),
+				kook.ErrField(err),
+			)
+		}
+
+	case kook.SystemEventDeletedMessage:
+		var body struct {
+			MsgID string `json:"msg_id"`
+		}
+		if err := system.DecodeBody(&body); err != nil || body.MsgID == "" {
+			return
+		}
+		menu, err := m.store.Load(body.MsgID)
+		if err != nil || menu == nil {
+			return
+		}
+		if err := m.Unbind(body.MsgID); err != nil {
+			m.logger.Error("解除反应角色失败", kook.F("msg_id", body.MsgID), kook.ErrField(err))
+			return
+		}
+		m.logger.Info("消息已删除，解除反应角色", kook.F("msg_id", body.MsgID))
+	}
+}
+
+// handleReaction 根据回应授予或移除角色
+func (m *Manager) handleReaction(body *kook.ReactionEventBody, added bool) error {
+	menu, err := m.store.Load(body.MsgID)
+	if err != nil {
+		return fmt.Errorf("读取反应角色失败: %w", err)
+	}
+	if menu == nil {
+		return nil
+	}
+	entry, ok := menu.entry(body.Emoji.ID, body.Emoji.Name)
+	if !ok {
+		return nil
+	}
+
+	botID, err := m.botUserID()
+	if err != nil {
+		return err
+	}
+	if body.UserID == botID {
+		return nil
+	}
+
+	unlock := m.lock(menu.GuildID + "/" + body.UserID)
+	defer unlock()
+
+	user, err := m.client.User.GetUser(body.UserID, menu.GuildID)
+	if err != nil {
+		return fmt.Errorf("获取成员角色失败: %w", err)
+	}
+	held := make(map[int]bool, len(user.Roles))
+	for _, id := range user.Roles {
+		held[id] = true
+	}
+
+	if !added {
+		if menu.GrantOnly || !held[entry.RoleID] {
+			return nil
+		}
+		if _, err := m.client.Role.RevokeRole(menu.GuildID, body.UserID, entry.RoleID); err != nil {
+			return fmt.Errorf("移除角色失败: %w", err)
+		}
+		m.logger.Info("移除反应角色", kook.F("user_id", body.UserID), kook.F("role_id", entry.RoleID))
+		return nil
+	}
+
+	if held[entry.RoleID] {
+		return nil
+	}
+
+	// 菜单中成员已持有的其他角色
+	var others []Entry
+	for _, other := range menu.Entries {
+		if other.RoleID != entry.RoleID && held[other.RoleID] {
+			others = append(others, other)
+		}
+	}
+
+	if menu.Exclusive {
+		for _, other := range others {
+			if _, err := m.client.Role.RevokeRole(menu.GuildID, body.UserID, other.RoleID); err != nil {
+				return fmt.Errorf("移除互斥角色失败: %w", err)
+			}
+			// 回应撤回失败不影响角色
+			if err := m.client.Message.DeleteReaction(menu.MsgID, other.Emoji, body.UserID); err != nil {
+				m.logger.Warn("撤回互斥回应失败", kook.F("emoji", other.Emoji), kook.ErrField(err))
+			}
+		}
+	} else if menu.MaxRoles > 0 && len(others) >= menu.MaxRoles {
+		m.logger.Info("超出反应角色数量上限",
+			kook.F("user_id", body.UserID),
+			kook.F("max_roles", menu.MaxRoles),
+		)
+		if err := m.client.Message.DeleteReaction(menu.MsgID, entry.Emoji, body.UserID); err != nil {
+			return fmt.Errorf("撤回回应失败: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := m.client.Role.GrantRole(menu.GuildID, body.UserID, entry.RoleID); err != nil {
+		return fmt.Errorf("授予角色失败: %w", err)
+	}
+	m.logger.Info("授予反应角色", kook.F("user_id", body.UserID), kook.F("role_id", entry.RoleID))
+	return nil
+}
+
+// lock 获取用户的处理锁，返回解锁函数
+func (m *Manager) lock(key string) func() {
+	return m.locks.Lock(key)
+}
@@ -0,0 +1,114 @@
+// Package reactionrole 反应角色：成员在指定消息上添加回应即获得对应角色，取消回应则移除
+//
+// 每条消息对应一个 Menu，其中每个 Entry 将一个表情绑定到一个角色。
+// Manager 监听添加/取消回应事件，调用 RoleService.GrantRole 与 RevokeRole，
+// 绑定关系通过 Store 持久化，重启后继续生效。
+package reactionrole
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Entry 表情与角色的绑定
+type Entry struct {
+	Emoji  string `json:"emoji"`           // 表情，内置表情为 Unicode 字符，服务器表情为表情ID
+	RoleID int    `json:"role_id"`         // 角色ID
+	Label  string `json:"label,omitempty"` // 说明，Publish 自动生成消息内容时使用
+}
+
+// Menu 一条消息上的反应角色
+type Menu struct {
+	MsgID     string  `json:"msg_id"`     // 消息ID
+	GuildID   string  `json:"guild_id"`   // 服务器ID
+	ChannelID string  `json:"channel_id"` // 频道ID
+	Entries   []Entry `json:"entries"`    // 表情与角色的绑定
+
+	// Exclusive 互斥：同一菜单只能持有一个角色，选择新角色时移除旧角色及其回应
+	Exclusive bool `json:"exclusive,omitempty"`
+	// MaxRoles 同一菜单最多持有的角色数，0表示不限制，超出时撤回新添加的回应
+	MaxRoles int `json:"max_roles,omitempty"`
+	// GrantOnly 只授予：取消回应时不移除角色，适用于规则确认等场景
+	GrantOnly bool `json:"grant_only,omitempty"`
+}
+
+// validate 检查菜单配置
+func (m *Menu) validate() error {
+	if m.GuildID == "" {
+		return fmt.Errorf("服务器ID不能为空")
+	}
+	if len(m.Entries) == 0 {
+		return fmt.Errorf("反应角色至少需要一个选项")
+	}
+	if m.MaxRoles < 0 {
+		return fmt.Errorf("最多角色数不能为负数")
+	}
+
+	emojis := make(map[string]bool, len(m.Entries))
+	roles := make(map[int]bool, len(m.Entries))
+	for _, entry := range m.Entries {
+		if entry.Emoji == "" {
+			return fmt.Errorf("表情不能为空")
+		}
+		if entry.RoleID <= 0 {
+			return fmt.Errorf("表情 %s 的角色ID无效", entry.Emoji)
+		}
+		if emojis[entry.Emoji] {
+			return fmt.Errorf("表情 %s 重复", entry.Emoji)
+		}
+		if roles[entry.RoleID] {
+			return fmt.Errorf("角色 %d 重复", entry.RoleID)
+		}
+		emojis[entry.Emoji] = true
+		roles[entry.RoleID] = true
+	}
+	return nil
+}
+
+// entry 根据表情查找绑定
+func (m *Menu) entry(emojiID, emojiName string) (Entry, bool) {
+	for _, entry := range m.Entries {
+		if entry.Emoji == emojiID || (emojiName != "" && entry.Emoji == emojiName) {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// roleIDs 获取菜单中的所有角色
+func (m *Menu) roleIDs() []int {
+	ids := make([]int, len(m.Entries))
+	for i, entry := range m.Entries {
+		ids[i] = entry.RoleID
+	}
+	return ids
+}
+
+// content 根据选项生成消息内容
+func (m *Menu) content(title string) string {
+	var b strings.Builder
+	if title != "" {
+		b.WriteString(title)
+		b.WriteString("\n\n")
+	}
+	for _, entry := range m.Entries {
+		label := entry.Label
+		if label == "" {
+			label = fmt.Sprintf("(rol)%d(rol)", entry.RoleID)
+		}
+		emoji := entry.Emoji
+		// 服务器表情ID形如 服务器ID/表情key，需要以 KMarkdown 语法显示
+		if strings.Contains(emoji, "/") {
+			emoji = fmt.Sprintf("(emj)emoji(emj)[%s]", emoji)
+		}
+		fmt.Fprintf(&b, "%s %s\n", emoji, label)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// copyMenu 复制菜单，避免调用方修改存储中的数据
+func copyMenu(menu *Menu) *Menu {
+	copied := *menu
+	copied.Entries = append([]Entry(nil), menu.Entries...)
+	return &copied
+}
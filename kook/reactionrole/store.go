@@ -0,0 +1,104 @@
+package reactionrole
+
+import (
+	"sync"
+
+	"kook-go-sdk/kook/internal/jsonfile"
+)
+
+// Store 反应角色绑定存储
+//
+// 绑定以消息ID为键，消息被删除时由 Manager 移除。
+type Store interface {
+	// Load 读取消息上的反应角色，没有时返回 nil, nil
+	Load(msgID string) (*Menu, error)
+	// Save 保存反应角色
+	Save(menu *Menu) error
+	// Delete 删除消息上的反应角色
+	Delete(msgID string) error
+	// List 列出所有反应角色
+	List() ([]*Menu, error)
+}
+
+// MemoryStore 内存存储，进程退出后绑定丢失
+type MemoryStore struct {
+	mu    sync.Mutex
+	menus map[string]*Menu
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{menus: make(map[string]*Menu)}
+}
+
+// Load 读取反应角色
+func (s *MemoryStore) Load(msgID string) (*Menu, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	menu, ok := s.menus[msgID]
+	if !ok {
+		return nil, nil
+	}
+	return copyMenu(menu), nil
+}
+
+// Save 保存反应角色
+func (s *MemoryStore) Save(menu *Menu) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.menus[menu.MsgID] = copyMenu(menu)
+	return nil
+}
+
+// Delete 删除反应角色
+func (s *MemoryStore) Delete(msgID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.menus, msgID)
+	return nil
+}
+
+// List 列出所有反应角色
+func (s *MemoryStore) List() ([]*Menu, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	menus := make([]*Menu, 0, len(s.menus))
+	for _, menu := range s.menus {
+		menus = append(menus, copyMenu(menu))
+	}
+	return menus, nil
+}
+
+// FileStore 文件存储，所有绑定以JSON格式保存在一个文件中
+type FileStore struct {
+	file *jsonfile.Map[Menu]
+}
+
+// NewFileStore 创建文件存储
+func NewFileStore(path string) *FileStore {
+	return &FileStore{file: jsonfile.NewMap[Menu](path, "反应角色")}
+}
+
+// Load 读取反应角色
+func (s *FileStore) Load(msgID string) (*Menu, error) {
+	return s.file.Load(msgID)
+}
+
+// Save 保存反应角色
+func (s *FileStore) Save(menu *Menu) error {
+	return s.file.Save(menu.MsgID, menu)
+}
+
+// Delete 删除反应角色
+func (s *FileStore) Delete(msgID string) error {
+	return s.file.Delete(msgID)
+}
+
+// List 列出所有反应角色
+func (s *FileStore) List() ([]*Menu, error) {
+	return s.file.List()
+}
@@ -106,6 +106,20 @@ type RetryableFunc func() (*Response, error)
 
 // DoWithRetry 执行带重试的操作
 func DoWithRetry(fn RetryableFunc, config *RetryConfig, logger Logger) (*Response, error) {
+	return doWithRetry(fn, config, logger, nil)
+}
+
+// doWithRetry 执行带重试的操作，每次重试前调用 onRetry
+func doWithRetry(fn RetryableFunc, config *RetryConfig, logger Logger, onRetry func(err error)) (*Response, error) {
+	if logger == nil {
+		logger = NewNopLogger()
+	}
+
+	retryable := config.RetryableError
+	if retryable == nil {
+		retryable = IsRetryableError
+	}
+
 	var lastErr error
 
 	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
@@ -115,9 +129,15 @@ func DoWithRetry(fn RetryableFunc, config *RetryConfig, logger Logger) (*Respons
 			if IsRateLimitError(lastErr) {
 				// 速率限制错误，使用更长的延迟
 				delay = delay * 2
-				logger.Warnf("遇到速率限制错误，等待 %v 后重试 (第 %d 次)", delay, attempt)
+				logger.Warn("遇到速率限制错误，等待后重试",
+					F("attempt", attempt+1), F("delay", delay))
 			} else {
-				logger.Warnf("请求失败，等待 %v 后重试 (第 %d 次): %v", delay, attempt, lastErr)
+				logger.Warn("请求失败，等待后重试",
+					F("attempt", attempt+1), F("delay", delay), ErrField(lastErr))
+			}
+
+			if onRetry != nil {
+				onRetry(lastErr)
 			}
 
 			time.Sleep(delay)
@@ -126,7 +146,7 @@ func DoWithRetry(fn RetryableFunc, config *RetryConfig, logger Logger) (*Respons
 		resp, err := fn()
 		if err == nil {
 			if attempt > 0 {
-				logger.Infof("重试成功 (第 %d 次尝试)", attempt+1)
+				logger.Info("重试成功", F("attempt", attempt+1))
 			}
 			return resp, nil
 		}
@@ -134,27 +154,20 @@ func DoWithRetry(fn RetryableFunc, config *RetryConfig, logger Logger) (*Respons
 		lastErr = err
 
 		// 检查是否为可重试错误
-		if !config.RetryableError(err) {
-			logger.Debugf("遇到不可重试错误: %v", err)
+		if !retryable(err) {
+			logger.Debug("遇到不可重试错误", F("attempt", attempt+1), ErrField(err))
 			break
 		}
 
 		if attempt == config.MaxRetries {
-			logger.Errorf("重试失败，已达到最大重试次数 (%d)", config.MaxRetries)
+			logger.Error("重试失败，已达到最大重试次数",
+				F("attempt", attempt+1), F("max_retries", config.MaxRetries))
 		}
 	}
 
 	return nil, fmt.Errorf("重试失败: %w", lastErr)
 }
 
-// Logger 日志接口
-type Logger interface {
-	Debugf(format string, args ...interface{})
-	Infof(format string, args ...interface{})
-	Warnf(format string, args ...interface{})
-	Errorf(format string, args ...interface{})
-}
-
 // ExtractRetryAfter 从 HTTP 响应头中提取 Retry-After 值
 func ExtractRetryAfter(resp *http.Response) time.Duration {
 	if resp == nil {
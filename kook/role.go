@@ -98,8 +98,8 @@ func (s *RoleService) UpdateRole(guildID string, roleID int, params UpdateRolePa
 	if params.Mentionable >= 0 {
 		requestParams["mentionable"] = params.Mentionable
 	}
-	if params.Permissions >= 0 {
-		requestParams["permissions"] = params.Permissions
+	if params.Permissions != nil {
+		requestParams["permissions"] = *params.Permissions
 	}
 
 	resp, err := s.client.Post("guild-role/update", requestParams)
@@ -201,6 +201,12 @@ func (s *RoleService) RevokeRole(guildID, userID string, roleID int) (*UserRoleR
 
 // 数据结构定义
 
+// 角色类型
+const (
+	RoleTypeCustom = 0 // 自定义角色
+	RoleTypeBot    = 1 // 机器人加入服务器时自动创建的角色，由KOOK管理，不能删除
+)
+
 // GuildRole 服务器角色信息
 type GuildRole struct {
 	RoleID      int `json:"role_id"`      // 角色ID
@@ -209,7 +215,8 @@ type GuildRole struct {
 	Position    int `json:"position"`     // 角色位置
 	Hoist       int `json:"hoist"`        // 是否在用户列表排到前面
 	Mentionable int `json:"mentionable"`  // 是否可以被提及
-	Permissions int `json:"permissions"`  // 权限值
+	Permissions Permissions `json:"permissions"`  // 权限值
+	Type        int `json:"type"`         // 角色类型，RoleType* 常量
 }
 
 // UpdateRoleParams 更新角色参数
@@ -218,7 +225,7 @@ type UpdateRoleParams struct {
 	Color       int    `json:"color,omitempty"`       // 角色色值
 	Hoist       int    `json:"hoist,omitempty"`       // 是否在用户列表排到前面
 	Mentionable int    `json:"mentionable,omitempty"` // 是否可以被提及
-	Permissions int    `json:"permissions,omitempty"` // 权限值
+	Permissions *Permissions `json:"permissions,omitempty"` // 权限值，为 nil 时不修改
 }
 
 // ListRolesResponse 角色列表响应
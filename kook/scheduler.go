@@ -0,0 +1,470 @@
+package kook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultJobStorePath 默认的任务存储文件
+const DefaultJobStorePath = "kook_jobs.json"
+
+// JobHandler 任务处理器，返回的错误可重试时按重试配置稍后重新执行
+type JobHandler func(ctx context.Context, job *Job) error
+
+// Scheduler 定时任务调度器
+//
+// 支持一次性任务与cron周期任务，任务保存在 JobStore 中，重启后从存储恢复，
+// 期间错过的任务在启动后立即执行一次。处理器返回可重试错误时按 RetryConfig 退避重试，
+// 默认使用客户端的重试配置。
+type Scheduler struct {
+	client          *Client
+	logger          Logger
+	store           JobStore
+	retry           *RetryConfig
+	location        *time.Location
+	shutdownTimeout time.Duration
+	now             func() time.Time
+
+	mu         sync.Mutex
+	handlers   map[string]JobHandler
+	jobs       map[string]*scheduledJob
+	running    bool
+	stopLoop   context.CancelFunc
+	loopDone   chan struct{}
+	jobCtx     context.Context
+	cancelJobs context.CancelFunc
+	wake       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// scheduledJob 已加载的任务
+type scheduledJob struct {
+	job      *Job
+	schedule CronSchedule
+	running  bool
+}
+
+// SchedulerOption 调度器配置选项
+type SchedulerOption func(*Scheduler)
+
+// WithJobStore 设置任务存储，默认使用 DefaultJobStorePath 文件存储
+func WithJobStore(store JobStore) SchedulerOption {
+	return func(s *Scheduler) {
+		s.store = store
+	}
+}
+
+// WithJobRetryConfig 设置任务失败后的重试配置，默认使用客户端的重试配置
+func WithJobRetryConfig(config *RetryConfig) SchedulerOption {
+	return func(s *Scheduler) {
+		s.retry = config
+	}
+}
+
+// WithSchedulerLocation 设置计算cron表达式使用的时区，默认为本地时区
+func WithSchedulerLocation(loc *time.Location) SchedulerOption {
+	return func(s *Scheduler) {
+		s.location = loc
+	}
+}
+
+// WithShutdownTimeout 设置随网关关闭时等待运行中任务结束的最长时间，默认30秒
+func WithShutdownTimeout(timeout time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// NewScheduler 创建定时任务调度器
+func NewScheduler(client *Client, opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		client:          client,
+		logger:          client.Logger(LogSubsystemScheduler),
+		retry:           client.retryConfig,
+		location:        time.Local,
+		shutdownTimeout: 30 * time.Second,
+		now:             time.Now,
+		handlers:        make(map[string]JobHandler),
+		jobs:            make(map[string]*scheduledJob),
+		wake:            make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.store == nil {
+		s.store = NewFileJobStore(DefaultJobStorePath)
+	}
+	if s.retry == nil {
+		s.retry = &RetryConfig{MaxRetries: 0}
+	}
+	return s
+}
+
+// Handle 注册任务处理器，需在 Start 之前注册，启动时没有处理器的任务会保留在存储中但不会执行
+func (s *Scheduler) Handle(name string, handler JobHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[name] = handler
+}
+
+// Schedule 添加在指定时间执行一次的任务，payload 会以JSON格式保存
+func (s *Scheduler) Schedule(name string, at time.Time, payload interface{}) (*Job, error) {
+	return s.add(name, "", at, payload)
+}
+
+// After 添加在 delay 后执行一次的任务
+func (s *Scheduler) After(name string, delay time.Duration, payload interface{}) (*Job, error) {
+	return s.add(name, "", s.now().Add(delay), payload)
+}
+
+// Cron 添加按cron表达式周期执行的任务，表达式格式见 ParseCron
+func (s *Scheduler) Cron(name, spec string, payload interface{}) (*Job, error) {
+	return s.add(name, spec, time.Time{}, payload)
+}
+
+// add 构造并添加任务
+func (s *Scheduler) add(name, spec string, at time.Time, payload interface{}) (*Job, error) {
+	job := &Job{Name: name, Spec: spec, RunAt: at}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("序列化任务参数失败: %w", err)
+		}
+		job.Payload = data
+	}
+	if err := s.Add(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Add 添加任务，ID 为空时自动生成，ID 已存在时替换原任务
+//
+// 周期任务未指定 RunAt 时按 Spec 计算下次执行时间；一次性任务必须指定 RunAt。
+// 可用固定ID避免每次启动重复添加同一个周期任务。
+func (s *Scheduler) Add(job *Job) error {
+	if job.Name == "" {
+		return fmt.Errorf("任务处理器名称不能为空")
+	}
+
+	now := s.now()
+	var schedule CronSchedule
+	if job.Recurring() {
+		var err error
+		if schedule, err = ParseCron(job.Spec); err != nil {
+			return err
+		}
+		if job.RunAt.IsZero() {
+			job.RunAt = schedule.Next(now.In(s.location))
+			if job.RunAt.IsZero() {
+				return fmt.Errorf("cron表达式 %q 没有后续执行时间", job.Spec)
+			}
+		}
+	} else if job.RunAt.IsZero() {
+		return fmt.Errorf("一次性任务需要指定执行时间")
+	}
+	if job.ID == "" {
+		job.ID = newJobID()
+	}
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = now
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.store.Save(job); err != nil {
+		return fmt.Errorf("保存任务失败: %w", err)
+	}
+	if s.running {
+		s.jobs[job.ID] = &scheduledJob{job: copyJob(job), schedule: schedule}
+		s.notify()
+	}
+	s.logger.Debug("添加定时任务",
+		F("id", job.ID),
+		F("name", job.Name),
+		F("run_at", job.RunAt.Format(time.RFC3339)),
+	)
+	return nil
+}
+
+// Cancel 取消任务，正在执行的任务会执行完毕但不再重试或继续周期执行
+func (s *Scheduler) Cancel(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.jobs, id)
+	if err := s.store.Delete(id); err != nil {
+		return fmt.Errorf("删除任务失败: %w", err)
+	}
+	return nil
+}
+
+// Get 获取任务，不存在时返回 nil
+func (s *Scheduler) Get(id string) (*Job, error) {
+	return s.store.Load(id)
+}
+
+// Jobs 列出所有任务
+func (s *Scheduler) Jobs() ([]*Job, error) {
+	return s.store.List()
+}
+
+// Start 从存储加载任务并开始调度，已启动时直接返回
+func (s *Scheduler) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return nil
+	}
+
+	stored, err := s.store.List()
+	if err != nil {
+		return fmt.Errorf("加载任务失败: %w", err)
+	}
+
+	s.jobs = make(map[string]*scheduledJob, len(stored))
+	for _, job := range stored {
+		var schedule CronSchedule
+		if job.Recurring() {
+			if schedule, err = ParseCron(job.Spec); err != nil {
+				s.logger.Error("任务的cron表达式无效，已跳过", F("id", job.ID), ErrField(err))
+				continue
+			}
+		}
+		s.jobs[job.ID] = &scheduledJob{job: job, schedule: schedule}
+	}
+
+	loopCtx, stopLoop := context.WithCancel(context.Background())
+	s.jobCtx, s.cancelJobs = context.WithCancel(s.client.Context())
+	s.stopLoop = stopLoop
+	s.loopDone = make(chan struct{})
+	s.running = true
+
+	go s.loop(loopCtx, s.loopDone)
+	s.logger.Info("定时任务调度器已启动", F("jobs", len(s.jobs)))
+	return nil
+}
+
+// Stop 停止调度并等待运行中的任务结束，未结束的任务保留在存储中，下次 Start 时继续
+//
+// ctx 结束时取消运行中任务的上下文，等待其返回后返回 ctx 的错误。可重复调用。
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return nil
+	}
+	s.running = false
+	s.stopLoop()
+	loopDone, cancelJobs := s.loopDone, s.cancelJobs
+	s.mu.Unlock()
+
+	<-loopDone
+	defer cancelJobs()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.logger.Info("定时任务调度器已停止")
+		return nil
+	case <-ctx.Done():
+		cancelJobs()
+		<-done
+		s.logger.Warn("等待任务结束超时，已取消运行中的任务")
+		return ctx.Err()
+	}
+}
+
+// shutdown 按关闭超时停止调度器，随网关关闭时调用
+func (s *Scheduler) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	return s.Stop(ctx)
+}
+
+// notify 唤醒调度协程重新计算等待时间
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// loop 调度协程，等待最早的任务到期后启动执行
+func (s *Scheduler) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	for {
+		next := s.dispatchDue()
+
+		var timeout <-chan time.Time
+		var timer *time.Timer
+		if !next.IsZero() {
+			timer = time.NewTimer(next.Sub(s.now()))
+			timeout = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-s.wake:
+		case <-timeout:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// dispatchDue 启动所有到期的任务，返回下一个任务的执行时间，没有任务时返回零值
+func (s *Scheduler) dispatchDue() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var next time.Time
+	for id, entry := range s.jobs {
+		if entry.running {
+			continue
+		}
+		if entry.job.RunAt.After(now) {
+			if next.IsZero() || entry.job.RunAt.Before(next) {
+				next = entry.job.RunAt
+			}
+			continue
+		}
+
+		handler, ok := s.handlers[entry.job.Name]
+		if !ok {
+			s.logger.Warn("任务没有注册处理器，已跳过", F("id", id), F("name", entry.job.Name))
+			delete(s.jobs, id)
+			continue
+		}
+
+		entry.running = true
+		s.wg.Add(1)
+		go s.run(s.jobCtx, entry, handler, copyJob(entry.job))
+	}
+	return next
+}
+
+// run 执行任务并根据结果安排重试或下次执行
+func (s *Scheduler) run(ctx context.Context, entry *scheduledJob, handler JobHandler, job *Job) {
+	defer s.wg.Done()
+
+	started := s.now()
+	err := invoke(ctx, handler, job)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.running = false
+	if s.jobs[job.ID] != entry {
+		// 执行期间任务被取消或替换
+		return
+	}
+	if err != nil && ctx.Err() != nil {
+		// 因停止而中断，保留任务在下次启动时重新执行
+		return
+	}
+	defer s.notify()
+
+	current := entry.job
+	current.LastRun = started
+	current.LastError = ""
+	if err != nil {
+		current.LastError = err.Error()
+	}
+
+	switch {
+	case err != nil && current.Attempt < s.retry.MaxRetries && s.retryable(err):
+		delay := GetRetryDelay(current.Attempt, s.retry)
+		current.Attempt++
+		current.RunAt = s.now().Add(delay)
+		s.logger.Warn("任务执行失败，等待后重试",
+			F("id", current.ID),
+			F("name", current.Name),
+			F("attempt", current.Attempt),
+			F("delay", delay.String()),
+			ErrField(err),
+		)
+
+	case current.Recurring():
+		if err != nil {
+			s.logger.Error("周期任务执行失败", F("id", current.ID), F("name", current.Name), ErrField(err))
+		}
+		current.Attempt = 0
+		current.RunAt = entry.schedule.Next(s.now().In(s.location))
+		if current.RunAt.IsZero() {
+			s.remove(current.ID)
+			return
+		}
+
+	default:
+		if err != nil {
+			s.logger.Error("任务执行失败", F("id", current.ID), F("name", current.Name), ErrField(err))
+		}
+		s.remove(current.ID)
+		return
+	}
+
+	if saveErr := s.store.Save(current); saveErr != nil {
+		s.logger.Error("保存任务失败", F("id", current.ID), ErrField(saveErr))
+	}
+}
+
+// invoke 调用处理器，处理器 panic 时作为错误返回
+func invoke(ctx context.Context, handler JobHandler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("任务处理器发生panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// remove 删除已完成的任务，调用方需持有锁
+func (s *Scheduler) remove(id string) {
+	delete(s.jobs, id)
+	if err := s.store.Delete(id); err != nil {
+		s.logger.Error("删除任务失败", F("id", id), ErrField(err))
+	}
+}
+
+// retryable 判断错误是否可重试，错误链中任一错误可重试即可
+func (s *Scheduler) retryable(err error) bool {
+	check := s.retry.RetryableError
+	if check == nil {
+		check = IsRetryableError
+	}
+	for ; err != nil; err = errors.Unwrap(err) {
+		if check(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// newJobID 生成随机任务ID
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,95 @@
+package kook
+
+import (
+	"sync"
+	"time"
+
+	"kook-go-sdk/kook/internal/jsonfile"
+)
+
+// GatewaySession 网关会话，用于断线或重启后恢复并补发错过的事件
+type GatewaySession struct {
+	SessionID string    `json:"session_id"` // 会话ID
+	SN        int       `json:"sn"`         // 最后处理的消息序号
+	UpdatedAt time.Time `json:"updated_at"` // 保存时间
+}
+
+// SessionStore 网关会话存储
+//
+// WebSocketClient 在会话建立和处理事件后保存会话，连接时读取会话并尝试恢复。
+// 可自行实现该接口将会话保存到 Redis、数据库等位置。
+type SessionStore interface {
+	// Load 读取会话，没有会话时返回 nil, nil
+	Load() (*GatewaySession, error)
+	// Save 保存会话
+	Save(session *GatewaySession) error
+	// Clear 清除会话
+	Clear() error
+}
+
+// MemorySessionStore 内存会话存储，只在进程内有效，适用于同一进程中重建客户端
+type MemorySessionStore struct {
+	mu      sync.Mutex
+	session *GatewaySession
+}
+
+// NewMemorySessionStore 创建内存会话存储
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{}
+}
+
+// Load 读取会话
+func (s *MemorySessionStore) Load() (*GatewaySession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.session == nil {
+		return nil, nil
+	}
+	session := *s.session
+	return &session, nil
+}
+
+// Save 保存会话
+func (s *MemorySessionStore) Save(session *GatewaySession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *session
+	s.session = &copied
+	return nil
+}
+
+// Clear 清除会话
+func (s *MemorySessionStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.session = nil
+	return nil
+}
+
+// FileSessionStore 文件会话存储，会话以JSON格式保存，进程重启后仍可恢复
+type FileSessionStore struct {
+	file *jsonfile.File[*GatewaySession]
+}
+
+// NewFileSessionStore 创建文件会话存储
+func NewFileSessionStore(path string) *FileSessionStore {
+	return &FileSessionStore{file: jsonfile.New[*GatewaySession](path, "会话")}
+}
+
+// Load 读取会话
+func (s *FileSessionStore) Load() (*GatewaySession, error) {
+	return s.file.Read()
+}
+
+// Save 保存会话
+func (s *FileSessionStore) Save(session *GatewaySession) error {
+	return s.file.Write(session)
+}
+
+// Clear 清除会话
+func (s *FileSessionStore) Clear() error {
+	return s.file.Remove()
+}
@@ -0,0 +1,296 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"kook-go-sdk/kook"
+)
+
+// options 备份与恢复选项
+type options struct {
+	httpClient    *http.Client
+	logger        kook.Logger
+	skipSettings  bool
+	skipEmojis    bool
+	skipInvites   bool
+	skipBlacklist bool
+}
+
+// Option 备份与恢复配置选项
+type Option func(*options)
+
+// WithHTTPClient 设置下载表情图片使用的HTTP客户端
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = httpClient
+	}
+}
+
+// WithLogger 设置日志器
+func WithLogger(logger kook.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithoutSettings 恢复时不修改服务器名称、区域等设置
+func WithoutSettings() Option {
+	return func(o *options) {
+		o.skipSettings = true
+	}
+}
+
+// WithoutEmojis 不备份或恢复表情
+func WithoutEmojis() Option {
+	return func(o *options) {
+		o.skipEmojis = true
+	}
+}
+
+// WithoutInvites 不备份或恢复邀请
+func WithoutInvites() Option {
+	return func(o *options) {
+		o.skipInvites = true
+	}
+}
+
+// WithoutBlacklist 不备份或恢复黑名单
+func WithoutBlacklist() Option {
+	return func(o *options) {
+		o.skipBlacklist = true
+	}
+}
+
+func newOptions(client *kook.Client, opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.httpClient == nil {
+		o.httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if o.logger == nil {
+		o.logger = client.Logger("snapshot")
+	}
+	return o
+}
+
+// Backup 备份服务器
+func Backup(client *kook.Client, guildID string, opts ...Option) (*Snapshot, error) {
+	if guildID == "" {
+		return nil, fmt.Errorf("服务器ID不能为空")
+	}
+	o := newOptions(client, opts)
+
+	guild, err := client.Guild.GetGuildInfo(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &Snapshot{
+		Version:   Version,
+		CreatedAt: time.Now().UTC(),
+		GuildID:   guildID,
+		Guild: Guild{
+			Name:             guild.Name,
+			Region:           guild.Region,
+			NotifyType:       guild.NotifyType,
+			EnableOpen:       guild.EnableOpen,
+			DefaultChannelID: guild.DefaultChannelID,
+			WelcomeChannelID: guild.WelcomeChannelID,
+		},
+	}
+
+	if err := backupRoles(client, guildID, snap); err != nil {
+		return nil, err
+	}
+	if err := backupChannels(client, guildID, snap); err != nil {
+		return nil, err
+	}
+	if !o.skipEmojis {
+		if err := backupEmojis(client, guildID, snap, o); err != nil {
+			return nil, err
+		}
+	}
+	if !o.skipInvites {
+		if err := backupInvites(client, guildID, snap); err != nil {
+			return nil, err
+		}
+	}
+	if !o.skipBlacklist {
+		if err := backupBlacklist(client, guildID, snap); err != nil {
+			return nil, err
+		}
+	}
+
+	o.logger.Info("服务器备份完成",
+		kook.F("guild_id", guildID),
+		kook.F("roles", len(snap.Roles)),
+		kook.F("channels", len(snap.Channels)),
+		kook.F("emojis", len(snap.Emojis)),
+		kook.F("invites", len(snap.Invites)),
+		kook.F("blacklist", len(snap.Blacklist)))
+	return snap, nil
+}
+
+func backupRoles(client *kook.Client, guildID string, snap *Snapshot) error {
+	for page := 1; ; page++ {
+		result, err := client.Role.GetRoleList(guildID, page, 50)
+		if err != nil {
+			return err
+		}
+		for _, role := range result.Items {
+			snap.Roles = append(snap.Roles, Role{
+				ID:          role.RoleID,
+				Name:        role.Name,
+				Color:       role.Color,
+				Position:    role.Position,
+				Hoist:       role.Hoist,
+				Mentionable: role.Mentionable,
+				Permissions: role.Permissions,
+				Type:        role.Type,
+			})
+		}
+		if page >= result.Meta.PageTotal {
+			break
+		}
+	}
+
+	sort.SliceStable(snap.Roles, func(i, j int) bool {
+		return snap.Roles[i].Position < snap.Roles[j].Position
+	})
+	return nil
+}
+
+func backupChannels(client *kook.Client, guildID string, snap *Snapshot) error {
+	for page := 1; ; page++ {
+		result, err := client.Channel.GetChannelList(guildID, page, 50, "")
+		if err != nil {
+			return err
+		}
+		for _, channel := range result.Items {
+			snap.Channels = append(snap.Channels, Channel{
+				ID:             channel.ID,
+				Name:           channel.Name,
+				IsCategory:     channel.IsCategory,
+				ParentID:       channel.ParentID,
+				Type:           channel.Type,
+				Level:          channel.Level,
+				Topic:          channel.Topic,
+				SlowMode:       channel.SlowMode,
+				LimitAmount:    channel.LimitAmount,
+				VoiceQuality:   channel.VoiceQuality,
+				PermissionSync: channel.PermissionSync,
+			})
+		}
+		if page >= result.Meta.PageTotal {
+			break
+		}
+	}
+
+	sort.SliceStable(snap.Channels, func(i, j int) bool {
+		return snap.Channels[i].Level < snap.Channels[j].Level
+	})
+
+	for i := range snap.Channels {
+		overwrites, err := client.ChannelRole.List(snap.Channels[i].ID)
+		if err != nil {
+			return fmt.Errorf("获取频道 %s 的权限失败: %w", snap.Channels[i].Name, err)
+		}
+		snap.Channels[i].Overwrites = overwrites.Overwrites()
+		snap.Channels[i].PermissionSync = overwrites.PermissionSync
+	}
+	return nil
+}
+
+func backupEmojis(client *kook.Client, guildID string, snap *Snapshot, o *options) error {
+	for page := 1; ; page++ {
+		result, err := client.Emoji.GetEmojiList(guildID, page, 50)
+		if err != nil {
+			return err
+		}
+		for _, emoji := range result.Items {
+			data, err := download(o.httpClient, emoji.URL)
+			if err != nil {
+				// 图片下载失败时仍保留表情信息，恢复时跳过
+				o.logger.Warn("下载表情图片失败", kook.F("emoji", emoji.Name), kook.ErrField(err))
+			}
+			snap.Emojis = append(snap.Emojis, Emoji{
+				ID:   emoji.ID,
+				Name: emoji.Name,
+				URL:  emoji.URL,
+				Data: data,
+			})
+		}
+		if page >= result.Meta.PageTotal {
+			break
+		}
+	}
+	return nil
+}
+
+func backupInvites(client *kook.Client, guildID string, snap *Snapshot) error {
+	for page := 1; ; page++ {
+		result, err := client.Invite.GetInviteList(guildID, page, 50)
+		if err != nil {
+			return err
+		}
+		for _, invite := range result.Items {
+			snap.Invites = append(snap.Invites, Invite{
+				URLCode:   invite.URLCode,
+				ChannelID: invite.ChannelID,
+				Duration:  invite.Duration,
+				Setting:   invite.Setting,
+			})
+		}
+		if page >= result.Meta.PageTotal {
+			break
+		}
+	}
+	return nil
+}
+
+func backupBlacklist(client *kook.Client, guildID string, snap *Snapshot) error {
+	for page := 1; ; page++ {
+		result, err := client.Blacklist.GetBlacklistUsers(guildID, page, 50)
+		if err != nil {
+			return err
+		}
+		for _, user := range result.Items {
+			userID := user.UserID
+			if userID == "" {
+				userID = user.User.ID
+			}
+			snap.Blacklist = append(snap.Blacklist, BlockUser{
+				UserID: userID,
+				Remark: user.Remark,
+			})
+		}
+		if page >= result.Meta.PageTotal {
+			break
+		}
+	}
+	return nil
+}
+
+// download 下载文件内容
+func download(httpClient *http.Client, url string) ([]byte, error) {
+	if url == "" {
+		return nil, fmt.Errorf("地址为空")
+	}
+
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP状态码 %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,435 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+
+	"kook-go-sdk/kook"
+)
+
+// IDMap 快照中的旧ID到恢复后新ID的映射
+type IDMap struct {
+	Roles    map[int]int       `json:"roles"`    // 角色ID
+	Channels map[string]string `json:"channels"` // 分组与频道ID
+	Emojis   map[string]string `json:"emojis"`   // 表情ID
+	Invites  map[string]string `json:"invites"`  // 邀请码
+}
+
+func newIDMap() *IDMap {
+	return &IDMap{
+		Roles:    map[int]int{0: 0},
+		Channels: make(map[string]string),
+		Emojis:   make(map[string]string),
+		Invites:  make(map[string]string),
+	}
+}
+
+// restorer 恢复过程的状态
+type restorer struct {
+	client  *kook.Client
+	guildID string
+	snap    *Snapshot
+	opts    *options
+	ids     *IDMap
+	errs    []error
+}
+
+// Restore 将快照恢复到 guildID 指定的服务器，可以是原服务器或新服务器
+//
+// 已存在的同名角色、同一分组下的同名频道以及同名表情会被复用并更新，不会重复创建。
+// KOOK 没有调整角色位置的接口，角色位置不会恢复；机器人角色由KOOK管理，不会恢复，引用它们的权限覆写会被跳过。
+// 单个对象恢复失败不会中断整个过程，所有错误合并后返回，返回的 IDMap 包含已恢复的对象。
+func Restore(client *kook.Client, snap *Snapshot, guildID string, opts ...Option) (*IDMap, error) {
+	if guildID == "" {
+		return nil, fmt.Errorf("服务器ID不能为空")
+	}
+	if snap == nil {
+		return nil, fmt.Errorf("快照不能为空")
+	}
+
+	r := &restorer{
+		client:  client,
+		guildID: guildID,
+		snap:    snap,
+		opts:    newOptions(client, opts),
+		ids:     newIDMap(),
+	}
+
+	if !r.opts.skipSettings {
+		r.restoreSettings()
+	}
+	if err := r.restoreRoles(); err != nil {
+		return r.ids, err
+	}
+	if err := r.restoreChannels(); err != nil {
+		return r.ids, err
+	}
+	r.restoreOverwrites()
+	r.restoreOrder()
+	if !r.opts.skipSettings {
+		r.restoreDefaultChannels()
+	}
+	if !r.opts.skipEmojis {
+		if err := r.restoreEmojis(); err != nil {
+			return r.ids, err
+		}
+	}
+	if !r.opts.skipInvites {
+		if err := r.restoreInvites(); err != nil {
+			return r.ids, err
+		}
+	}
+	if !r.opts.skipBlacklist {
+		if err := r.restoreBlacklist(); err != nil {
+			return r.ids, err
+		}
+	}
+
+	r.opts.logger.Info("服务器恢复完成",
+		kook.F("guild_id", guildID),
+		kook.F("source_guild_id", snap.GuildID),
+		kook.F("errors", len(r.errs)))
+	return r.ids, errors.Join(r.errs...)
+}
+
+// fail 记录单个对象的恢复错误
+func (r *restorer) fail(kind, name string, err error) {
+	r.opts.logger.Warn("恢复失败", kook.F("kind", kind), kook.F("name", name), kook.ErrField(err))
+	r.errs = append(r.errs, fmt.Errorf("恢复%s %s 失败: %w", kind, name, err))
+}
+
+func (r *restorer) restoreSettings() {
+	enableOpen := r.snap.Guild.EnableOpen
+	_, err := r.client.Guild.UpdateGuild(r.guildID, kook.UpdateGuildParams{
+		Name:       r.snap.Guild.Name,
+		Region:     r.snap.Guild.Region,
+		NotifyType: r.snap.Guild.NotifyType,
+		EnableOpen: &enableOpen,
+	})
+	if err != nil {
+		r.fail("服务器设置", r.snap.Guild.Name, err)
+	}
+}
+
+func (r *restorer) restoreRoles() error {
+	existing := make(map[string]int)
+	for page := 1; ; page++ {
+		result, err := r.client.Role.GetRoleList(r.guildID, page, 50)
+		if err != nil {
+			return err
+		}
+		for _, role := range result.Items {
+			if role.RoleID == 0 || role.Type == kook.RoleTypeBot {
+				continue
+			}
+			if _, ok := existing[role.Name]; !ok {
+				existing[role.Name] = role.RoleID
+			}
+		}
+		if page >= result.Meta.PageTotal {
+			break
+		}
+	}
+
+	for _, role := range r.snap.Roles {
+		// 全体成员角色无法通过接口修改
+		if role.ID == 0 {
+			continue
+		}
+		if role.Type == kook.RoleTypeBot {
+			r.opts.logger.Warn("跳过机器人角色", kook.F("role", role.Name), kook.F("role_id", role.ID))
+			continue
+		}
+
+		roleID, ok := existing[role.Name]
+		if ok {
+			delete(existing, role.Name)
+		} else {
+			created, err := r.client.Role.CreateRole(r.guildID, role.Name)
+			if err != nil {
+				r.fail("角色", role.Name, err)
+				continue
+			}
+			roleID = created.RoleID
+		}
+		r.ids.Roles[role.ID] = roleID
+
+		permissions := role.Permissions
+		_, err := r.client.Role.UpdateRole(r.guildID, roleID, kook.UpdateRoleParams{
+			Name:        role.Name,
+			Color:       role.Color,
+			Hoist:       role.Hoist,
+			Mentionable: role.Mentionable,
+			Permissions: &permissions,
+		})
+		if err != nil {
+			r.fail("角色", role.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *restorer) restoreChannels() error {
+	// 目标服务器中已有的频道，按 "父分组ID/名称" 索引
+	existing := make(map[string]string)
+	for page := 1; ; page++ {
+		result, err := r.client.Channel.GetChannelList(r.guildID, page, 50, "")
+		if err != nil {
+			return err
+		}
+		for _, channel := range result.Items {
+			key := channelKey(channel.IsCategory, channel.ParentID, channel.Name)
+			if _, ok := existing[key]; !ok {
+				existing[key] = channel.ID
+			}
+		}
+		if page >= result.Meta.PageTotal {
+			break
+		}
+	}
+
+	// 先恢复分组，再恢复频道，保证父分组已存在
+	for _, categories := range []bool{true, false} {
+		for _, channel := range r.snap.Channels {
+			if channel.IsCategory != categories {
+				continue
+			}
+			r.restoreChannel(channel, existing)
+		}
+	}
+	return nil
+}
+
+func channelKey(isCategory bool, parentID, name string) string {
+	if isCategory {
+		return "category/" + name
+	}
+	if parentID == "0" {
+		parentID = ""
+	}
+	return parentID + "/" + name
+}
+
+func (r *restorer) restoreChannel(channel Channel, existing map[string]string) {
+	parentID := ""
+	if channel.ParentID != "" && channel.ParentID != "0" {
+		mapped, ok := r.ids.Channels[channel.ParentID]
+		if !ok {
+			r.fail("频道", channel.Name, fmt.Errorf("父分组 %s 未恢复", channel.ParentID))
+			return
+		}
+		parentID = mapped
+	}
+
+	key := channelKey(channel.IsCategory, parentID, channel.Name)
+	channelID, ok := existing[key]
+	if ok {
+		delete(existing, key)
+	} else {
+		created, err := r.client.Channel.CreateChannel(r.guildID, kook.CreateChannelParams{
+			Name:         channel.Name,
+			Type:         channel.Type,
+			ParentID:     parentID,
+			LimitAmount:  channel.LimitAmount,
+			VoiceQuality: channel.VoiceQuality,
+			IsCategory:   channel.IsCategory,
+		})
+		if err != nil {
+			r.fail("频道", channel.Name, err)
+			return
+		}
+		channelID = created.ID
+	}
+	r.ids.Channels[channel.ID] = channelID
+
+	if channel.IsCategory {
+		return
+	}
+	_, err := r.client.Channel.UpdateChannel(channelID, kook.UpdateChannelParams{
+		Topic:        channel.Topic,
+		SlowMode:     channel.SlowMode,
+		LimitAmount:  channel.LimitAmount,
+		VoiceQuality: channel.VoiceQuality,
+	})
+	if err != nil {
+		r.fail("频道", channel.Name, err)
+	}
+}
+
+func (r *restorer) restoreOverwrites() {
+	for _, channel := range r.snap.Channels {
+		channelID, ok := r.ids.Channels[channel.ID]
+		if !ok {
+			continue
+		}
+
+		// 与分组同步的频道直接同步，不单独设置覆写
+		if channel.PermissionSync == 1 && channel.ParentID != "" && channel.ParentID != "0" {
+			if _, err := r.client.ChannelRole.Sync(channelID); err != nil {
+				r.fail("频道权限", channel.Name, err)
+			}
+			continue
+		}
+
+		desired := make([]kook.ChannelOverwrite, 0, len(channel.Overwrites))
+		for _, o := range channel.Overwrites {
+			if o.Type == kook.OverwriteTypeRole {
+				oldID, _ := strconv.Atoi(o.Value)
+				newID, ok := r.ids.Roles[oldID]
+				if !ok {
+					r.opts.logger.Warn("跳过未恢复角色的权限覆写", kook.F("channel", channel.Name), kook.F("role_id", oldID))
+					continue
+				}
+				o = kook.RoleOverwrite(newID, o.Allow, o.Deny)
+			}
+			desired = append(desired, o)
+		}
+
+		if _, err := r.client.ChannelRole.Apply(channelID, desired, true); err != nil {
+			r.fail("频道权限", channel.Name, err)
+		}
+	}
+}
+
+func (r *restorer) restoreOrder() {
+	channelIDs := make([]string, 0, len(r.snap.Channels))
+	for _, channel := range r.snap.Channels {
+		if id, ok := r.ids.Channels[channel.ID]; ok {
+			channelIDs = append(channelIDs, id)
+		}
+	}
+	if len(channelIDs) == 0 {
+		return
+	}
+	if err := r.client.Channel.MoveChannel(r.guildID, channelIDs); err != nil {
+		r.fail("频道顺序", r.guildID, err)
+	}
+}
+
+func (r *restorer) restoreDefaultChannels() {
+	params := kook.UpdateGuildParams{
+		DefaultChannelID: r.ids.Channels[r.snap.Guild.DefaultChannelID],
+		WelcomeChannelID: r.ids.Channels[r.snap.Guild.WelcomeChannelID],
+		NotifyType:       -1,
+	}
+	if params.DefaultChannelID == "" && params.WelcomeChannelID == "" {
+		return
+	}
+	if _, err := r.client.Guild.UpdateGuild(r.guildID, params); err != nil {
+		r.fail("服务器设置", "默认频道", err)
+	}
+}
+
+func (r *restorer) restoreEmojis() error {
+	existing := make(map[string]string)
+	for page := 1; ; page++ {
+		result, err := r.client.Emoji.GetEmojiList(r.guildID, page, 50)
+		if err != nil {
+			return err
+		}
+		for _, emoji := range result.Items {
+			existing[emoji.Name] = emoji.ID
+		}
+		if page >= result.Meta.PageTotal {
+			break
+		}
+	}
+
+	for _, emoji := range r.snap.Emojis {
+		if id, ok := existing[emoji.Name]; ok {
+			r.ids.Emojis[emoji.ID] = id
+			continue
+		}
+		if len(emoji.Data) == 0 {
+			r.fail("表情", emoji.Name, fmt.Errorf("快照中没有表情图片"))
+			continue
+		}
+
+		ext := path.Ext(emoji.URL)
+		if ext == "" {
+			ext = ".png"
+		}
+		created, err := r.client.Emoji.UploadEmoji(r.guildID, emoji.Name, emoji.Name+ext, emoji.Data)
+		if err != nil {
+			r.fail("表情", emoji.Name, err)
+			continue
+		}
+		r.ids.Emojis[emoji.ID] = created.ID
+	}
+	return nil
+}
+
+func (r *restorer) restoreInvites() error {
+	existing := make(map[string]bool)
+	for page := 1; ; page++ {
+		result, err := r.client.Invite.GetInviteList(r.guildID, page, 50)
+		if err != nil {
+			return err
+		}
+		for _, invite := range result.Items {
+			existing[invite.URLCode] = true
+		}
+		if page >= result.Meta.PageTotal {
+			break
+		}
+	}
+
+	for _, invite := range r.snap.Invites {
+		// 恢复到原服务器时邀请仍然有效
+		if existing[invite.URLCode] {
+			r.ids.Invites[invite.URLCode] = invite.URLCode
+			continue
+		}
+
+		params := kook.CreateInviteParams{
+			GuildID:  r.guildID,
+			Duration: invite.Duration,
+			Setting:  invite.Setting,
+		}
+		if invite.ChannelID != "" {
+			channelID, ok := r.ids.Channels[invite.ChannelID]
+			if !ok {
+				r.fail("邀请", invite.URLCode, fmt.Errorf("频道 %s 未恢复", invite.ChannelID))
+				continue
+			}
+			params.ChannelID = channelID
+		}
+
+		created, err := r.client.Invite.CreateInvite(params)
+		if err != nil {
+			r.fail("邀请", invite.URLCode, err)
+			continue
+		}
+		r.ids.Invites[invite.URLCode] = created.URLCode
+	}
+	return nil
+}
+
+func (r *restorer) restoreBlacklist() error {
+	existing := make(map[string]bool)
+	for page := 1; ; page++ {
+		result, err := r.client.Blacklist.GetBlacklistUsers(r.guildID, page, 50)
+		if err != nil {
+			return err
+		}
+		for _, user := range result.Items {
+			existing[user.UserID] = true
+			existing[user.User.ID] = true
+		}
+		if page >= result.Meta.PageTotal {
+			break
+		}
+	}
+
+	for _, user := range r.snap.Blacklist {
+		if existing[user.UserID] {
+			continue
+		}
+		if err := r.client.Blacklist.CreateBlacklistUser(r.guildID, user.UserID, user.Remark, 0); err != nil {
+			r.fail("黑名单用户", user.UserID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Package snapshot 备份与恢复KOOK服务器结构
+//
+// Backup 通过现有的API服务采集服务器设置、角色、分组与频道（含权限覆写）、
+// 表情（含图片）、邀请和黑名单，保存为带版本号的快照文件；
+// Restore 将快照恢复到原服务器或新服务器，并返回旧ID到新ID的映射。
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"kook-go-sdk/kook"
+)
+
+// Version 当前快照格式版本
+const Version = 1
+
+// Snapshot 服务器快照
+type Snapshot struct {
+	Version   int         `json:"version"`    // 快照格式版本
+	CreatedAt time.Time   `json:"created_at"` // 备份时间
+	GuildID   string      `json:"guild_id"`   // 备份的服务器ID
+	Guild     Guild       `json:"guild"`      // 服务器设置
+	Roles     []Role      `json:"roles"`      // 角色，按位置排序
+	Channels  []Channel   `json:"channels"`   // 分组与频道，按位置排序
+	Emojis    []Emoji     `json:"emojis"`     // 表情
+	Invites   []Invite    `json:"invites"`    // 邀请
+	Blacklist []BlockUser `json:"blacklist"`  // 黑名单
+}
+
+// Guild 服务器设置
+type Guild struct {
+	Name             string `json:"name"`
+	Region           string `json:"region"`
+	NotifyType       int    `json:"notify_type"`
+	EnableOpen       bool   `json:"enable_open"`
+	DefaultChannelID string `json:"default_channel_id"`
+	WelcomeChannelID string `json:"welcome_channel_id"`
+}
+
+// Role 角色
+type Role struct {
+	ID          int              `json:"id"`
+	Name        string           `json:"name"`
+	Color       int              `json:"color"`
+	Position    int              `json:"position"`
+	Hoist       int              `json:"hoist"`
+	Mentionable int              `json:"mentionable"`
+	Permissions kook.Permissions `json:"permissions"`
+	Type        int              `json:"type,omitempty"` // 角色类型，kook.RoleType* 常量
+}
+
+// Channel 分组或频道
+type Channel struct {
+	ID             string                  `json:"id"`
+	Name           string                  `json:"name"`
+	IsCategory     bool                    `json:"is_category"`
+	ParentID       string                  `json:"parent_id,omitempty"`
+	Type           int                     `json:"type"`
+	Level          int                     `json:"level"`
+	Topic          string                  `json:"topic,omitempty"`
+	SlowMode       int                     `json:"slow_mode,omitempty"`
+	LimitAmount    int                     `json:"limit_amount,omitempty"`
+	VoiceQuality   int                     `json:"voice_quality,omitempty"`
+	PermissionSync int                     `json:"permission_sync"`
+	Overwrites     []kook.ChannelOverwrite `json:"overwrites"`
+}
+
+// Emoji 表情，Data 为下载的图片内容
+type Emoji struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	Data []byte `json:"data,omitempty"`
+}
+
+// Invite 邀请
+type Invite struct {
+	URLCode   string `json:"url_code"`
+	ChannelID string `json:"channel_id,omitempty"`
+	Duration  int    `json:"duration"`
+	Setting   int    `json:"setting"`
+}
+
+// BlockUser 黑名单用户
+type BlockUser struct {
+	UserID string `json:"user_id"`
+	Remark string `json:"remark,omitempty"`
+}
+
+// WriteTo 以JSON格式输出快照
+func (s *Snapshot) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("序列化快照失败: %w", err)
+	}
+	n, err := w.Write(append(data, '\n'))
+	return int64(n), err
+}
+
+// Save 将快照保存到文件
+func (s *Snapshot) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建快照文件失败: %w", err)
+	}
+
+	if _, err := s.WriteTo(file); err != nil {
+		file.Close()
+		return fmt.Errorf("写入快照文件失败: %w", err)
+	}
+	return file.Close()
+}
+
+// Read 从 r 读取快照
+func Read(r io.Reader) (*Snapshot, error) {
+	var s Snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("解析快照失败: %w", err)
+	}
+	if s.Version == 0 || s.Version > Version {
+		return nil, fmt.Errorf("不支持的快照版本: %d", s.Version)
+	}
+	return &s, nil
+}
+
+// Load 从文件加载快照
+func Load(path string) (*Snapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开快照文件失败: %w", err)
+	}
+	defer file.Close()
+
+	return Read(file)
+}
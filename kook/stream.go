@@ -0,0 +1,441 @@
+package kook
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// EventFilter 事件过滤条件，返回 true 表示保留该事件
+type EventFilter func(*Event) bool
+
+// FilterGuild 只保留指定服务器的事件
+func FilterGuild(guildID string) EventFilter {
+	return func(event *Event) bool {
+		return event.GuildID() == guildID
+	}
+}
+
+// FilterChannel 只保留指定频道的事件
+func FilterChannel(channelID string) EventFilter {
+	return func(event *Event) bool {
+		return event.ChannelID() == channelID
+	}
+}
+
+// FilterAuthor 只保留指定用户发送的事件
+func FilterAuthor(userID string) EventFilter {
+	return func(event *Event) bool {
+		return event.AuthorID == userID
+	}
+}
+
+// FilterEventType 只保留指定类型的事件
+func FilterEventType(eventTypes ...int) EventFilter {
+	return func(event *Event) bool {
+		for _, eventType := range eventTypes {
+			if event.Type == eventType {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FilterSystemEvent 只保留指定子类型的系统事件，如 SystemEventJoinedGuild
+func FilterSystemEvent(kinds ...string) EventFilter {
+	return func(event *Event) bool {
+		if !event.IsSystemEvent() {
+			return false
+		}
+		extra, err := event.SystemEvent()
+		if err != nil {
+			return false
+		}
+		for _, kind := range kinds {
+			if extra.Type == kind {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FilterMessages 只保留消息事件，不含系统事件
+func FilterMessages() EventFilter {
+	return func(event *Event) bool {
+		return !event.IsSystemEvent()
+	}
+}
+
+// FilterPrivate 只保留私聊事件
+func FilterPrivate() EventFilter {
+	return func(event *Event) bool {
+		return event.ChannelType == "PERSON"
+	}
+}
+
+// FilterAny 满足任一条件即保留
+func FilterAny(filters ...EventFilter) EventFilter {
+	return func(event *Event) bool {
+		for _, filter := range filters {
+			if filter(event) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FilterNot 保留不满足条件的事件
+func FilterNot(filter EventFilter) EventFilter {
+	return func(event *Event) bool {
+		return !filter(event)
+	}
+}
+
+// matchFilters 判断事件是否满足所有条件
+func matchFilters(event *Event, filters []EventFilter) bool {
+	for _, filter := range filters {
+		if !filter(event) {
+			return false
+		}
+	}
+	return true
+}
+
+// streamEventTypes 事件流默认订阅的事件类型
+var streamEventTypes = []int{
+	EventTypeTextMessage,
+	EventTypeImageMessage,
+	EventTypeVideoMessage,
+	EventTypeFileMessage,
+	EventTypeAudioMessage,
+	EventTypeKMDMessage,
+	EventTypeCardMessage,
+	EventTypeSystem,
+}
+
+// EventSeq 事件序列，与 Go 1.23 的 iter.Seq[*Event] 签名相同，可直接用于 for range
+type EventSeq func(yield func(*Event) bool)
+
+// Filter 返回只包含满足所有条件的事件的序列
+func (seq EventSeq) Filter(filters ...EventFilter) EventSeq {
+	return func(yield func(*Event) bool) {
+		seq(func(event *Event) bool {
+			if !matchFilters(event, filters) {
+				return true
+			}
+			return yield(event)
+		})
+	}
+}
+
+// streamHubs 自定义事件源上的事件流分发器，最后一个事件流关闭时移除
+//
+// SDK 内置的事件源各自持有分发器，不经过这里，随事件源一起回收。
+var (
+	streamHubsMu sync.Mutex
+	streamHubs   = make(map[EventDispatcher]*streamHub)
+)
+
+// hubSource 自己持有事件流分发器的事件源
+type hubSource interface {
+	streamHub() *streamHub
+}
+
+// hubSlot 嵌入内置事件源的字段，首次创建事件流时创建分发器
+type hubSlot struct {
+	once sync.Once
+	hub  *streamHub
+}
+
+// get 获取分发器，不存在时为 dispatcher 创建
+func (slot *hubSlot) get(dispatcher EventDispatcher) *streamHub {
+	slot.once.Do(func() {
+		slot.hub = newStreamHub(dispatcher, false)
+	})
+	return slot.hub
+}
+
+// streamHub 在事件源上注册一次处理器，将事件分发给当前订阅的事件流
+//
+// 事件流关闭时从订阅中移除，因此创建再多事件流也不会在事件源上累积处理器。
+type streamHub struct {
+	dispatcher EventDispatcher
+	shared     bool // 是否保存在 streamHubs 中
+
+	mu         sync.RWMutex
+	nextID     uint64
+	registered map[int]bool
+	streams    map[uint64]*EventStream
+}
+
+func newStreamHub(dispatcher EventDispatcher, shared bool) *streamHub {
+	return &streamHub{
+		dispatcher: dispatcher,
+		shared:     shared,
+		registered: make(map[int]bool),
+		streams:    make(map[uint64]*EventStream),
+	}
+}
+
+// subscribeStream 将事件流订阅到事件源的分发器
+func subscribeStream(dispatcher EventDispatcher, s *EventStream) {
+	if source, ok := dispatcher.(hubSource); ok {
+		s.hub = source.streamHub()
+		s.hub.subscribe(s)
+		return
+	}
+	// 不可比较的事件源不能作为键，每个事件流单独注册处理器
+	if !reflect.TypeOf(dispatcher).Comparable() {
+		s.hub = newStreamHub(dispatcher, false)
+		s.hub.subscribe(s)
+		return
+	}
+
+	// 持有锁直到订阅完成，避免分发器在订阅前被移除
+	streamHubsMu.Lock()
+	defer streamHubsMu.Unlock()
+
+	hub, ok := streamHubs[dispatcher]
+	if !ok {
+		hub = newStreamHub(dispatcher, true)
+		streamHubs[dispatcher] = hub
+	}
+	s.hub = hub
+	hub.subscribe(s)
+}
+
+// subscribe 添加事件流，事件类型首次被订阅时在事件源上注册处理器
+func (h *streamHub) subscribe(s *EventStream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	s.id = h.nextID
+	h.streams[s.id] = s
+
+	for eventType := range s.eventTypes {
+		if h.registered[eventType] {
+			continue
+		}
+		h.registered[eventType] = true
+		eventType := eventType
+		h.dispatcher.OnEvent(eventType, func(event *Event) {
+			h.dispatch(eventType, event)
+		})
+	}
+}
+
+// unsubscribe 移除事件流，共享的分发器没有事件流时从 streamHubs 中移除
+func (h *streamHub) unsubscribe(s *EventStream) {
+	if h.shared {
+		streamHubsMu.Lock()
+		defer streamHubsMu.Unlock()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.streams, s.id)
+	if h.shared && len(h.streams) == 0 && streamHubs[h.dispatcher] == h {
+		delete(streamHubs, h.dispatcher)
+	}
+}
+
+// dispatch 将事件交给订阅了该事件类型的事件流
+func (h *streamHub) dispatch(eventType int, event *Event) {
+	h.mu.RLock()
+	streams := make([]*EventStream, 0, len(h.streams))
+	for _, s := range h.streams {
+		if s.eventTypes[eventType] {
+			streams = append(streams, s)
+		}
+	}
+	h.mu.RUnlock()
+
+	// 阻塞模式的投递可能等待消费，不能持有锁
+	for _, s := range streams {
+		s.push(event)
+	}
+}
+
+// EventStream 事件流，将事件源的事件以通道形式输出
+//
+// 事件处理器在各自的协程中执行，因此通道中事件的顺序与到达顺序可能略有不同。
+// 同一事件源上的所有事件流共用一组处理器，Close 后事件流即从中移除。
+type EventStream struct {
+	events     chan *Event
+	done       chan struct{}
+	filters    []EventFilter
+	eventTypes map[int]bool
+	blocking   bool
+	dropped    atomic.Uint64
+
+	hub *streamHub
+	id  uint64
+
+	mu        sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+}
+
+// streamConfig 事件流配置
+type streamConfig struct {
+	buffer     int
+	eventTypes []int
+	filters    []EventFilter
+	blocking   bool
+	ctx        context.Context
+}
+
+// StreamOption 事件流配置选项
+type StreamOption func(*streamConfig)
+
+// WithStreamBuffer 设置通道缓冲区大小，默认64
+func WithStreamBuffer(size int) StreamOption {
+	return func(c *streamConfig) {
+		c.buffer = size
+	}
+}
+
+// WithStreamEventTypes 设置订阅的事件类型，默认订阅所有消息与系统事件
+func WithStreamEventTypes(eventTypes ...int) StreamOption {
+	return func(c *streamConfig) {
+		c.eventTypes = eventTypes
+	}
+}
+
+// WithStreamFilter 添加过滤条件，事件须满足所有条件才会进入事件流
+func WithStreamFilter(filters ...EventFilter) StreamOption {
+	return func(c *streamConfig) {
+		c.filters = append(c.filters, filters...)
+	}
+}
+
+// WithStreamBlocking 缓冲区满时阻塞等待消费，默认丢弃新事件并计入 Dropped
+func WithStreamBlocking() StreamOption {
+	return func(c *streamConfig) {
+		c.blocking = true
+	}
+}
+
+// WithStreamContext 在 ctx 结束时自动关闭事件流
+func WithStreamContext(ctx context.Context) StreamOption {
+	return func(c *streamConfig) {
+		c.ctx = ctx
+	}
+}
+
+// NewEventStream 创建事件流，订阅事件源的事件
+func NewEventStream(dispatcher EventDispatcher, options ...StreamOption) *EventStream {
+	config := &streamConfig{
+		buffer:     64,
+		eventTypes: streamEventTypes,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	s := &EventStream{
+		events:     make(chan *Event, config.buffer),
+		done:       make(chan struct{}),
+		filters:    config.filters,
+		eventTypes: make(map[int]bool, len(config.eventTypes)),
+		blocking:   config.blocking,
+	}
+	for _, eventType := range config.eventTypes {
+		s.eventTypes[eventType] = true
+	}
+	subscribeStream(dispatcher, s)
+
+	if config.ctx != nil {
+		go func() {
+			select {
+			case <-config.ctx.Done():
+				s.Close()
+			case <-s.done:
+			}
+		}()
+	}
+	return s
+}
+
+// push 将事件投递到通道
+func (s *EventStream) push(event *Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed || !matchFilters(event, s.filters) {
+		return
+	}
+
+	if s.blocking {
+		select {
+		case s.events <- event:
+		case <-s.done:
+		}
+		return
+	}
+
+	select {
+	case s.events <- event:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// C 获取事件通道，事件流关闭后通道也会关闭
+func (s *EventStream) C() <-chan *Event {
+	return s.events
+}
+
+// Seq 以序列形式遍历事件，直到事件流关闭或遍历中止
+//
+// Go 1.23 及以上版本可以直接写 for event := range stream.Seq()。
+func (s *EventStream) Seq() EventSeq {
+	return func(yield func(*Event) bool) {
+		for event := range s.events {
+			if !yield(event) {
+				return
+			}
+		}
+	}
+}
+
+// Next 等待下一个事件，ctx 结束或事件流关闭时返回 false
+func (s *EventStream) Next(ctx context.Context) (*Event, bool) {
+	select {
+	case event, ok := <-s.events:
+		return event, ok
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// Dropped 获取因缓冲区已满而丢弃的事件数
+func (s *EventStream) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Done 获取事件流关闭的通知通道
+func (s *EventStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close 关闭事件流并取消订阅，可重复调用
+func (s *EventStream) Close() {
+	s.closeOnce.Do(func() {
+		s.hub.unsubscribe(s)
+
+		// 先唤醒阻塞中的投递，再关闭事件通道
+		close(s.done)
+
+		s.mu.Lock()
+		s.closed = true
+		close(s.events)
+		s.mu.Unlock()
+	})
+}
@@ -0,0 +1,60 @@
+package kook
+
+import (
+	"context"
+)
+
+// 链路追踪属性名
+const (
+	TraceAttrMethod      = "http.method"        // 请求方法
+	TraceAttrEndpoint    = "kook.endpoint"      // API端点
+	TraceAttrAttempt     = "kook.attempt"       // 第几次尝试
+	TraceAttrAttempts    = "kook.attempts"      // 总尝试次数
+	TraceAttrStatus      = "http.status_code"   // HTTP状态码
+	TraceAttrCode        = "kook.code"          // KOOK错误码
+	TraceAttrRequestID   = "kook.request_id"    // KOOK请求ID
+	TraceAttrEventType   = "kook.event.type"    // 事件类型
+	TraceAttrSource      = "kook.event.source"  // 事件来源
+	TraceAttrTargetID    = "kook.event.target"  // 事件目标ID
+	TraceAttrMsgID       = "kook.event.msg_id"  // 消息ID
+	TraceAttrChannelType = "kook.event.channel" // 频道类型
+)
+
+// Span 追踪片段
+type Span interface {
+	// SetAttributes 设置属性
+	SetAttributes(attrs ...Field)
+	// RecordError 记录错误并将片段标记为失败
+	RecordError(err error)
+	// End 结束片段
+	End()
+}
+
+// Tracer 链路追踪接口
+//
+// SDK 为每次 API 调用、每次重试尝试、速率限制等待以及收到的每个事件创建片段。
+// 默认不追踪，可通过 oteltrace 子包适配 OpenTelemetry。
+type Tracer interface {
+	// Start 从 ctx 派生子片段，返回携带新片段的 ctx
+	Start(ctx context.Context, name string, attrs ...Field) (context.Context, Span)
+}
+
+// nopTracer 不做任何追踪
+type nopTracer struct{}
+
+// NewNopTracer 创建不做任何追踪的Tracer
+func NewNopTracer() Tracer {
+	return nopTracer{}
+}
+
+// Start 返回原 ctx 与空片段
+func (nopTracer) Start(ctx context.Context, _ string, _ ...Field) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+// nopSpan 空片段
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(...Field) {}
+func (nopSpan) RecordError(error)      {}
+func (nopSpan) End()                   {}
@@ -1,6 +1,7 @@
 package kook
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 )
@@ -68,7 +69,8 @@ type Role struct {
 	Position    int    `json:"position"`
 	Hoist       int    `json:"hoist"`
 	Mentionable int    `json:"mentionable"`
-	Permissions int    `json:"permissions"`
+	Permissions Permissions `json:"permissions"`
+	Type        int    `json:"type"`
 }
 
 // Channel 频道信息
@@ -93,16 +95,16 @@ type Channel struct {
 
 // PermissionOverwrite 权限覆写
 type PermissionOverwrite struct {
-	RoleID int `json:"role_id"`
-	Allow  int `json:"allow"`
-	Deny   int `json:"deny"`
+	RoleID int         `json:"role_id"`
+	Allow  Permissions `json:"allow"`
+	Deny   Permissions `json:"deny"`
 }
 
 // PermissionUser 用户权限
 type PermissionUser struct {
-	User  User `json:"user"`
-	Allow int  `json:"allow"`
-	Deny  int  `json:"deny"`
+	User  User        `json:"user"`
+	Allow Permissions `json:"allow"`
+	Deny  Permissions `json:"deny"`
 }
 
 // Message 消息信息
@@ -215,6 +217,16 @@ type Event struct {
 	MsgTimestamp int64      `json:"msg_timestamp"`
 	Nonce       string      `json:"nonce"`
 	Extra       interface{} `json:"extra"`
+
+	ctx context.Context
+}
+
+// Context 获取事件的上下文，其中携带该事件的追踪片段
+func (e *Event) Context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
 }
 
 
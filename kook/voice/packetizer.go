@@ -0,0 +1,129 @@
+package voice
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Packetizer 将音频帧封装为RTP包，维护序号与时间戳
+//
+// Packetizer 不发送数据，可以配合任意UDP传输使用。它不是并发安全的。
+type Packetizer struct {
+	payloadType uint8
+	ssrc        uint32
+	sequence    uint16
+	timestamp   uint32
+	marker      bool
+}
+
+// NewPacketizer 创建RTP封装器，序号与时间戳从随机值开始
+func NewPacketizer(payloadType uint8, ssrc uint32) *Packetizer {
+	return &Packetizer{
+		payloadType: payloadType,
+		ssrc:        ssrc,
+		sequence:    uint16(rand.Uint32()),
+		timestamp:   rand.Uint32(),
+		marker:      true,
+	}
+}
+
+// Packetize 封装一帧，samples 为该帧的采样数（48kHz下20ms为960）
+func (p *Packetizer) Packetize(payload []byte, samples uint32) []byte {
+	header := rtpHeader{
+		Marker:         p.marker,
+		PayloadType:    p.payloadType,
+		SequenceNumber: p.sequence,
+		Timestamp:      p.timestamp,
+		SSRC:           p.ssrc,
+	}
+	p.marker = false
+	p.sequence++
+	p.timestamp += samples
+	return header.marshal(payload)
+}
+
+// Skip 时间戳前进 samples 而不发送数据，用于暂停等静默期，下一个包会带标记位
+func (p *Packetizer) Skip(samples uint32) {
+	p.timestamp += samples
+	p.marker = true
+}
+
+// MarkNext 为下一个包设置标记位，表示新的一段音频开始
+func (p *Packetizer) MarkNext() {
+	p.marker = true
+}
+
+// Sequence 获取下一个包的序号
+func (p *Packetizer) Sequence() uint16 {
+	return p.sequence
+}
+
+// Timestamp 获取下一个包的时间戳
+func (p *Packetizer) Timestamp() uint32 {
+	return p.timestamp
+}
+
+// SSRC 获取同步源标识
+func (p *Packetizer) SSRC() uint32 {
+	return p.ssrc
+}
+
+// opusFrameSamples 各 config 对应的单帧采样数（48kHz）
+var opusFrameSamples = [32]int{
+	// SILK：10、20、40、60毫秒
+	480, 960, 1920, 2880, 480, 960, 1920, 2880, 480, 960, 1920, 2880,
+	// Hybrid：10、20毫秒
+	480, 960, 480, 960,
+	// CELT：2.5、5、10、20毫秒
+	120, 240, 480, 960, 120, 240, 480, 960, 120, 240, 480, 960, 120, 240, 480, 960,
+}
+
+// OpusPacketSamples 根据TOC字节计算Opus包包含的采样数（48kHz）
+func OpusPacketSamples(packet []byte) (int, error) {
+	if len(packet) == 0 {
+		return 0, fmt.Errorf("Opus包为空")
+	}
+
+	toc := packet[0]
+	frameSamples := opusFrameSamples[toc>>3]
+
+	var frames int
+	switch toc & 0x03 {
+	case 0:
+		frames = 1
+	case 1, 2:
+		frames = 2
+	default:
+		if len(packet) < 2 {
+			return 0, fmt.Errorf("Opus包缺少帧数")
+		}
+		frames = int(packet[1] & 0x3f)
+	}
+
+	samples := frames * frameSamples
+	// 单个包最长120毫秒
+	if samples == 0 || samples > 5760 {
+		return 0, fmt.Errorf("无效的Opus包")
+	}
+	return samples, nil
+}
+
+// OpusPacketDuration 计算Opus包的时长
+func OpusPacketDuration(packet []byte) (time.Duration, error) {
+	samples, err := OpusPacketSamples(packet)
+	if err != nil {
+		return 0, err
+	}
+	return samplesToDuration(samples), nil
+}
+
+// samplesToDuration 将48kHz采样数换算为时长
+func samplesToDuration(samples int) time.Duration {
+	return time.Duration(samples) * time.Second / SampleRate
+}
+
+// durationToSamples 将时长换算为48kHz采样数
+func durationToSamples(d time.Duration) int64 {
+	return int64(d / (time.Second / SampleRate))
+}
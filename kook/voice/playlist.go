@@ -0,0 +1,289 @@
+package voice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"kook-go-sdk/kook"
+)
+
+// Track 播放列表中的曲目
+type Track struct {
+	Title string                     // 标题
+	Open  func() (OpusSource, error) // 打开数据源，返回值实现 io.Closer 时播放结束后关闭
+}
+
+// oggFile 带文件句柄的Ogg数据源
+type oggFile struct {
+	*OggReader
+	file *os.File
+}
+
+// Close 关闭文件
+func (f *oggFile) Close() error {
+	return f.file.Close()
+}
+
+// OggFileTrack 创建播放本地Ogg/Opus文件的曲目
+func OggFileTrack(path string) Track {
+	return Track{
+		Title: filepath.Base(path),
+		Open: func() (OpusSource, error) {
+			file, err := os.Open(path)
+			if err != nil {
+				return nil, fmt.Errorf("打开音频文件失败: %w", err)
+			}
+			reader, err := NewOggReader(file)
+			if err != nil {
+				file.Close()
+				return nil, err
+			}
+			return &oggFile{OggReader: reader, file: file}, nil
+		},
+	}
+}
+
+// lockedSource 串行化读取与跳转，跳转可能发生在播放协程之外
+type lockedSource struct {
+	mu  sync.Mutex
+	src OpusSource
+}
+
+// ReadPacket 读取下一个包
+func (l *lockedSource) ReadPacket() ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.src.ReadPacket()
+}
+
+// Seek 跳转到指定位置
+func (l *lockedSource) Seek(position time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	seeker, ok := l.src.(Seeker)
+	if !ok {
+		return fmt.Errorf("当前曲目不支持跳转")
+	}
+	return seeker.Seek(position)
+}
+
+// Player 播放列表播放器，在一个语音连接上依次播放队列中的曲目
+type Player struct {
+	vc     *Client
+	logger kook.Logger
+
+	mu      sync.Mutex
+	queue   []Track
+	current *Track
+	source  *lockedSource
+	skip    context.CancelFunc
+	wake    chan struct{}
+
+	onStart func(Track)
+	onEnd   func(Track, error)
+}
+
+// NewPlayer 创建播放器
+func NewPlayer(vc *Client) *Player {
+	return &Player{
+		vc:     vc,
+		logger: vc.logger,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// OnTrackStart 设置曲目开始播放时的回调
+func (p *Player) OnTrackStart(f func(Track)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onStart = f
+}
+
+// OnTrackEnd 设置曲目结束时的回调，被跳过时 err 为 ErrStopped
+func (p *Player) OnTrackEnd(f func(Track, error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onEnd = f
+}
+
+// Enqueue 将曲目加入队列末尾
+func (p *Player) Enqueue(tracks ...Track) {
+	p.mu.Lock()
+	p.queue = append(p.queue, tracks...)
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Queue 获取等待播放的曲目
+func (p *Player) Queue() []Track {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Track(nil), p.queue...)
+}
+
+// Remove 移除队列中指定位置的曲目
+func (p *Player) Remove(index int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if index < 0 || index >= len(p.queue) {
+		return fmt.Errorf("曲目序号超出范围: %d", index)
+	}
+	p.queue = append(p.queue[:index], p.queue[index+1:]...)
+	return nil
+}
+
+// Clear 清空队列，不影响正在播放的曲目
+func (p *Player) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queue = nil
+}
+
+// Current 获取正在播放的曲目
+func (p *Player) Current() (Track, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.current == nil {
+		return Track{}, false
+	}
+	return *p.current, true
+}
+
+// Skip 跳过正在播放的曲目
+func (p *Player) Skip() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.skip != nil {
+		p.skip()
+	}
+}
+
+// Seek 跳转到正在播放曲目的指定位置
+func (p *Player) Seek(position time.Duration) error {
+	p.mu.Lock()
+	source := p.source
+	p.mu.Unlock()
+
+	if source == nil {
+		return fmt.Errorf("没有正在播放的曲目")
+	}
+	return source.Seek(position)
+}
+
+// Pause 暂停播放
+func (p *Player) Pause() {
+	p.vc.Pause()
+}
+
+// Resume 恢复播放
+func (p *Player) Resume() {
+	p.vc.Resume()
+}
+
+// Run 依次播放队列中的曲目，队列为空时等待新曲目，直到 ctx 取消或语音连接关闭
+func (p *Player) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-p.vc.Done():
+			return ErrClosed
+		default:
+		}
+
+		track, ok := p.next()
+		if !ok {
+			select {
+			case <-p.wake:
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-p.vc.Done():
+				return ErrClosed
+			}
+		}
+
+		err := p.play(ctx, track)
+		if err != nil && !errors.Is(err, ErrStopped) {
+			p.logger.Warn("曲目播放失败", kook.F("title", track.Title), kook.ErrField(err))
+		}
+
+		p.mu.Lock()
+		onEnd := p.onEnd
+		p.mu.Unlock()
+		if onEnd != nil {
+			onEnd(track, err)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, ErrClosed) {
+			return err
+		}
+	}
+}
+
+// next 取出队首曲目
+func (p *Player) next() (Track, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.queue) == 0 {
+		return Track{}, false
+	}
+	track := p.queue[0]
+	p.queue = p.queue[1:]
+	return track, true
+}
+
+// play 打开并播放一个曲目
+func (p *Player) play(ctx context.Context, track Track) error {
+	src, err := track.Open()
+	if err != nil {
+		return err
+	}
+	if closer, ok := src.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	trackCtx, skip := context.WithCancel(ctx)
+	defer skip()
+
+	source := &lockedSource{src: src}
+	p.mu.Lock()
+	p.current = &track
+	p.source = source
+	p.skip = skip
+	onStart := p.onStart
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		p.current = nil
+		p.source = nil
+		p.skip = nil
+		p.mu.Unlock()
+	}()
+
+	if onStart != nil {
+		onStart(track)
+	}
+	err = p.vc.Play(trackCtx, source)
+	if trackCtx.Err() != nil && ctx.Err() == nil {
+		return ErrStopped
+	}
+	return err
+}
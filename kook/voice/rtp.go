@@ -0,0 +1,65 @@
+package voice
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+const (
+	// SampleRate Opus 的 RTP 时钟频率
+	SampleRate = 48000
+	// FrameDuration 默认每个Opus包的时长
+	FrameDuration = 20 * time.Millisecond
+
+	rtpHeaderSize = 12
+	rtpVersion    = 2
+)
+
+// rtpHeader RTP固定头部
+type rtpHeader struct {
+	Marker         bool
+	PayloadType    uint8
+	SequenceNumber uint16
+	Timestamp      uint32
+	SSRC           uint32
+}
+
+// marshal 将头部与负载编码为RTP包
+func (h *rtpHeader) marshal(payload []byte) []byte {
+	packet := make([]byte, rtpHeaderSize+len(payload))
+	packet[0] = rtpVersion << 6
+	packet[1] = h.PayloadType & 0x7f
+	if h.Marker {
+		packet[1] |= 0x80
+	}
+	binary.BigEndian.PutUint16(packet[2:4], h.SequenceNumber)
+	binary.BigEndian.PutUint32(packet[4:8], h.Timestamp)
+	binary.BigEndian.PutUint32(packet[8:12], h.SSRC)
+	copy(packet[rtpHeaderSize:], payload)
+	return packet
+}
+
+// senderReport 生成RTCP发送者报告（SR）
+//
+// 语音服务器根据它对齐时间戳，同时起到保活作用。
+func senderReport(ssrc uint32, now time.Time, rtpTimestamp, packets, octets uint32) []byte {
+	report := make([]byte, 28)
+	report[0] = rtpVersion << 6
+	report[1] = 200 // PT=SR
+	binary.BigEndian.PutUint16(report[2:4], 6)
+	binary.BigEndian.PutUint32(report[4:8], ssrc)
+
+	// NTP 时间戳：1900 年以来的秒数与小数部分
+	seconds := uint64(now.Unix()) + 2208988800
+	fraction := uint64(now.Nanosecond()) << 32 / uint64(time.Second)
+	binary.BigEndian.PutUint32(report[8:12], uint32(seconds))
+	binary.BigEndian.PutUint32(report[12:16], uint32(fraction))
+
+	binary.BigEndian.PutUint32(report[16:20], rtpTimestamp)
+	binary.BigEndian.PutUint32(report[20:24], packets)
+	binary.BigEndian.PutUint32(report[24:28], octets)
+	return report
+}
+
+// silenceFrame Opus静音帧，停止或暂停时发送几帧，避免接收端插值出杂音
+var silenceFrame = []byte{0xf8, 0xff, 0xfe}
@@ -0,0 +1,180 @@
+package voice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"kook-go-sdk/kook"
+)
+
+// errSignalingClosed 信令连接已关闭
+var errSignalingClosed = errors.New("语音信令连接已关闭")
+
+// signalMessage 语音网关信令消息
+//
+// 请求带 request 与 id，响应带 response、相同的 id 与 ok，服务端主动推送的消息带 notification。
+type signalMessage struct {
+	Request      bool            `json:"request,omitempty"`
+	Response     bool            `json:"response,omitempty"`
+	Notification bool            `json:"notification,omitempty"`
+	ID           int             `json:"id,omitempty"`
+	Method       string          `json:"method,omitempty"`
+	OK           bool            `json:"ok,omitempty"`
+	Data         json.RawMessage `json:"data,omitempty"`
+	ErrorCode    int             `json:"errorCode,omitempty"`
+	ErrorReason  string          `json:"errorReason,omitempty"`
+}
+
+// signaling 语音网关信令连接
+type signaling struct {
+	conn   *websocket.Conn
+	logger kook.Logger
+
+	// 请求由多个协程发出，写入需要串行
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan *signalMessage
+
+	notify func(method string, data json.RawMessage)
+
+	done      chan struct{}
+	closeOnce sync.Once
+	err       error
+}
+
+// dialSignaling 连接语音网关并启动读取协程
+func dialSignaling(ctx context.Context, url string, logger kook.Logger, notify func(string, json.RawMessage)) (*signaling, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("连接语音网关失败: %w", err)
+	}
+
+	s := &signaling{
+		conn:    conn,
+		logger:  logger,
+		nextID:  int(time.Now().UnixNano() % 1000000),
+		pending: make(map[int]chan *signalMessage),
+		notify:  notify,
+		done:    make(chan struct{}),
+	}
+	go s.readLoop()
+	return s, nil
+}
+
+// request 发送请求并等待响应
+func (s *signaling) request(ctx context.Context, method string, data interface{}) (json.RawMessage, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("序列化信令 %s 失败: %w", method, err)
+	}
+
+	result := make(chan *signalMessage, 1)
+	s.mu.Lock()
+	s.nextID++
+	id := s.nextID
+	s.pending[id] = result
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	msg, err := json.Marshal(signalMessage{Request: true, ID: id, Method: method, Data: payload})
+	if err != nil {
+		return nil, fmt.Errorf("序列化信令 %s 失败: %w", method, err)
+	}
+
+	s.logger.Debug("发送语音信令", kook.F("method", method), kook.F("id", id))
+	if err := s.write(msg); err != nil {
+		return nil, fmt.Errorf("发送信令 %s 失败: %w", method, err)
+	}
+
+	select {
+	case resp := <-result:
+		if !resp.OK {
+			return nil, fmt.Errorf("信令 %s 失败 [%d]: %s", method, resp.ErrorCode, resp.ErrorReason)
+		}
+		return resp.Data, nil
+	case <-s.done:
+		return nil, fmt.Errorf("信令 %s 失败: %w", method, s.err)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("信令 %s 失败: %w", method, ctx.Err())
+	}
+}
+
+// write 写入一条文本消息
+func (s *signaling) write(data []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	select {
+	case <-s.done:
+		return errSignalingClosed
+	default:
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return s.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// ping 发送WebSocket Ping保持连接
+func (s *signaling) ping() error {
+	// WriteControl 可以与 WriteMessage 并发调用
+	return s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second))
+}
+
+// readLoop 读取响应与通知
+func (s *signaling) readLoop() {
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			s.close(err)
+			return
+		}
+
+		var msg signalMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.logger.Warn("解析语音信令失败", kook.ErrField(err))
+			continue
+		}
+
+		switch {
+		case msg.Response:
+			s.mu.Lock()
+			result, ok := s.pending[msg.ID]
+			s.mu.Unlock()
+			if ok {
+				result <- &msg
+			}
+		case msg.Notification:
+			s.logger.Debug("收到语音网关通知", kook.F("method", msg.Method))
+			if s.notify != nil {
+				s.notify(msg.Method, msg.Data)
+			}
+		}
+	}
+}
+
+// close 关闭连接并记录原因，只有第一次调用生效
+func (s *signaling) close(err error) {
+	s.closeOnce.Do(func() {
+		s.err = err
+		close(s.done)
+
+		s.writeMu.Lock()
+		s.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		s.writeMu.Unlock()
+		s.conn.Close()
+	})
+}
@@ -0,0 +1,310 @@
+package voice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// OpusSource Opus数据源
+type OpusSource interface {
+	// ReadPacket 返回下一个Opus包，数据结束时返回 io.EOF
+	ReadPacket() ([]byte, error)
+}
+
+// Seeker 支持按播放位置跳转的数据源
+type Seeker interface {
+	// Seek 跳转到距开头 position 处，之后读取的数据从该位置开始
+	Seek(position time.Duration) error
+}
+
+// skipPackets 读取并丢弃数据源开头 position 时长的Opus包
+func skipPackets(src OpusSource, position time.Duration) error {
+	target := durationToSamples(position)
+	var skipped int64
+	for skipped < target {
+		packet, err := src.ReadPacket()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		samples, err := OpusPacketSamples(packet)
+		if err != nil {
+			samples = SampleRate / 50
+		}
+		skipped += int64(samples)
+	}
+	return nil
+}
+
+// FrameReader 读取以2字节小端长度前缀分隔的Opus帧，DCA 等格式使用这种封装
+type FrameReader struct {
+	r io.Reader
+}
+
+// NewFrameReader 创建Opus帧读取器
+func NewFrameReader(r io.Reader) *FrameReader {
+	return &FrameReader{r: r}
+}
+
+// ReadPacket 读取下一个Opus帧
+func (f *FrameReader) ReadPacket() ([]byte, error) {
+	var size uint16
+	if err := binary.Read(f.r, binary.LittleEndian, &size); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("读取Opus帧长度失败: %w", err)
+		}
+		return nil, err
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(f.r, frame); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("读取Opus帧失败: %w", err)
+	}
+	return frame, nil
+}
+
+// Seek 跳转到指定位置，底层数据需实现 io.Seeker
+func (f *FrameReader) Seek(position time.Duration) error {
+	seeker, ok := f.r.(io.Seeker)
+	if !ok {
+		return fmt.Errorf("数据源不支持跳转")
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("跳转失败: %w", err)
+	}
+	return skipPackets(f, position)
+}
+
+// OpusHead Ogg/Opus 文件的识别头
+type OpusHead struct {
+	Version       uint8  // 版本
+	Channels      uint8  // 声道数
+	PreSkip       uint16 // 解码时需丢弃的起始采样数
+	SampleRate    uint32 // 原始采样率，仅供参考
+	OutputGain    int16  // 输出增益（Q7.8 dB）
+	MappingFamily uint8  // 声道映射族
+}
+
+const (
+	oggPageHeaderSize = 27
+	oggContinued      = 0x01
+)
+
+// OggReader Ogg/Opus 解复用器，按顺序输出音频流中的Opus包
+//
+// 只读取第一个逻辑流，跳过 OpusHead 与 OpusTags 头部包。
+type OggReader struct {
+	r       io.Reader
+	head    OpusHead
+	serial  uint32
+	started bool
+
+	packets [][]byte // 当前页中已完整的包
+	partial []byte   // 跨页的未完成包
+	granule int64    // 最近一页的 granule position
+	eos     bool
+}
+
+// NewOggReader 创建Ogg/Opus解复用器，并读取头部包
+func NewOggReader(r io.Reader) (*OggReader, error) {
+	o := &OggReader{r: r}
+
+	head, err := o.nextPacket()
+	if err != nil {
+		return nil, fmt.Errorf("读取OpusHead失败: %w", err)
+	}
+	if err := o.parseHead(head); err != nil {
+		return nil, err
+	}
+
+	tags, err := o.nextPacket()
+	if err != nil {
+		return nil, fmt.Errorf("读取OpusTags失败: %w", err)
+	}
+	if !bytes.HasPrefix(tags, []byte("OpusTags")) {
+		return nil, fmt.Errorf("缺少OpusTags头部")
+	}
+	return o, nil
+}
+
+// Head 获取 OpusHead 信息
+func (o *OggReader) Head() OpusHead {
+	return o.head
+}
+
+// Granule 获取最近读取的页的 granule position，即截至该页末尾的48kHz采样数
+func (o *OggReader) Granule() int64 {
+	return o.granule
+}
+
+// ReadPacket 读取下一个Opus包
+func (o *OggReader) ReadPacket() ([]byte, error) {
+	return o.nextPacket()
+}
+
+// Seek 跳转到指定位置，底层数据需实现 io.Seeker
+//
+// 从头重新解复用并丢弃目标位置之前的包，精度为一个包的时长。
+func (o *OggReader) Seek(position time.Duration) error {
+	seeker, ok := o.r.(io.Seeker)
+	if !ok {
+		return fmt.Errorf("数据源不支持跳转")
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("跳转失败: %w", err)
+	}
+
+	*o = OggReader{r: o.r, head: o.head}
+	// 跳过 OpusHead 与 OpusTags
+	for i := 0; i < 2; i++ {
+		if _, err := o.nextPacket(); err != nil {
+			return fmt.Errorf("跳转失败: %w", err)
+		}
+	}
+	return skipPackets(o, position)
+}
+
+// parseHead 解析 OpusHead
+func (o *OggReader) parseHead(data []byte) error {
+	if len(data) < 19 || !bytes.HasPrefix(data, []byte("OpusHead")) {
+		return fmt.Errorf("不是Ogg/Opus文件")
+	}
+	o.head = OpusHead{
+		Version:       data[8],
+		Channels:      data[9],
+		PreSkip:       binary.LittleEndian.Uint16(data[10:12]),
+		SampleRate:    binary.LittleEndian.Uint32(data[12:16]),
+		OutputGain:    int16(binary.LittleEndian.Uint16(data[16:18])),
+		MappingFamily: data[18],
+	}
+	if o.head.Version>>4 != 0 {
+		return fmt.Errorf("不支持的OpusHead版本: %d", o.head.Version)
+	}
+	return nil
+}
+
+// nextPacket 返回下一个完整的包，必要时读取新页
+func (o *OggReader) nextPacket() ([]byte, error) {
+	for len(o.packets) == 0 {
+		if o.eos {
+			return nil, io.EOF
+		}
+		if err := o.readPage(); err != nil {
+			return nil, err
+		}
+	}
+
+	packet := o.packets[0]
+	o.packets = o.packets[1:]
+	return packet, nil
+}
+
+// readPage 读取一页并拆分出其中的包
+func (o *OggReader) readPage() error {
+	header := make([]byte, oggPageHeaderSize)
+	if _, err := io.ReadFull(o.r, header); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.EOF
+		}
+		return fmt.Errorf("读取Ogg页失败: %w", err)
+	}
+	if !bytes.Equal(header[:4], []byte("OggS")) {
+		return fmt.Errorf("无效的Ogg页")
+	}
+	if header[4] != 0 {
+		return fmt.Errorf("不支持的Ogg版本: %d", header[4])
+	}
+
+	headerType := header[5]
+	granule := int64(binary.LittleEndian.Uint64(header[6:14]))
+	serial := binary.LittleEndian.Uint32(header[14:18])
+	checksum := binary.LittleEndian.Uint32(header[22:26])
+
+	segments := make([]byte, header[26])
+	if _, err := io.ReadFull(o.r, segments); err != nil {
+		return fmt.Errorf("读取Ogg分段表失败: %w", err)
+	}
+	size := 0
+	for _, s := range segments {
+		size += int(s)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(o.r, body); err != nil {
+		return fmt.Errorf("读取Ogg页数据失败: %w", err)
+	}
+
+	// 校验和计算时该字段置零
+	binary.LittleEndian.PutUint32(header[22:26], 0)
+	crc := oggCRC(0, header)
+	crc = oggCRC(crc, segments)
+	crc = oggCRC(crc, body)
+	if crc != checksum {
+		return fmt.Errorf("Ogg页校验失败")
+	}
+
+	// 只处理第一个逻辑流
+	if !o.started {
+		o.serial = serial
+		o.started = true
+	}
+	if serial != o.serial {
+		return nil
+	}
+
+	if headerType&oggContinued == 0 {
+		o.partial = nil
+	}
+
+	offset := 0
+	for _, s := range segments {
+		o.partial = append(o.partial, body[offset:offset+int(s)]...)
+		offset += int(s)
+		// 长度小于255的分段表示包结束
+		if s < 255 {
+			o.packets = append(o.packets, o.partial)
+			o.partial = nil
+		}
+	}
+
+	if granule >= 0 {
+		o.granule = granule
+	}
+	if headerType&0x04 != 0 {
+		o.eos = true
+	}
+	return nil
+}
+
+// oggCRCTable Ogg 使用的 CRC32 表（多项式 0x04c11db7，不反转）
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		table[i] = r
+	}
+	return table
+}()
+
+// oggCRC 计算 Ogg 页校验和
+func oggCRC(crc uint32, data []byte) uint32 {
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
@@ -0,0 +1,505 @@
+// Package voice KOOK语音频道推流客户端
+//
+// Connect 通过语音网关完成信令握手（getRouterRtpCapabilities、join、createPlainTransport、produce），
+// 之后 Play 将 OpusSource 中的Opus包按各自时长封装为RTP包，经UDP发送到服务器返回的地址。
+// 连接期间定时发送WebSocket Ping与RTCP发送者报告以保持连接。
+package voice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"kook-go-sdk/kook"
+)
+
+var (
+	// ErrStopped 播放被 Stop 中止
+	ErrStopped = errors.New("播放已停止")
+	// ErrClosed 语音连接已关闭
+	ErrClosed = errors.New("语音连接已关闭")
+)
+
+// TransportInfo 语音服务器为推流分配的地址
+type TransportInfo struct {
+	ID       string `json:"id"`       // 传输通道ID
+	IP       string `json:"ip"`       // RTP地址
+	Port     int    `json:"port"`     // RTP端口
+	RTCPPort int    `json:"rtcpPort"` // RTCP端口
+}
+
+// Client 语音推流客户端
+type Client struct {
+	api       *kook.Client
+	logger    kook.Logger
+	channelID string
+
+	// 配置
+	gatewayURL    string
+	ssrc          uint32
+	payloadType   uint8
+	keepAlive     time.Duration
+	frameDuration time.Duration
+
+	sig       *signaling
+	transport TransportInfo
+	rtp       net.Conn
+	rtcp      net.Conn
+
+	// 播放状态
+	mu      sync.Mutex
+	playing bool
+	paused  bool
+	resume  chan struct{}
+	stop    chan struct{}
+
+	// RTP状态，packetizer 只由播放协程使用，其余供RTCP读取
+	packetizer *Packetizer
+	timestamp  atomic.Uint32
+	packets    atomic.Uint32
+	octets     atomic.Uint32
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// Option 语音客户端配置选项
+type Option func(*Client)
+
+// WithGatewayURL 直接指定语音网关地址，不再通过 GatewayService.GetVoiceGateway 获取
+func WithGatewayURL(url string) Option {
+	return func(c *Client) {
+		c.gatewayURL = url
+	}
+}
+
+// WithSSRC 设置RTP同步源标识，默认随机生成
+func WithSSRC(ssrc uint32) Option {
+	return func(c *Client) {
+		c.ssrc = ssrc
+	}
+}
+
+// WithPayloadType 设置Opus的RTP负载类型，默认100
+func WithPayloadType(payloadType uint8) Option {
+	return func(c *Client) {
+		c.payloadType = payloadType
+	}
+}
+
+// WithKeepAliveInterval 设置保活间隔，默认5秒
+func WithKeepAliveInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.keepAlive = interval
+	}
+}
+
+// WithFrameDuration 设置无法从TOC解析时长时使用的Opus包时长，默认20毫秒
+func WithFrameDuration(duration time.Duration) Option {
+	return func(c *Client) {
+		c.frameDuration = duration
+	}
+}
+
+// WithLogger 设置日志器
+func WithLogger(logger kook.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// Connect 连接语音频道并完成推流前的握手
+func Connect(ctx context.Context, api *kook.Client, channelID string, opts ...Option) (*Client, error) {
+	if channelID == "" {
+		return nil, fmt.Errorf("频道ID不能为空")
+	}
+
+	c := &Client{
+		api:           api,
+		channelID:     channelID,
+		ssrc:          rand.Uint32(),
+		payloadType:   100,
+		keepAlive:     5 * time.Second,
+		frameDuration: FrameDuration,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.logger == nil {
+		c.logger = api.Logger("voice")
+	}
+	c.logger = c.logger.With(kook.F("channel_id", channelID))
+	c.packetizer = NewPacketizer(c.payloadType, c.ssrc)
+	c.timestamp.Store(c.packetizer.Timestamp())
+
+	if c.gatewayURL == "" {
+		gateway, err := api.Gateway.GetVoiceGateway(channelID)
+		if err != nil {
+			return nil, err
+		}
+		c.gatewayURL = gateway.GatewayURL
+	}
+
+	sig, err := dialSignaling(ctx, c.gatewayURL, c.logger, c.handleNotification)
+	if err != nil {
+		return nil, err
+	}
+	c.sig = sig
+
+	if err := c.handshake(ctx); err != nil {
+		sig.close(err)
+		return nil, err
+	}
+	if err := c.dialTransport(); err != nil {
+		sig.close(err)
+		return nil, err
+	}
+
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	c.wg.Add(1)
+	go c.keepAliveLoop()
+
+	c.logger.Info("语音连接成功",
+		kook.F("ip", c.transport.IP), kook.F("port", c.transport.Port), kook.F("ssrc", c.ssrc))
+	return c, nil
+}
+
+// handshake 执行信令握手
+func (c *Client) handshake(ctx context.Context) error {
+	if _, err := c.sig.request(ctx, "getRouterRtpCapabilities", struct{}{}); err != nil {
+		return err
+	}
+	if _, err := c.sig.request(ctx, "join", map[string]interface{}{"displayName": ""}); err != nil {
+		return err
+	}
+
+	data, err := c.sig.request(ctx, "createPlainTransport", map[string]interface{}{
+		"comedia": true,
+		"rtcpMux": false,
+		"type":    "plain",
+	})
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &c.transport); err != nil {
+		return fmt.Errorf("解析传输通道信息失败: %w", err)
+	}
+	if c.transport.IP == "" || c.transport.Port == 0 {
+		return fmt.Errorf("语音服务器没有返回推流地址")
+	}
+
+	_, err = c.sig.request(ctx, "produce", map[string]interface{}{
+		"appData":     map[string]interface{}{},
+		"kind":        "audio",
+		"peerId":      "",
+		"transportId": c.transport.ID,
+		"rtpParameters": map[string]interface{}{
+			"codecs": []map[string]interface{}{{
+				"channels":    2,
+				"clockRate":   SampleRate,
+				"mimeType":    "audio/opus",
+				"parameters":  map[string]interface{}{"sprop-stereo": 1},
+				"payloadType": c.payloadType,
+			}},
+			"encodings": []map[string]interface{}{{"ssrc": c.ssrc}},
+		},
+	})
+	return err
+}
+
+// dialTransport 建立RTP与RTCP的UDP连接
+func (c *Client) dialTransport() error {
+	rtp, err := net.Dial("udp", net.JoinHostPort(c.transport.IP, strconv.Itoa(c.transport.Port)))
+	if err != nil {
+		return fmt.Errorf("连接RTP地址失败: %w", err)
+	}
+	c.rtp = rtp
+
+	if c.transport.RTCPPort > 0 {
+		rtcp, err := net.Dial("udp", net.JoinHostPort(c.transport.IP, strconv.Itoa(c.transport.RTCPPort)))
+		if err != nil {
+			rtp.Close()
+			return fmt.Errorf("连接RTCP地址失败: %w", err)
+		}
+		c.rtcp = rtcp
+	}
+	return nil
+}
+
+// handleNotification 处理语音网关推送的通知
+func (c *Client) handleNotification(method string, data json.RawMessage) {
+	switch method {
+	case "disconnect", "transportClose", "producerClose":
+		c.logger.Warn("语音服务器关闭了连接", kook.F("method", method))
+	}
+}
+
+// keepAliveLoop 定时发送Ping与RTCP发送者报告
+func (c *Client) keepAliveLoop() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-c.sig.done:
+			// 播放中的 Play 同样在等待 sig.done，会返回 ErrClosed
+			c.logger.Warn("语音信令连接断开", kook.ErrField(c.sig.err))
+			return
+		case <-ticker.C:
+			if err := c.sig.ping(); err != nil {
+				c.logger.Warn("发送语音保活失败", kook.ErrField(err))
+			}
+			if c.rtcp != nil {
+				report := senderReport(c.ssrc, time.Now(), c.timestamp.Load(), c.packets.Load(), c.octets.Load())
+				if _, err := c.rtcp.Write(report); err != nil {
+					c.logger.Warn("发送RTCP报告失败", kook.ErrField(err))
+				}
+			}
+		}
+	}
+}
+
+// Transport 获取推流地址
+func (c *Client) Transport() TransportInfo {
+	return c.transport
+}
+
+// SSRC 获取RTP同步源标识
+func (c *Client) SSRC() uint32 {
+	return c.ssrc
+}
+
+// Done 语音信令连接断开时关闭
+func (c *Client) Done() <-chan struct{} {
+	return c.sig.done
+}
+
+// Play 播放音频，阻塞直到播放完毕、被 Stop 中止、ctx 结束或连接关闭
+//
+// 同一时间只能有一个 Play 在执行。播放完毕返回 nil，被中止返回 ErrStopped，
+// 连接关闭或信令断开返回 ErrClosed。
+func (c *Client) Play(ctx context.Context, src OpusSource) error {
+	if c.closed() {
+		return ErrClosed
+	}
+
+	c.mu.Lock()
+	if c.playing {
+		c.mu.Unlock()
+		return fmt.Errorf("正在播放中")
+	}
+	c.playing = true
+	c.paused = false
+	stop := make(chan struct{})
+	c.stop = stop
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.playing = false
+		c.paused = false
+		c.resume = nil
+		c.stop = nil
+		c.mu.Unlock()
+	}()
+
+	// interrupted 在中止时发送静音帧并返回原因
+	interrupted := func(err error) error {
+		c.sendSilence()
+		return err
+	}
+
+	c.packetizer.MarkNext()
+	next := time.Now()
+	for {
+		if resume := c.pausedChan(); resume != nil {
+			c.sendSilence()
+			pausedAt := time.Now()
+			select {
+			case <-resume:
+			case <-stop:
+				return ErrStopped
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-c.ctx.Done():
+				return ErrClosed
+			case <-c.sig.done:
+				return ErrClosed
+			}
+			// 暂停期间时间戳继续前进，接收端据此识别静默
+			c.packetizer.Skip(uint32(durationToSamples(time.Since(pausedAt))))
+			c.timestamp.Store(c.packetizer.Timestamp())
+			next = time.Now()
+		}
+
+		packet, err := src.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			c.sendSilence()
+			return nil
+		}
+		if err != nil {
+			return interrupted(fmt.Errorf("读取音频失败: %w", err))
+		}
+
+		if wait := time.Until(next); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-stop:
+				timer.Stop()
+				return interrupted(ErrStopped)
+			case <-ctx.Done():
+				timer.Stop()
+				return interrupted(ctx.Err())
+			case <-c.ctx.Done():
+				timer.Stop()
+				return ErrClosed
+			case <-c.sig.done:
+				timer.Stop()
+				return ErrClosed
+			}
+		} else if c.closed() {
+			return ErrClosed
+		}
+
+		samples, err := OpusPacketSamples(packet)
+		if err != nil {
+			samples = int(durationToSamples(c.frameDuration))
+		}
+		if err := c.writeRTP(packet, samples); err != nil {
+			if c.closed() {
+				return ErrClosed
+			}
+			return fmt.Errorf("发送RTP包失败: %w", err)
+		}
+
+		// 按计划时间推进，避免累计误差；落后太多时重新计时，避免突发发送
+		next = next.Add(samplesToDuration(samples))
+		if time.Since(next) > 10*c.frameDuration {
+			next = time.Now()
+		}
+	}
+}
+
+// closed 判断连接是否已关闭或信令已断开
+func (c *Client) closed() bool {
+	select {
+	case <-c.ctx.Done():
+		return true
+	case <-c.sig.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// pausedChan 暂停中时返回恢复通知通道
+func (c *Client) pausedChan() chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.paused {
+		return c.resume
+	}
+	return nil
+}
+
+// writeRTP 封装并发送一个包含 samples 个采样的Opus包
+func (c *Client) writeRTP(payload []byte, samples int) error {
+	if _, err := c.rtp.Write(c.packetizer.Packetize(payload, uint32(samples))); err != nil {
+		return err
+	}
+
+	c.timestamp.Store(c.packetizer.Timestamp())
+	c.packets.Add(1)
+	c.octets.Add(uint32(len(payload)))
+	return nil
+}
+
+// sendSilence 发送5帧静音
+func (c *Client) sendSilence() {
+	for i := 0; i < 5; i++ {
+		if err := c.writeRTP(silenceFrame, SampleRate/50); err != nil {
+			return
+		}
+	}
+}
+
+// Pause 暂停播放
+func (c *Client) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.playing && !c.paused {
+		c.paused = true
+		c.resume = make(chan struct{})
+	}
+}
+
+// Resume 恢复播放
+func (c *Client) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.paused {
+		c.paused = false
+		close(c.resume)
+		c.resume = nil
+	}
+}
+
+// Paused 判断是否处于暂停状态
+func (c *Client) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// Playing 判断是否正在播放（含暂停）
+func (c *Client) Playing() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.playing
+}
+
+// Stop 中止当前播放，没有播放时不做任何事
+func (c *Client) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+}
+
+// Close 中止播放并断开语音连接，可重复调用
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		c.Stop()
+		c.cancel()
+		c.wg.Wait()
+
+		c.sig.close(ErrClosed)
+		c.rtp.Close()
+		if c.rtcp != nil {
+			c.rtcp.Close()
+		}
+		c.logger.Info("语音连接已关闭")
+	})
+	return nil
+}
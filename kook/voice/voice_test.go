@@ -0,0 +1,501 @@
+package voice
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kook-go-sdk/kook"
+)
+
+// fakeVoiceServer 模拟语音网关的信令与RTP接收端
+type fakeVoiceServer struct {
+	srv  *httptest.Server
+	rtp  *net.UDPConn
+	rtcp *net.UDPConn
+
+	mu      sync.Mutex
+	methods []string
+	produce json.RawMessage
+	conns   []*websocket.Conn
+}
+
+func newFakeVoiceServer(t *testing.T) *fakeVoiceServer {
+	t.Helper()
+
+	s := &fakeVoiceServer{}
+	var err error
+	s.rtp, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	s.rtcp, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+
+	upgrader := websocket.Upgrader{}
+	s.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+
+		for {
+			var req signalMessage
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.methods = append(s.methods, req.Method)
+			if req.Method == "produce" {
+				s.produce = req.Data
+			}
+			s.mu.Unlock()
+
+			resp := signalMessage{Response: true, ID: req.ID, OK: true, Data: json.RawMessage(`{}`)}
+			switch req.Method {
+			case "createPlainTransport":
+				data, _ := json.Marshal(TransportInfo{
+					ID:       "transport-1",
+					IP:       "127.0.0.1",
+					Port:     s.rtp.LocalAddr().(*net.UDPAddr).Port,
+					RTCPPort: s.rtcp.LocalAddr().(*net.UDPAddr).Port,
+				})
+				resp.Data = data
+			case "produce":
+				resp.Data = json.RawMessage(`{"id":"producer-1"}`)
+			}
+			if err := conn.WriteJSON(resp); err != nil {
+				return
+			}
+		}
+	}))
+
+	t.Cleanup(func() {
+		s.srv.Close()
+		s.rtp.Close()
+		s.rtcp.Close()
+	})
+	return s
+}
+
+// disconnect 断开所有信令连接
+func (s *fakeVoiceServer) disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *fakeVoiceServer) url() string {
+	return "ws" + strings.TrimPrefix(s.srv.URL, "http")
+}
+
+// readRTP 读取一个RTP包
+func (s *fakeVoiceServer) readRTP(t *testing.T, timeout time.Duration) (rtpHeader, []byte, bool) {
+	t.Helper()
+
+	buf := make([]byte, 1500)
+	s.rtp.SetReadDeadline(time.Now().Add(timeout))
+	n, err := s.rtp.Read(buf)
+	if err != nil {
+		return rtpHeader{}, nil, false
+	}
+	require.GreaterOrEqual(t, n, rtpHeaderSize)
+	require.Equal(t, byte(rtpVersion<<6), buf[0])
+
+	header := rtpHeader{
+		Marker:         buf[1]&0x80 != 0,
+		PayloadType:    buf[1] & 0x7f,
+		SequenceNumber: binary.BigEndian.Uint16(buf[2:4]),
+		Timestamp:      binary.BigEndian.Uint32(buf[4:8]),
+		SSRC:           binary.BigEndian.Uint32(buf[8:12]),
+	}
+	return header, append([]byte(nil), buf[rtpHeaderSize:n]...), true
+}
+
+func connectFake(t *testing.T, s *fakeVoiceServer, opts ...Option) *Client {
+	t.Helper()
+
+	api := kook.NewClient("test-token", kook.WithLogLevel(kook.LogLevelOff))
+	opts = append([]Option{WithGatewayURL(s.url()), WithSSRC(1234)}, opts...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	client, err := Connect(ctx, api, "channel-1", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// frames 生成 n 个带长度前缀的Opus帧
+func frames(n int) io.Reader {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		frame := []byte{0xfc, byte(i), byte(i)}
+		binary.Write(&buf, binary.LittleEndian, uint16(len(frame)))
+		buf.Write(frame)
+	}
+	return &buf
+}
+
+// endless 无限产生Opus帧的数据源
+type endless struct{}
+
+func (endless) ReadPacket() ([]byte, error) {
+	return []byte{0xfc, 0x01}, nil
+}
+
+func TestConnectHandshake(t *testing.T) {
+	s := newFakeVoiceServer(t)
+	client := connectFake(t, s)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	assert.Equal(t, []string{"getRouterRtpCapabilities", "join", "createPlainTransport", "produce"}, s.methods)
+	assert.Contains(t, string(s.produce), `"transportId":"transport-1"`)
+	assert.Contains(t, string(s.produce), `"ssrc":1234`)
+	assert.Equal(t, "transport-1", client.Transport().ID)
+}
+
+func TestPlaySendsPacedRTP(t *testing.T) {
+	s := newFakeVoiceServer(t)
+	client := connectFake(t, s)
+
+	start := time.Now()
+	require.NoError(t, client.Play(context.Background(), NewFrameReader(frames(5))))
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+
+	var previous rtpHeader
+	for i := 0; i < 5; i++ {
+		header, payload, ok := s.readRTP(t, time.Second)
+		require.True(t, ok)
+		assert.Equal(t, uint8(100), header.PayloadType)
+		assert.Equal(t, uint32(1234), header.SSRC)
+		assert.Equal(t, []byte{0xfc, byte(i), byte(i)}, payload)
+		assert.Equal(t, i == 0, header.Marker)
+		if i > 0 {
+			assert.Equal(t, previous.SequenceNumber+1, header.SequenceNumber)
+			assert.Equal(t, previous.Timestamp+960, header.Timestamp)
+		}
+		previous = header
+	}
+
+	// 结束时发送静音帧
+	_, payload, ok := s.readRTP(t, time.Second)
+	require.True(t, ok)
+	assert.Equal(t, silenceFrame, payload)
+}
+
+func TestPauseResumeStop(t *testing.T) {
+	s := newFakeVoiceServer(t)
+	client := connectFake(t, s)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Play(context.Background(), endless{})
+	}()
+
+	_, _, ok := s.readRTP(t, time.Second)
+	require.True(t, ok)
+	require.Eventually(t, client.Playing, time.Second, time.Millisecond)
+
+	client.Pause()
+	assert.True(t, client.Paused())
+	// 排空暂停前已发送的包与静音帧
+	for {
+		if _, _, ok := s.readRTP(t, 60*time.Millisecond); !ok {
+			break
+		}
+	}
+
+	client.Resume()
+	header, _, ok := s.readRTP(t, time.Second)
+	require.True(t, ok)
+	assert.True(t, header.Marker)
+
+	client.Stop()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrStopped)
+	case <-time.After(time.Second):
+		t.Fatal("Stop 后播放未结束")
+	}
+	assert.False(t, client.Playing())
+}
+
+func TestPlayRejectsConcurrentPlayback(t *testing.T) {
+	s := newFakeVoiceServer(t)
+	client := connectFake(t, s)
+
+	go client.Play(context.Background(), endless{})
+	require.Eventually(t, client.Playing, time.Second, time.Millisecond)
+
+	assert.Error(t, client.Play(context.Background(), endless{}))
+	require.NoError(t, client.Close())
+	require.NoError(t, client.Close())
+}
+
+func TestPlayReturnsClosedWhenSignalingDrops(t *testing.T) {
+	s := newFakeVoiceServer(t)
+	client := connectFake(t, s)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Play(context.Background(), endless{})
+	}()
+	require.Eventually(t, client.Playing, time.Second, time.Millisecond)
+
+	s.disconnect()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrClosed)
+	case <-time.After(time.Second):
+		t.Fatal("信令断开后播放未结束")
+	}
+	assert.ErrorIs(t, client.Play(context.Background(), endless{}), ErrClosed)
+}
+
+func TestKeepAliveSendsSenderReport(t *testing.T) {
+	s := newFakeVoiceServer(t)
+	connectFake(t, s, WithKeepAliveInterval(10*time.Millisecond))
+
+	buf := make([]byte, 64)
+	s.rtcp.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := s.rtcp.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, 28, n)
+	assert.Equal(t, byte(200), buf[1])
+	assert.Equal(t, uint32(1234), binary.BigEndian.Uint32(buf[4:8]))
+}
+
+// oggPage 构造一个Ogg页
+func oggPage(headerType byte, granule int64, seq uint32, segments []byte, body []byte) []byte {
+	page := make([]byte, oggPageHeaderSize, oggPageHeaderSize+len(segments)+len(body))
+	copy(page, "OggS")
+	page[5] = headerType
+	binary.LittleEndian.PutUint64(page[6:14], uint64(granule))
+	binary.LittleEndian.PutUint32(page[14:18], 0x1234)
+	binary.LittleEndian.PutUint32(page[18:22], seq)
+	page[26] = byte(len(segments))
+	page = append(page, segments...)
+	page = append(page, body...)
+	binary.LittleEndian.PutUint32(page[22:26], oggCRC(0, page))
+	return page
+}
+
+func TestOggReader(t *testing.T) {
+	head := append([]byte("OpusHead"), 1, 2, 0x38, 0x01, 0x80, 0xbb, 0, 0, 0, 0, 0)
+	tags := append([]byte("OpusTags"), 0, 0, 0, 0, 0, 0, 0, 0)
+	long := bytes.Repeat([]byte{0xab}, 300)
+
+	var file bytes.Buffer
+	file.Write(oggPage(0x02, 0, 0, []byte{byte(len(head))}, head))
+	file.Write(oggPage(0, 0, 1, []byte{byte(len(tags))}, tags))
+	// 第一个包跨越两个分段，第二个包从本页延续到下一页
+	file.Write(oggPage(0, 960, 2, []byte{255, 45, 255}, append(append([]byte{}, long...), long[:255]...)))
+	file.Write(oggPage(oggContinued|0x04, 1920, 3, []byte{45}, long[255:]))
+
+	reader, err := NewOggReader(&file)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(2), reader.Head().Channels)
+	assert.Equal(t, uint16(312), reader.Head().PreSkip)
+	assert.Equal(t, uint32(48000), reader.Head().SampleRate)
+
+	for i := 0; i < 2; i++ {
+		packet, err := reader.ReadPacket()
+		require.NoError(t, err)
+		assert.Equal(t, long, packet)
+	}
+	_, err = reader.ReadPacket()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, int64(1920), reader.Granule())
+}
+
+func TestOggReaderRejectsCorruptPage(t *testing.T) {
+	head := append([]byte("OpusHead"), 1, 2, 0, 0, 0x80, 0xbb, 0, 0, 0, 0, 0)
+	page := oggPage(0x02, 0, 0, []byte{byte(len(head))}, head)
+	page[len(page)-1] ^= 0xff
+
+	_, err := NewOggReader(bytes.NewReader(page))
+	assert.Error(t, err)
+}
+
+func TestPacketizer(t *testing.T) {
+	p := NewPacketizer(111, 42)
+	seq, ts := p.Sequence(), p.Timestamp()
+
+	packet := p.Packetize([]byte{0xfc}, 960)
+	assert.Equal(t, byte(0x80|111), packet[1])
+	assert.Equal(t, seq, binary.BigEndian.Uint16(packet[2:4]))
+	assert.Equal(t, ts, binary.BigEndian.Uint32(packet[4:8]))
+	assert.Equal(t, uint32(42), binary.BigEndian.Uint32(packet[8:12]))
+
+	packet = p.Packetize([]byte{0xfc}, 960)
+	assert.Equal(t, byte(111), packet[1])
+	assert.Equal(t, ts+960, binary.BigEndian.Uint32(packet[4:8]))
+
+	// 静默期后时间戳跳过且重新设置标记位
+	p.Skip(4800)
+	packet = p.Packetize([]byte{0xfc}, 960)
+	assert.Equal(t, byte(0x80|111), packet[1])
+	assert.Equal(t, seq+2, binary.BigEndian.Uint16(packet[2:4]))
+	assert.Equal(t, ts+1920+4800, binary.BigEndian.Uint32(packet[4:8]))
+}
+
+func TestOpusPacketSamples(t *testing.T) {
+	cases := []struct {
+		packet  []byte
+		samples int
+	}{
+		{[]byte{0xfc}, 960},              // CELT 20ms，单帧
+		{[]byte{0x08}, 960},              // SILK 20ms
+		{[]byte{0x18}, 2880},             // SILK 60ms
+		{[]byte{0xe1, 0x00}, 240},        // CELT 2.5ms，两帧
+		{[]byte{0xfb, 0x03, 0x00}, 2880}, // CELT 20ms，三帧
+	}
+	for _, c := range cases {
+		samples, err := OpusPacketSamples(c.packet)
+		require.NoError(t, err)
+		assert.Equal(t, c.samples, samples, "toc %#x", c.packet[0])
+	}
+
+	_, err := OpusPacketSamples(nil)
+	assert.Error(t, err)
+	_, err = OpusPacketSamples([]byte{0xfb, 0x3f})
+	assert.Error(t, err)
+}
+
+// wavFile 生成16位单声道WAV文件，包含一个需跳过的 LIST 块
+func wavFile(sampleRate int, samples []int16) []byte {
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, samples)
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(4+24+10+8+data.Len()))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, []uint32{16})
+	binary.Write(&buf, binary.LittleEndian, []uint16{1, 1})
+	binary.Write(&buf, binary.LittleEndian, []uint32{uint32(sampleRate), uint32(sampleRate * 2)})
+	binary.Write(&buf, binary.LittleEndian, []uint16{2, 16})
+	buf.WriteString("LIST")
+	binary.Write(&buf, binary.LittleEndian, uint32(1))
+	buf.Write([]byte{0, 0})
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(data.Len()))
+	buf.Write(data.Bytes())
+	return buf.Bytes()
+}
+
+func TestWAVReader(t *testing.T) {
+	// 8kHz 下20毫秒为160个采样，共2.5帧
+	samples := make([]int16, 400)
+	for i := range samples {
+		samples[i] = int16(i + 1)
+	}
+	reader, err := NewWAVReader(bytes.NewReader(wavFile(8000, samples)))
+	require.NoError(t, err)
+	assert.Equal(t, PCMFormat{SampleRate: 8000, Channels: 1, BitsPerSample: 16}, reader.Format())
+	assert.Equal(t, 50*time.Millisecond, reader.Duration())
+
+	var frames [][]byte
+	for {
+		frame, err := reader.ReadFrame()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		frames = append(frames, frame)
+	}
+	require.Len(t, frames, 3)
+	assert.Equal(t, int16(1), int16(binary.LittleEndian.Uint16(frames[0])))
+	assert.Equal(t, int16(161), int16(binary.LittleEndian.Uint16(frames[1])))
+	// 最后一帧不足部分补静音
+	assert.Len(t, frames[2], 320)
+	assert.Equal(t, int16(400), int16(binary.LittleEndian.Uint16(frames[2][158:])))
+	assert.Equal(t, make([]byte, 160), frames[2][160:])
+
+	require.NoError(t, reader.Seek(40*time.Millisecond))
+	frame, err := reader.ReadFrame()
+	require.NoError(t, err)
+	assert.Equal(t, int16(321), int16(binary.LittleEndian.Uint16(frame)))
+
+	// 经编码器转换为Opus数据源，PCM原样送入编码器
+	src := NewEncodedSource(reader, EncoderFunc(func(pcm []byte) ([]byte, error) {
+		return []byte{0xfc, pcm[0]}, nil
+	}))
+	require.NoError(t, src.(Seeker).Seek(0))
+	packet, err := src.ReadPacket()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xfc, 1}, packet)
+}
+
+func TestFrameReaderSeek(t *testing.T) {
+	data, err := io.ReadAll(frames(10))
+	require.NoError(t, err)
+	reader := NewFrameReader(bytes.NewReader(data))
+
+	require.NoError(t, reader.Seek(100*time.Millisecond))
+	packet, err := reader.ReadPacket()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xfc, 5, 5}, packet)
+
+	assert.Error(t, NewFrameReader(frames(1)).Seek(time.Second))
+}
+
+func TestPlayerPlaysQueueAndSkips(t *testing.T) {
+	s := newFakeVoiceServer(t)
+	client := connectFake(t, s)
+	player := NewPlayer(client)
+
+	started := make(chan string, 3)
+	ended := make(chan error, 3)
+	player.OnTrackStart(func(track Track) { started <- track.Title })
+	player.OnTrackEnd(func(track Track, err error) { ended <- err })
+
+	player.Enqueue(
+		Track{Title: "endless", Open: func() (OpusSource, error) { return endless{}, nil }},
+		Track{Title: "short", Open: func() (OpusSource, error) { return NewFrameReader(frames(2)), nil }},
+	)
+	assert.Len(t, player.Queue(), 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- player.Run(ctx) }()
+
+	assert.Equal(t, "endless", <-started)
+	current, ok := player.Current()
+	assert.True(t, ok)
+	assert.Equal(t, "endless", current.Title)
+	assert.Error(t, player.Seek(time.Second))
+
+	player.Skip()
+	assert.ErrorIs(t, <-ended, ErrStopped)
+	assert.Equal(t, "short", <-started)
+	assert.NoError(t, <-ended)
+
+	// 队列播放完后等待新曲目
+	player.Enqueue(Track{Title: "later", Open: func() (OpusSource, error) { return NewFrameReader(frames(1)), nil }})
+	assert.Equal(t, "later", <-started)
+	assert.NoError(t, <-ended)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,274 @@
+package voice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// PCMFormat PCM音频格式
+type PCMFormat struct {
+	SampleRate    int // 采样率
+	Channels      int // 声道数
+	BitsPerSample int // 位深
+}
+
+// BlockAlign 每个采样帧（所有声道）的字节数
+func (f PCMFormat) BlockAlign() int {
+	return f.Channels * f.BitsPerSample / 8
+}
+
+// FrameBytes 时长 d 对应的字节数
+func (f PCMFormat) FrameBytes(d time.Duration) int {
+	return int(int64(f.SampleRate)*int64(d)/int64(time.Second)) * f.BlockAlign()
+}
+
+// PCMSource PCM数据源
+type PCMSource interface {
+	// Format 返回PCM格式
+	Format() PCMFormat
+	// ReadFrame 返回下一帧PCM数据，数据结束时返回 io.EOF
+	ReadFrame() ([]byte, error)
+}
+
+// Encoder 将一帧PCM编码为一个Opus包
+//
+// SDK 不内置Opus编码器，可接入 libopus 绑定等实现。
+type Encoder interface {
+	Encode(pcm []byte) ([]byte, error)
+}
+
+// EncoderFunc 函数形式的编码器
+type EncoderFunc func(pcm []byte) ([]byte, error)
+
+// Encode 实现 Encoder
+func (f EncoderFunc) Encode(pcm []byte) ([]byte, error) {
+	return f(pcm)
+}
+
+// encodedSource 将PCM数据源经编码器转换为Opus数据源
+type encodedSource struct {
+	src PCMSource
+	enc Encoder
+}
+
+// NewEncodedSource 用编码器将PCM数据源转换为Opus数据源，数据原样送入编码器，不做音量等处理
+//
+// 若 src 实现 Seeker，返回的数据源也支持跳转。
+func NewEncodedSource(src PCMSource, enc Encoder) OpusSource {
+	return &encodedSource{src: src, enc: enc}
+}
+
+// ReadPacket 读取并编码下一帧
+func (e *encodedSource) ReadPacket() ([]byte, error) {
+	pcm, err := e.src.ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+	packet, err := e.enc.Encode(pcm)
+	if err != nil {
+		return nil, fmt.Errorf("编码音频失败: %w", err)
+	}
+	return packet, nil
+}
+
+// Seek 跳转到指定位置
+func (e *encodedSource) Seek(position time.Duration) error {
+	seeker, ok := e.src.(Seeker)
+	if !ok {
+		return fmt.Errorf("数据源不支持跳转")
+	}
+	return seeker.Seek(position)
+}
+
+const (
+	wavFormatPCM        = 0x0001
+	wavFormatExtensible = 0xfffe
+)
+
+// WAVReader WAV文件读取器，按固定时长输出PCM帧
+//
+// 只支持未压缩的整数PCM。
+type WAVReader struct {
+	r             io.Reader
+	format        PCMFormat
+	frameDuration time.Duration
+
+	dataStart int64 // data 块在文件中的偏移
+	dataSize  int64 // data 块长度，未知时为 -1
+	remaining int64 // data 块中尚未读取的字节数，未知时为 -1
+}
+
+// NewWAVReader 创建WAV读取器并解析头部，默认每帧20毫秒
+func NewWAVReader(r io.Reader) (*WAVReader, error) {
+	w := &WAVReader{r: r, frameDuration: FrameDuration}
+
+	riff := make([]byte, 12)
+	if _, err := io.ReadFull(r, riff); err != nil {
+		return nil, fmt.Errorf("读取WAV头部失败: %w", err)
+	}
+	if !bytes.Equal(riff[:4], []byte("RIFF")) || !bytes.Equal(riff[8:12], []byte("WAVE")) {
+		return nil, fmt.Errorf("不是WAV文件")
+	}
+
+	offset := int64(12)
+	hasFormat := false
+	for {
+		chunk := make([]byte, 8)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, fmt.Errorf("缺少data块: %w", err)
+		}
+		offset += 8
+		id := string(chunk[:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+
+		if id == "data" {
+			if !hasFormat {
+				return nil, fmt.Errorf("缺少fmt块")
+			}
+			w.dataStart = offset
+			w.dataSize = size
+			// 流式写入的文件长度可能为0或最大值，读到文件结尾为止
+			if size == 0 || size == 0xffffffff {
+				w.dataSize = -1
+			}
+			w.remaining = w.dataSize
+			return w, nil
+		}
+
+		// 块长度为奇数时有一个填充字节
+		padded := size + size%2
+		if id != "fmt " {
+			if _, err := io.CopyN(io.Discard, r, padded); err != nil {
+				return nil, fmt.Errorf("读取WAV块失败: %w", err)
+			}
+			offset += padded
+			continue
+		}
+
+		if size < 16 {
+			return nil, fmt.Errorf("fmt块长度无效")
+		}
+		body := make([]byte, padded)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return nil, fmt.Errorf("读取fmt块失败: %w", err)
+		}
+		offset += padded
+		if err := w.parseFormat(body[:size]); err != nil {
+			return nil, err
+		}
+		hasFormat = true
+	}
+}
+
+// parseFormat 解析 fmt 块
+func (w *WAVReader) parseFormat(body []byte) error {
+	audioFormat := binary.LittleEndian.Uint16(body[0:2])
+	if audioFormat == wavFormatExtensible {
+		// 扩展格式的子格式GUID前两字节即格式代码
+		if len(body) < 26 {
+			return fmt.Errorf("fmt块长度无效")
+		}
+		audioFormat = binary.LittleEndian.Uint16(body[24:26])
+	}
+	if audioFormat != wavFormatPCM {
+		return fmt.Errorf("不支持的WAV编码: %d", audioFormat)
+	}
+
+	w.format = PCMFormat{
+		Channels:      int(binary.LittleEndian.Uint16(body[2:4])),
+		SampleRate:    int(binary.LittleEndian.Uint32(body[4:8])),
+		BitsPerSample: int(binary.LittleEndian.Uint16(body[14:16])),
+	}
+	if w.format.Channels == 0 || w.format.SampleRate == 0 {
+		return fmt.Errorf("WAV格式无效")
+	}
+	switch w.format.BitsPerSample {
+	case 8, 16, 24, 32:
+	default:
+		return fmt.Errorf("不支持的位深: %d", w.format.BitsPerSample)
+	}
+	return nil
+}
+
+// SetFrameDuration 设置每帧时长，应与编码器的帧长一致
+func (w *WAVReader) SetFrameDuration(d time.Duration) {
+	w.frameDuration = d
+}
+
+// Format 获取PCM格式
+func (w *WAVReader) Format() PCMFormat {
+	return w.format
+}
+
+// Duration 获取音频总时长，长度未知时返回0
+func (w *WAVReader) Duration() time.Duration {
+	if w.dataSize < 0 {
+		return 0
+	}
+	frames := w.dataSize / int64(w.format.BlockAlign())
+	return time.Duration(frames) * time.Second / time.Duration(w.format.SampleRate)
+}
+
+// ReadFrame 读取一帧PCM数据，最后不足一帧的部分以静音补齐
+func (w *WAVReader) ReadFrame() ([]byte, error) {
+	frame := make([]byte, w.format.FrameBytes(w.frameDuration))
+	want := int64(len(frame))
+	if w.remaining >= 0 && w.remaining < want {
+		want = w.remaining
+	}
+	if want == 0 {
+		return nil, io.EOF
+	}
+
+	n, err := io.ReadFull(w.r, frame[:want])
+	if w.remaining >= 0 {
+		w.remaining -= int64(n)
+	}
+	if n == 0 {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("读取PCM数据失败: %w", err)
+	}
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("读取PCM数据失败: %w", err)
+	}
+
+	// 不完整的采样帧丢弃，剩余部分为静音；8位PCM为无符号，静音为0x80
+	n -= n % w.format.BlockAlign()
+	for i := n; i < len(frame); i++ {
+		if w.format.BitsPerSample == 8 {
+			frame[i] = 0x80
+		} else {
+			frame[i] = 0
+		}
+	}
+	return frame, nil
+}
+
+// Seek 跳转到指定位置，底层数据需实现 io.Seeker
+func (w *WAVReader) Seek(position time.Duration) error {
+	seeker, ok := w.r.(io.Seeker)
+	if !ok {
+		return fmt.Errorf("数据源不支持跳转")
+	}
+	if position < 0 {
+		position = 0
+	}
+
+	offset := int64(position) * int64(w.format.SampleRate) / int64(time.Second) * int64(w.format.BlockAlign())
+	if w.dataSize >= 0 && offset > w.dataSize {
+		offset = w.dataSize
+	}
+	if _, err := seeker.Seek(w.dataStart+offset, io.SeekStart); err != nil {
+		return fmt.Errorf("跳转失败: %w", err)
+	}
+	if w.dataSize >= 0 {
+		w.remaining = w.dataSize - offset
+	}
+	return nil
+}
@@ -0,0 +1,472 @@
+package kook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// VoiceSession 用户在一个语音频道中的停留记录
+type VoiceSession struct {
+	UserID    string    // 用户ID
+	GuildID   string    // 服务器ID
+	ChannelID string    // 语音频道ID
+	JoinedAt  time.Time // 加入时间
+	LeftAt    time.Time // 离开时间，仍在频道中时为零值
+}
+
+// Active 判断会话是否仍在进行
+func (s VoiceSession) Active() bool {
+	return s.LeftAt.IsZero()
+}
+
+// Duration 获取会话时长，进行中的会话计算到当前时间
+func (s VoiceSession) Duration() time.Duration {
+	if s.Active() {
+		return time.Since(s.JoinedAt)
+	}
+	return s.LeftAt.Sub(s.JoinedAt)
+}
+
+// voiceThreshold 累计时长阈值回调
+type voiceThreshold struct {
+	duration time.Duration
+	handler  func(guildID, userID string, total time.Duration)
+	reached  map[voiceUserKey]bool
+}
+
+// voiceUserKey 服务器内的用户
+type voiceUserKey struct {
+	guildID string
+	userID  string
+}
+
+// departedRetention 结束的会话保留多久，用于忽略在此期间乱序到达的加入事件
+const departedRetention = 10 * time.Second
+
+// departure 最近结束的会话
+type departure struct {
+	session    VoiceSession
+	recordedAt time.Time
+}
+
+// pendingLeave 等待确认是否为换频道的离开
+type pendingLeave struct {
+	session VoiceSession
+	timer   *time.Timer
+}
+
+// VoiceTracker 语音频道在线状态跟踪器
+//
+// 根据 joined_channel 与 exited_channel 系统事件维护每个用户所在的语音频道，
+// 累计每个服务器内每个用户的语音时长，并在进入、离开、换频道时回调。
+// 用户离开后若在 MoveWindow 内加入同一服务器的其他频道，视为换频道，只触发 OnMove。
+// 时长只保存在内存中，需要长期累计时可在 OnLeave 中自行持久化。
+type VoiceTracker struct {
+	client     *Client
+	logger     Logger
+	moveWindow time.Duration
+
+	mu       sync.Mutex
+	active   map[string]VoiceSession        // 用户ID -> 当前会话
+	leaving  map[string]*pendingLeave       // 用户ID -> 等待确认的离开
+	departed map[string]departure           // 用户ID -> 最近结束的会话，用于忽略乱序到达的加入事件
+	totals   map[voiceUserKey]time.Duration // 已结束会话的累计时长
+
+	thresholds []*voiceThreshold
+	onEnter    []func(VoiceSession)
+	onLeave    []func(VoiceSession)
+	onMove     []func(from, to VoiceSession)
+}
+
+// VoiceTrackerOption 语音跟踪器配置选项
+type VoiceTrackerOption func(*VoiceTracker)
+
+// WithMoveWindow 设置识别换频道的时间窗口，默认2秒，0表示不合并离开与加入
+func WithMoveWindow(window time.Duration) VoiceTrackerOption {
+	return func(t *VoiceTracker) {
+		t.moveWindow = window
+	}
+}
+
+// NewVoiceTracker 创建语音频道在线状态跟踪器
+func NewVoiceTracker(client *Client, opts ...VoiceTrackerOption) *VoiceTracker {
+	t := &VoiceTracker{
+		client:     client,
+		logger:     client.Logger("voice_tracker"),
+		moveWindow: 2 * time.Second,
+		active:     make(map[string]VoiceSession),
+		leaving:    make(map[string]*pendingLeave),
+		departed:   make(map[string]departure),
+		totals:     make(map[voiceUserKey]time.Duration),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Attach 注册系统事件处理器
+func (t *VoiceTracker) Attach(dispatcher EventDispatcher) {
+	dispatcher.OnEvent(EventTypeSystem, t.HandleEvent)
+}
+
+// OnEnter 注册用户进入语音频道的回调
+func (t *VoiceTracker) OnEnter(handler func(session VoiceSession)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onEnter = append(t.onEnter, handler)
+}
+
+// OnLeave 注册用户离开语音频道的回调，session 为已结束的会话
+func (t *VoiceTracker) OnLeave(handler func(session VoiceSession)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onLeave = append(t.onLeave, handler)
+}
+
+// OnMove 注册用户换频道的回调，from 为已结束的会话，to 为新会话
+func (t *VoiceTracker) OnMove(handler func(from, to VoiceSession)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onMove = append(t.onMove, handler)
+}
+
+// OnThreshold 注册累计时长阈值回调，每个服务器内每个用户只触发一次
+//
+// 在离开、换频道以及 Check 时检查，进行中的会话也计入时长。
+func (t *VoiceTracker) OnThreshold(duration time.Duration, handler func(guildID, userID string, total time.Duration)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.thresholds = append(t.thresholds, &voiceThreshold{
+		duration: duration,
+		handler:  handler,
+		reached:  make(map[voiceUserKey]bool),
+	})
+}
+
+// Prime 从 REST 接口加载语音频道中已有的用户，加入时间记为当前时间
+func (t *VoiceTracker) Prime(guildID, channelID string) error {
+	users, err := t.client.Channel.GetChannelUserList(channelID)
+	if err != nil {
+		return fmt.Errorf("获取语音频道用户失败: %w", err)
+	}
+
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, user := range users {
+		if _, ok := t.active[user.ID]; ok {
+			continue
+		}
+		t.active[user.ID] = VoiceSession{
+			UserID:    user.ID,
+			GuildID:   guildID,
+			ChannelID: channelID,
+			JoinedAt:  now,
+		}
+	}
+	return nil
+}
+
+// HandleEvent 处理加入与退出语音频道事件
+func (t *VoiceTracker) HandleEvent(event *Event) {
+	system, err := event.SystemEvent()
+	if err != nil {
+		return
+	}
+	if system.Type != SystemEventJoinedChannel && system.Type != SystemEventExitedChannel {
+		return
+	}
+
+	var body VoiceChannelEventBody
+	if err := system.DecodeBody(&body); err != nil {
+		t.logger.Warn("解析语音频道事件失败", ErrField(err))
+		return
+	}
+	if body.UserID == "" || body.ChannelID == "" {
+		return
+	}
+
+	if system.Type == SystemEventJoinedChannel {
+		t.join(event.GuildID(), body.UserID, body.ChannelID, eventTime(body.JoinedAt))
+	} else {
+		t.exit(body.UserID, body.ChannelID, eventTime(body.ExitedAt))
+	}
+}
+
+// eventTime 将毫秒时间戳转换为时间，缺失时使用当前时间
+func eventTime(ms int64) time.Time {
+	if ms <= 0 {
+		return time.Now()
+	}
+	return time.UnixMilli(ms)
+}
+
+// join 记录用户加入频道
+func (t *VoiceTracker) join(guildID, userID, channelID string, at time.Time) {
+	t.mu.Lock()
+
+	// 事件并发分发，可能晚于同一频道的退出事件到达
+	if last, ok := t.departed[userID]; ok && last.session.ChannelID == channelID && !last.session.LeftAt.Before(at) {
+		t.mu.Unlock()
+		return
+	}
+
+	current, inChannel := t.active[userID]
+	if inChannel && current.ChannelID == channelID {
+		t.mu.Unlock()
+		return
+	}
+
+	// 换到其他服务器的频道不算换频道，原会话按离开处理
+	var from *VoiceSession
+	var left []VoiceSession
+	if pending, ok := t.leaving[userID]; ok {
+		pending.timer.Stop()
+		delete(t.leaving, userID)
+		if pending.session.GuildID == guildID {
+			from = &pending.session
+		} else {
+			left = append(left, pending.session)
+		}
+	}
+	if inChannel {
+		// 未收到退出事件就加入了其他频道
+		current.LeftAt = at
+		t.finish(current)
+		if current.GuildID == guildID {
+			from = &current
+		} else {
+			left = append(left, current)
+		}
+	}
+
+	session := VoiceSession{
+		UserID:    userID,
+		GuildID:   guildID,
+		ChannelID: channelID,
+		JoinedAt:  at,
+	}
+	t.active[userID] = session
+	delete(t.departed, userID)
+
+	onEnter := append([]func(VoiceSession){}, t.onEnter...)
+	onMove := append([]func(from, to VoiceSession){}, t.onMove...)
+	t.mu.Unlock()
+
+	for _, s := range left {
+		t.emitLeave(s)
+	}
+	if from != nil {
+		for _, handler := range onMove {
+			handler(*from, session)
+		}
+		t.Check()
+		return
+	}
+	for _, handler := range onEnter {
+		handler(session)
+	}
+}
+
+// exit 记录用户离开频道
+func (t *VoiceTracker) exit(userID, channelID string, at time.Time) {
+	t.mu.Lock()
+	t.pruneDeparted()
+
+	session, ok := t.active[userID]
+	if !ok || session.ChannelID != channelID {
+		// 已经换到其他频道，或加入事件尚未到达
+		if !ok {
+			t.depart(VoiceSession{UserID: userID, ChannelID: channelID, LeftAt: at})
+		}
+		t.mu.Unlock()
+		return
+	}
+
+	session.LeftAt = at
+	delete(t.active, userID)
+	t.depart(session)
+	t.finish(session)
+
+	if t.moveWindow <= 0 {
+		t.mu.Unlock()
+		t.emitLeave(session)
+		return
+	}
+
+	pending := &pendingLeave{session: session}
+	pending.timer = time.AfterFunc(t.moveWindow, func() {
+		t.mu.Lock()
+		if t.leaving[userID] != pending {
+			t.mu.Unlock()
+			return
+		}
+		delete(t.leaving, userID)
+		t.mu.Unlock()
+		t.emitLeave(session)
+	})
+	t.leaving[userID] = pending
+	t.mu.Unlock()
+}
+
+// depart 记录结束的会话，调用方需持有锁
+func (t *VoiceTracker) depart(session VoiceSession) {
+	t.departed[session.UserID] = departure{session: session, recordedAt: time.Now()}
+}
+
+// pruneDeparted 移除超过 departedRetention 的结束会话，调用方需持有锁
+func (t *VoiceTracker) pruneDeparted() {
+	for userID, d := range t.departed {
+		if time.Since(d.recordedAt) > departedRetention {
+			delete(t.departed, userID)
+		}
+	}
+}
+
+// finish 将结束的会话计入累计时长，调用方需持有锁
+func (t *VoiceTracker) finish(session VoiceSession) {
+	if duration := session.Duration(); duration > 0 {
+		t.totals[voiceUserKey{session.GuildID, session.UserID}] += duration
+	}
+}
+
+// emitLeave 触发离开回调并检查阈值
+func (t *VoiceTracker) emitLeave(session VoiceSession) {
+	t.mu.Lock()
+	onLeave := append([]func(VoiceSession){}, t.onLeave...)
+	t.mu.Unlock()
+
+	for _, handler := range onLeave {
+		handler(session)
+	}
+	t.Check()
+}
+
+// Session 获取用户当前所在语音频道的会话
+func (t *VoiceTracker) Session(userID string) (VoiceSession, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	session, ok := t.active[userID]
+	return session, ok
+}
+
+// ChannelSessions 获取语音频道中的所有会话，按加入时间排序
+func (t *VoiceTracker) ChannelSessions(channelID string) []VoiceSession {
+	return t.sessions(func(s VoiceSession) bool { return s.ChannelID == channelID })
+}
+
+// GuildSessions 获取服务器所有语音频道中的会话，按加入时间排序
+func (t *VoiceTracker) GuildSessions(guildID string) []VoiceSession {
+	return t.sessions(func(s VoiceSession) bool { return s.GuildID == guildID })
+}
+
+// sessions 按条件筛选当前会话
+func (t *VoiceTracker) sessions(match func(VoiceSession) bool) []VoiceSession {
+	t.mu.Lock()
+	var result []VoiceSession
+	for _, session := range t.active {
+		if match(session) {
+			result = append(result, session)
+		}
+	}
+	t.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].JoinedAt.Before(result[j].JoinedAt)
+	})
+	return result
+}
+
+// Duration 获取用户在服务器内的累计语音时长，包含进行中的会话
+func (t *VoiceTracker) Duration(guildID, userID string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.duration(guildID, userID)
+}
+
+// duration 计算累计时长，调用方需持有锁
+func (t *VoiceTracker) duration(guildID, userID string) time.Duration {
+	total := t.totals[voiceUserKey{guildID, userID}]
+	if session, ok := t.active[userID]; ok && session.GuildID == guildID {
+		total += session.Duration()
+	}
+	return total
+}
+
+// Reset 清零用户在服务器内的累计时长与已触发的阈值，进行中的会话从现在重新计时
+func (t *VoiceTracker) Reset(guildID, userID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := voiceUserKey{guildID, userID}
+	delete(t.totals, key)
+	for _, threshold := range t.thresholds {
+		delete(threshold.reached, key)
+	}
+	if session, ok := t.active[userID]; ok && session.GuildID == guildID {
+		session.JoinedAt = time.Now()
+		t.active[userID] = session
+	}
+}
+
+// Check 检查累计时长阈值，触发新达到的阈值回调
+func (t *VoiceTracker) Check() {
+	type reached struct {
+		threshold *voiceThreshold
+		guildID   string
+		userID    string
+		total     time.Duration
+	}
+
+	t.mu.Lock()
+	if len(t.thresholds) == 0 {
+		t.mu.Unlock()
+		return
+	}
+
+	// 候选用户为有累计时长或正在语音中的用户
+	candidates := make(map[voiceUserKey]bool)
+	for key := range t.totals {
+		candidates[key] = true
+	}
+	for _, session := range t.active {
+		candidates[voiceUserKey{session.GuildID, session.UserID}] = true
+	}
+
+	var fired []reached
+	for key := range candidates {
+		total := t.duration(key.guildID, key.userID)
+		for _, threshold := range t.thresholds {
+			if threshold.reached[key] || total < threshold.duration {
+				continue
+			}
+			threshold.reached[key] = true
+			fired = append(fired, reached{threshold, key.guildID, key.userID, total})
+		}
+	}
+	t.mu.Unlock()
+
+	for _, r := range fired {
+		r.threshold.handler(r.guildID, r.userID, r.total)
+	}
+}
+
+// Run 按间隔检查累计时长阈值，直到 ctx 结束
+func (t *VoiceTracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.Check()
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package kook
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Waiter 等待满足条件的事件，用于多步交互
+//
+// Waiter 只在事件源上注册一次处理器，之后每次等待都复用它，不会因等待次数增加而累积处理器。
+// 一个事件可以同时满足多个等待。
+type Waiter struct {
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]*pendingWait
+}
+
+// pendingWait 正在进行的等待
+type pendingWait struct {
+	filters []EventFilter
+	result  chan *Event
+}
+
+// NewWaiter 创建等待器，在事件源上注册消息与系统事件处理器
+func NewWaiter(dispatcher EventDispatcher) *Waiter {
+	w := &Waiter{pending: make(map[uint64]*pendingWait)}
+	for _, eventType := range streamEventTypes {
+		dispatcher.OnEvent(eventType, w.handleEvent)
+	}
+	return w
+}
+
+// handleEvent 将事件交给所有匹配的等待
+func (w *Waiter) handleEvent(event *Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for id, wait := range w.pending {
+		if !matchFilters(event, wait.filters) {
+			continue
+		}
+		// result 有一个缓冲位，匹配后立即移除，不会阻塞
+		wait.result <- event
+		delete(w.pending, id)
+	}
+}
+
+// Pending 获取正在等待的数量
+func (w *Waiter) Pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// Wait 等待第一个满足所有条件的事件，ctx 结束时返回错误
+//
+// 通常使用 context.WithTimeout 设置等待时间，超时后返回的错误满足
+// errors.Is(err, context.DeadlineExceeded)。
+func (w *Waiter) Wait(ctx context.Context, filters ...EventFilter) (*Event, error) {
+	wait := &pendingWait{
+		filters: filters,
+		result:  make(chan *Event, 1),
+	}
+
+	w.mu.Lock()
+	w.nextID++
+	id := w.nextID
+	w.pending[id] = wait
+	w.mu.Unlock()
+
+	select {
+	case event := <-wait.result:
+		return event, nil
+	case <-ctx.Done():
+		w.mu.Lock()
+		delete(w.pending, id)
+		w.mu.Unlock()
+
+		// 取消的同时可能已经匹配
+		select {
+		case event := <-wait.result:
+			return event, nil
+		default:
+		}
+		return nil, fmt.Errorf("等待事件失败: %w", ctx.Err())
+	}
+}
+
+// WaitForMessage 等待满足条件的消息，不含系统事件
+//
+// 例如等待同一用户在同一频道的回复：
+//
+//	reply, err := waiter.WaitForMessage(ctx,
+//		kook.FilterAuthor(event.AuthorID), kook.FilterChannel(event.ChannelID()))
+func (w *Waiter) WaitForMessage(ctx context.Context, filters ...EventFilter) (*Event, error) {
+	return w.Wait(ctx, append([]EventFilter{FilterMessages()}, filters...)...)
+}
+
+// WaitForReaction 等待指定消息上的新增回应，match 为 nil 时接受任意回应
+func (w *Waiter) WaitForReaction(ctx context.Context, msgID string, match func(*ReactionEventBody) bool) (*ReactionEventBody, error) {
+	var matched *ReactionEventBody
+	_, err := w.Wait(ctx,
+		FilterSystemEvent(SystemEventAddedReaction, SystemEventPrivateAddedReaction),
+		func(event *Event) bool {
+			var body ReactionEventBody
+			if !decodeSystemBody(event, &body) || body.MsgID != msgID {
+				return false
+			}
+			if match != nil && !match(&body) {
+				return false
+			}
+			matched = &body
+			return true
+		})
+	if err != nil {
+		return nil, err
+	}
+	return matched, nil
+}
+
+// WaitForButton 等待指定消息上的按钮点击，match 为 nil 时接受任意点击
+func (w *Waiter) WaitForButton(ctx context.Context, msgID string, match func(*MessageButtonClickBody) bool) (*MessageButtonClickBody, error) {
+	var matched *MessageButtonClickBody
+	_, err := w.Wait(ctx,
+		FilterSystemEvent(SystemEventMessageBtnClick),
+		func(event *Event) bool {
+			var body MessageButtonClickBody
+			if !decodeSystemBody(event, &body) || body.MsgID != msgID {
+				return false
+			}
+			if match != nil && !match(&body) {
+				return false
+			}
+			matched = &body
+			return true
+		})
+	if err != nil {
+		return nil, err
+	}
+	return matched, nil
+}
+
+// ReactionBy 匹配指定用户的回应，emojiIDs 非空时还要求表情在其中
+func ReactionBy(userID string, emojiIDs ...string) func(*ReactionEventBody) bool {
+	return func(body *ReactionEventBody) bool {
+		if userID != "" && body.UserID != userID {
+			return false
+		}
+		if len(emojiIDs) == 0 {
+			return true
+		}
+		for _, id := range emojiIDs {
+			if body.Emoji.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ButtonBy 匹配指定用户的点击，values 非空时还要求按钮的 value 在其中
+func ButtonBy(userID string, values ...string) func(*MessageButtonClickBody) bool {
+	return func(body *MessageButtonClickBody) bool {
+		if userID != "" && body.UserID != userID {
+			return false
+		}
+		if len(values) == 0 {
+			return true
+		}
+		for _, value := range values {
+			if body.Value == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// decodeSystemBody 解析系统事件数据，失败时返回 false
+func decodeSystemBody(event *Event, v interface{}) bool {
+	extra, err := event.SystemEvent()
+	if err != nil {
+		return false
+	}
+	return extra.DecodeBody(v) == nil
+}
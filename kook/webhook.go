@@ -17,9 +17,11 @@ import (
 // WebhookHandler Webhook处理器
 type WebhookHandler struct {
 	client       *Client
+	logger       Logger
 	encryptKey   string
 	verifyToken  string
 	eventHandlers map[int][]EventHandler
+	streamSlot    hubSlot
 }
 
 // WebhookMessage Webhook消息结构
@@ -34,6 +36,7 @@ type WebhookMessage struct {
 func NewWebhookHandler(client *Client, encryptKey, verifyToken string) *WebhookHandler {
 	return &WebhookHandler{
 		client:        client,
+		logger:        client.Logger(LogSubsystemWebhook),
 		encryptKey:    encryptKey,
 		verifyToken:   verifyToken,
 		eventHandlers: make(map[int][]EventHandler),
@@ -45,6 +48,11 @@ func (wh *WebhookHandler) OnEvent(eventType int, handler EventHandler) {
 	wh.eventHandlers[eventType] = append(wh.eventHandlers[eventType], handler)
 }
 
+// streamHub 实现 hubSource，事件流共用同一组处理器
+func (wh *WebhookHandler) streamHub() *streamHub {
+	return wh.streamSlot.get(wh)
+}
+
 // HandleRequest 处理HTTP请求
 func (wh *WebhookHandler) HandleRequest(w http.ResponseWriter, r *http.Request) {
 	// 验证请求方法
@@ -56,7 +64,7 @@ func (wh *WebhookHandler) HandleRequest(w http.ResponseWriter, r *http.Request)
 	// 读取请求体
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		wh.client.logger.WithError(err).Error("读取请求体失败")
+		wh.logger.Error("读取请求体失败", ErrField(err))
 		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
@@ -64,7 +72,7 @@ func (wh *WebhookHandler) HandleRequest(w http.ResponseWriter, r *http.Request)
 
 	// 验证签名
 	if !wh.verifySignature(r, body) {
-		wh.client.logger.Error("Webhook签名验证失败")
+		wh.logger.Error("Webhook签名验证失败", F("remote_addr", r.RemoteAddr))
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -73,25 +81,25 @@ func (wh *WebhookHandler) HandleRequest(w http.ResponseWriter, r *http.Request)
 	if r.Header.Get("Content-Encoding") == "gzip" || r.Header.Get("Content-Encoding") == "deflate" {
 		body, err = wh.decompress(body)
 		if err != nil {
-			wh.client.logger.WithError(err).Error("解压数据失败")
+			wh.logger.Error("解压数据失败", ErrField(err))
 			http.Error(w, "Bad Request", http.StatusBadRequest)
 			return
 		}
 	}
 
-	wh.client.logger.Debugf("收到Webhook消息: %s", string(body))
+	wh.logger.Debug("收到Webhook消息", F("body", string(body)))
 
 	// 解析消息
 	var msg WebhookMessage
 	if err := json.Unmarshal(body, &msg); err != nil {
-		wh.client.logger.WithError(err).Error("解析Webhook消息失败")
+		wh.logger.Error("解析Webhook消息失败", ErrField(err))
 		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
 
 	// 处理消息
 	if err := wh.handleMessage(&msg); err != nil {
-		wh.client.logger.WithError(err).Error("处理Webhook消息失败")
+		wh.logger.Error("处理Webhook消息失败", ErrField(err), F("sn", msg.SN))
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
@@ -131,7 +139,7 @@ func (wh *WebhookHandler) verifySignature(r *http.Request, body []byte) bool {
 	}
 	
 	if time.Now().Unix()-ts > 300 {
-		wh.client.logger.Warn("Webhook时间戳过期")
+		wh.logger.Warn("Webhook时间戳过期", F("timestamp", ts))
 		return false
 	}
 
@@ -149,7 +157,7 @@ func (wh *WebhookHandler) verifySignature(r *http.Request, body []byte) bool {
 func (wh *WebhookHandler) handleMessage(msg *WebhookMessage) error {
 	// 如果是挑战请求，直接返回
 	if msg.Challenge != "" {
-		wh.client.logger.Info("收到Webhook验证挑战")
+		wh.logger.Info("收到Webhook验证挑战")
 		return nil
 	}
 
@@ -168,20 +176,14 @@ func (wh *WebhookHandler) handleEvent(msg *WebhookMessage) error {
 		return fmt.Errorf("解析事件失败: %w", err)
 	}
 
-	wh.client.logger.Debugf("收到Webhook事件: 类型=%d, 内容=%s", event.Type, event.Content)
+	wh.logger.Debug("收到Webhook事件",
+		F("event_type", event.Type),
+		F("target_id", event.TargetID),
+		F("msg_id", event.MsgID),
+	)
 
 	// 调用事件处理器
-	handlers := wh.eventHandlers[event.Type]
-	for _, handler := range handlers {
-		go func(h EventHandler) {
-			defer func() {
-				if r := recover(); r != nil {
-					wh.client.logger.Errorf("事件处理器发生panic: %v", r)
-				}
-			}()
-			h(&event)
-		}(handler)
-	}
+	dispatchEvent(wh.client, wh.logger, EventSourceWebhook, wh.eventHandlers[event.Type], &event, nil)
 
 	return nil
 }
@@ -201,6 +203,6 @@ func (wh *WebhookHandler) decompress(data []byte) ([]byte, error) {
 func (wh *WebhookHandler) StartWebhookServer(addr, path string) error {
 	http.HandleFunc(path, wh.HandleRequest)
 	
-	wh.client.logger.Infof("启动Webhook服务器: %s%s", addr, path)
+	wh.logger.Info("启动Webhook服务器", F("addr", addr), F("path", path))
 	return http.ListenAndServe(addr, nil)
 } 
\ No newline at end of file
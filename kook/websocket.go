@@ -5,10 +5,14 @@ import (
 	"compress/zlib"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -17,24 +21,130 @@ import (
 // EventHandler 事件处理器函数类型
 type EventHandler func(*Event)
 
+// 连接断开原因
+var (
+	errConnClosed         = errors.New("WebSocket连接已关闭")
+	errHelloTimeout       = errors.New("等待Hello消息超时")
+	errHeartbeatTimeout   = errors.New("心跳超时")
+	errReconnectRequested = errors.New("服务器要求重连")
+)
+
 // WebSocketClient WebSocket客户端
+//
+// 每次连接由独立的 gatewayConn 管理：读取协程、写入协程（所有出站消息经队列由它串行写出）
+// 以及收到 Hello 后启动的心跳协程。连接断开后由唯一的监督协程负责重连，
+// Close 返回时所有协程都已退出。
 type WebSocketClient struct {
-	client          *Client
-	conn            *websocket.Conn
-	eventHandlers   map[int][]EventHandler
-	mu              sync.RWMutex
-	ctx             context.Context
-	cancel          context.CancelFunc
-	compress        bool
-	sn              int
+	client   *Client
+	logger   Logger
+	compress bool
+
+	mu            sync.RWMutex
+	eventHandlers map[int][]EventHandler
+	streamSlot    hubSlot
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	started   atomic.Bool
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+
+	// 连接配置
+	maxReconnects     int
+	reconnectDelay    time.Duration
+	heartbeatInterval time.Duration
+	helloTimeout      time.Duration
+	maxMissedPongs    int
+	writeTimeout      time.Duration
+
+	connMu sync.RWMutex
+	conn   *gatewayConn
+
+	// 会话状态与持久化
+	sessionMu       sync.Mutex
+	sn              int              // 最后收到的消息序号
+	acked           int              // 处理器已全部返回的最后一个连续序号，保存到会话存储
+	inflight        []*inflightEvent // 按到达顺序排列的处理中事件
 	sessionID       string
-	heartbeatTicker *time.Ticker
-	gatewayURL      string
-	reconnectCount  int
-	maxReconnects   int
-	reconnectDelay  time.Duration
-	isConnected     bool
-	connMu          sync.RWMutex
+	resuming        bool
+	sessionStore    SessionStore
+	resumeWindow    time.Duration
+	saveInterval    time.Duration
+	lastSessionSave time.Time
+
+	scheduler *Scheduler
+}
+
+// inflightEvent 处理器尚未全部返回的事件
+type inflightEvent struct {
+	sn   int
+	done bool
+}
+
+// maxSN 网关消息序号的最大值，超过后从1重新计数
+const maxSN = 65535
+
+// snAfter 判断序号 sn 是否在 last 之后，考虑序号回绕
+func snAfter(sn, last int) bool {
+	if sn > last {
+		return true
+	}
+	return last-sn > maxSN/2
+}
+
+// WebSocketOption WebSocket客户端配置选项
+type WebSocketOption func(*WebSocketClient)
+
+// WithSessionStore 设置会话存储，连接时会尝试恢复存储中的会话并补发错过的事件
+func WithSessionStore(store SessionStore) WebSocketOption {
+	return func(ws *WebSocketClient) {
+		ws.sessionStore = store
+	}
+}
+
+// WithScheduler 将定时任务调度器绑定到网关生命周期：Connect 时启动，Close 时等待运行中的任务结束后停止
+func WithScheduler(scheduler *Scheduler) WebSocketOption {
+	return func(ws *WebSocketClient) {
+		ws.scheduler = scheduler
+	}
+}
+
+// WithResumeWindow 设置可恢复会话的最长时间，超过该时间的会话不再尝试恢复，默认5分钟
+func WithResumeWindow(window time.Duration) WebSocketOption {
+	return func(ws *WebSocketClient) {
+		ws.resumeWindow = window
+	}
+}
+
+// WithSessionSaveInterval 设置处理事件后保存会话的最小间隔，默认1秒
+//
+// 间隔内的序号在进程异常退出时可能丢失，恢复时这部分事件会被重新投递。
+func WithSessionSaveInterval(interval time.Duration) WebSocketOption {
+	return func(ws *WebSocketClient) {
+		ws.saveInterval = interval
+	}
+}
+
+// WithHeartbeatInterval 设置心跳间隔，默认30秒
+func WithHeartbeatInterval(interval time.Duration) WebSocketOption {
+	return func(ws *WebSocketClient) {
+		ws.heartbeatInterval = interval
+	}
+}
+
+// WithHelloTimeout 设置连接后等待Hello消息的超时时间，默认6秒
+func WithHelloTimeout(timeout time.Duration) WebSocketOption {
+	return func(ws *WebSocketClient) {
+		ws.helloTimeout = timeout
+	}
+}
+
+// WithReconnect 设置最大重连次数与重连间隔，第 n 次重试前等待 n 倍间隔，默认10次、5秒
+func WithReconnect(maxReconnects int, delay time.Duration) WebSocketOption {
+	return func(ws *WebSocketClient) {
+		ws.maxReconnects = maxReconnects
+		ws.reconnectDelay = delay
+	}
 }
 
 // WebSocketMessage WebSocket消息结构
@@ -78,18 +188,29 @@ const (
 )
 
 // NewWebSocketClient 创建新的WebSocket客户端
-func NewWebSocketClient(client *Client, compress bool) *WebSocketClient {
+func NewWebSocketClient(client *Client, compress bool, options ...WebSocketOption) *WebSocketClient {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &WebSocketClient{
-		client:         client,
-		eventHandlers:  make(map[int][]EventHandler),
-		ctx:            ctx,
-		cancel:         cancel,
-		compress:       compress,
-		maxReconnects:  10,
-		reconnectDelay: 5 * time.Second,
+	ws := &WebSocketClient{
+		client:            client,
+		logger:            client.Logger(LogSubsystemGateway),
+		eventHandlers:     make(map[int][]EventHandler),
+		ctx:               ctx,
+		cancel:            cancel,
+		compress:          compress,
+		maxReconnects:     10,
+		reconnectDelay:    5 * time.Second,
+		heartbeatInterval: 30 * time.Second,
+		helloTimeout:      6 * time.Second,
+		maxMissedPongs:    2,
+		writeTimeout:      10 * time.Second,
+		resumeWindow:      5 * time.Minute,
+		saveInterval:      time.Second,
+	}
+	for _, option := range options {
+		option(ws)
 	}
+	return ws
 }
 
 // OnEvent 注册事件处理器
@@ -100,37 +221,102 @@ func (ws *WebSocketClient) OnEvent(eventType int, handler EventHandler) {
 	ws.eventHandlers[eventType] = append(ws.eventHandlers[eventType], handler)
 }
 
+// streamHub 实现 hubSource，事件流共用同一组处理器
+func (ws *WebSocketClient) streamHub() *streamHub {
+	return ws.streamSlot.get(ws)
+}
+
 // Connect 连接到WebSocket网关
+//
+// 首次连接成功后返回，之后断线由后台自动重连。
+// 重连达到最大次数而放弃后，可以再次调用 Connect 重新连接。
 func (ws *WebSocketClient) Connect() error {
-	return ws.connectWithRetry()
+	if ws.ctx.Err() != nil {
+		return fmt.Errorf("WebSocket客户端已关闭")
+	}
+	if !ws.started.CompareAndSwap(false, true) {
+		return fmt.Errorf("WebSocket客户端已连接")
+	}
+	if ws.scheduler != nil {
+		if err := ws.scheduler.Start(); err != nil {
+			ws.started.Store(false)
+			return fmt.Errorf("启动定时任务调度器失败: %w", err)
+		}
+	}
+
+	conn, err := ws.connectWithRetry()
+	if err != nil {
+		if ws.scheduler != nil {
+			ws.scheduler.shutdown()
+		}
+		ws.started.Store(false)
+		return err
+	}
+
+	ws.wg.Add(1)
+	go ws.supervise(conn)
+	return nil
+}
+
+// supervise 等待连接断开后重连，是唯一触发重连的地方
+func (ws *WebSocketClient) supervise(conn *gatewayConn) {
+	defer ws.wg.Done()
+
+	for {
+		err := conn.wait()
+
+		ws.setConn(conn, nil)
+		ws.client.metrics.SetGauge(MetricGatewayConnected, 0, nil)
+
+		if ws.ctx.Err() != nil {
+			return
+		}
+
+		ws.logger.Warn("WebSocket连接断开，准备重连", ErrField(err))
+		ws.client.metrics.IncCounter(MetricGatewayReconnects, nil)
+
+		conn, err = ws.connectWithRetry()
+		if err != nil {
+			if ws.ctx.Err() == nil {
+				ws.logger.Error("已达到最大重连次数，停止重连", ErrField(err), F("max_reconnects", ws.maxReconnects))
+			}
+			// 允许调用方再次 Connect
+			ws.started.Store(false)
+			return
+		}
+		ws.logger.Info("重连成功")
+	}
 }
 
 // connectWithRetry 带重试的连接
-func (ws *WebSocketClient) connectWithRetry() error {
+func (ws *WebSocketClient) connectWithRetry() (*gatewayConn, error) {
 	for attempts := 0; attempts <= ws.maxReconnects; attempts++ {
-		err := ws.doConnect()
+		conn, err := ws.doConnect()
 		if err == nil {
-			ws.reconnectCount = 0
-			return nil
+			return conn, nil
+		}
+		if ws.ctx.Err() != nil {
+			return nil, ws.ctx.Err()
 		}
 
-		ws.client.logger.WithError(err).Errorf("WebSocket连接失败，尝试 %d/%d", attempts+1, ws.maxReconnects+1)
+		ws.logger.Error("WebSocket连接失败",
+			ErrField(err), F("attempt", attempts+1), F("max_attempts", ws.maxReconnects+1))
 
 		if attempts < ws.maxReconnects {
 			select {
 			case <-ws.ctx.Done():
-				return ws.ctx.Err()
+				return nil, ws.ctx.Err()
 			case <-time.After(ws.reconnectDelay * time.Duration(attempts+1)):
-				// 指数退避
+				// 线性退避
 			}
 		}
 	}
 
-	return fmt.Errorf("WebSocket连接失败，已达到最大重试次数")
+	return nil, fmt.Errorf("WebSocket连接失败，已达到最大重试次数")
 }
 
 // doConnect 执行实际连接
-func (ws *WebSocketClient) doConnect() error {
+func (ws *WebSocketClient) doConnect() (*gatewayConn, error) {
 	// 获取网关信息
 	compress := 0
 	if ws.compress {
@@ -139,174 +325,117 @@ func (ws *WebSocketClient) doConnect() error {
 
 	gateway, err := ws.client.Gateway.GetGateway(compress)
 	if err != nil {
-		return fmt.Errorf("获取网关信息失败: %w", err)
+		return nil, fmt.Errorf("获取网关信息失败: %w", err)
 	}
 
-	ws.gatewayURL = gateway.URL
+	gatewayURL := ws.resumeURL(gateway.URL)
 
 	// 创建WebSocket连接
 	header := http.Header{}
 	header.Set("Authorization", fmt.Sprintf("%s %s", ws.client.tokenType, ws.client.token))
 
-	ws.client.logger.Infof("连接到WebSocket网关: %s", gateway.URL)
+	ws.logger.Info("连接到WebSocket网关", F("url", redactURL(gatewayURL)))
 
-	conn, _, err := websocket.DefaultDialer.Dial(gateway.URL, header)
+	wsConn, _, err := websocket.DefaultDialer.DialContext(ws.ctx, gatewayURL, header)
 	if err != nil {
-		return fmt.Errorf("WebSocket连接失败: %w", err)
+		ws.client.metrics.IncCounter(MetricGatewayConnects, Labels{"result": "failure"})
+		return nil, fmt.Errorf("WebSocket连接失败: %w", err)
 	}
 
-	ws.connMu.Lock()
-	ws.conn = conn
-	ws.isConnected = true
-	ws.connMu.Unlock()
+	conn := newGatewayConn(ws, wsConn)
+	ws.setConn(nil, conn)
 
-	ws.client.logger.Info("WebSocket连接成功")
+	// 拨号期间可能已调用 Close
+	if ws.ctx.Err() != nil {
+		ws.setConn(conn, nil)
+		conn.close(errConnClosed)
+		return nil, ws.ctx.Err()
+	}
 
-	// 启动消息处理协程
-	go ws.handleMessages()
+	ws.client.metrics.IncCounter(MetricGatewayConnects, Labels{"result": "success"})
+	ws.client.metrics.SetGauge(MetricGatewayConnected, 1, nil)
 
-	return nil
-}
+	ws.logger.Info("WebSocket连接成功")
 
-// Close 关闭WebSocket连接
-func (ws *WebSocketClient) Close() error {
-	ws.cancel()
+	conn.start()
+	return conn, nil
+}
 
-	if ws.heartbeatTicker != nil {
-		ws.heartbeatTicker.Stop()
-	}
+// setConn 当前连接为 old 时替换为 conn
+func (ws *WebSocketClient) setConn(old, conn *gatewayConn) {
+	ws.connMu.Lock()
+	defer ws.connMu.Unlock()
 
-	if ws.conn != nil {
-		return ws.conn.Close()
+	if ws.conn == old {
+		ws.conn = conn
 	}
-
-	return nil
 }
 
-// handleMessages 处理WebSocket消息
-func (ws *WebSocketClient) handleMessages() {
-	defer func() {
-		if r := recover(); r != nil {
-			ws.client.logger.Errorf("WebSocket消息处理发生panic: %v", r)
-		}
-
-		// 标记连接已断开
-		ws.connMu.Lock()
-		ws.isConnected = false
-		ws.connMu.Unlock()
-
-		// 尝试重连
-		ws.attemptReconnect()
-	}()
-
-	for {
-		select {
-		case <-ws.ctx.Done():
-			return
-		default:
-			ws.connMu.RLock()
-			conn := ws.conn
-			ws.connMu.RUnlock()
-
-			if conn == nil {
-				ws.client.logger.Error("WebSocket连接为空")
-				return
-			}
-
-			_, data, err := conn.ReadMessage()
-			if err != nil {
-				ws.client.logger.WithError(err).Error("读取WebSocket消息失败")
-				return
-			}
-
-			// 如果启用了压缩，需要解压
-			if ws.compress {
-				data, err = ws.decompress(data)
-				if err != nil {
-					ws.client.logger.WithError(err).Error("解压消息失败")
-					continue
-				}
-			}
-
-			ws.client.logger.Debugf("收到WebSocket消息: %s", string(data))
-
-			var msg WebSocketMessage
-			if err := json.Unmarshal(data, &msg); err != nil {
-				ws.client.logger.WithError(err).Error("解析WebSocket消息失败")
-				continue
-			}
-
-			if err := ws.handleMessage(&msg); err != nil {
-				ws.client.logger.WithError(err).Error("处理WebSocket消息失败")
-			}
-		}
-	}
+// currentConn 获取当前连接
+func (ws *WebSocketClient) currentConn() *gatewayConn {
+	ws.connMu.RLock()
+	defer ws.connMu.RUnlock()
+	return ws.conn
 }
 
-// attemptReconnect 尝试重连
-func (ws *WebSocketClient) attemptReconnect() {
-	if ws.reconnectCount >= ws.maxReconnects {
-		ws.client.logger.Error("已达到最大重连次数，停止重连")
-		return
-	}
+// Close 关闭WebSocket连接，等待所有后台协程退出，可重复调用
+//
+// 通过 WithScheduler 绑定了调度器时，随后停止调度器并等待运行中的任务结束。
+func (ws *WebSocketClient) Close() error {
+	ws.closeOnce.Do(func() {
+		ws.cancel()
+		ws.saveSession(true)
 
-	ws.reconnectCount++
-	ws.client.logger.Infof("开始第 %d 次重连尝试", ws.reconnectCount)
+		if conn := ws.currentConn(); conn != nil {
+			conn.closeGracefully()
+		}
+	})
 
-	// 等待一段时间后重连
-	time.Sleep(ws.reconnectDelay * time.Duration(ws.reconnectCount))
+	ws.wg.Wait()
 
-	err := ws.doConnect()
-	if err != nil {
-		ws.client.logger.WithError(err).Errorf("重连失败")
-		// 递归尝试重连
-		go ws.attemptReconnect()
-	} else {
-		ws.client.logger.Info("重连成功")
-		ws.reconnectCount = 0
+	if ws.scheduler != nil {
+		return ws.scheduler.shutdown()
 	}
+	return nil
 }
 
 // IsConnected 检查连接状态
 func (ws *WebSocketClient) IsConnected() bool {
-	ws.connMu.RLock()
-	defer ws.connMu.RUnlock()
-	return ws.isConnected
+	conn := ws.currentConn()
+	return conn != nil && !conn.closed()
+}
+
+// SessionID 获取当前会话ID
+func (ws *WebSocketClient) SessionID() string {
+	ws.sessionMu.Lock()
+	defer ws.sessionMu.Unlock()
+	return ws.sessionID
 }
 
 // handleMessage 处理单个WebSocket消息
-func (ws *WebSocketClient) handleMessage(msg *WebSocketMessage) error {
+func (ws *WebSocketClient) handleMessage(conn *gatewayConn, msg *WebSocketMessage) error {
 	switch msg.S {
 	case SignalEvent:
 		// 处理事件
 		return ws.handleEvent(msg)
 	case SignalHello:
 		// 处理Hello消息
-		return ws.handleHello(msg)
+		return ws.handleHello(conn, msg)
 	case SignalPing:
 		// 处理Ping消息
-		return ws.handlePing(msg)
+		return ws.handlePing(conn, msg)
+	case SignalPong:
+		// 处理Pong消息
+		ws.handlePong(conn, msg)
+		return nil
 	case SignalReconnect:
 		// 处理重连消息
-		return ws.handleReconnect(msg)
+		return ws.handleReconnect(conn)
 	case SignalResumeAck:
 		// 处理重连确认消息
-		return ws.handleResumeAck(msg)
-	case SignalPong:
-		// 处理Pong消息
-		var pong PongMessage
-		if msg.D != nil {
-			if err := json.Unmarshal(msg.D, &pong); err != nil {
-				ws.client.logger.WithError(err).Debug("解析Pong消息失败，可能是空的Pong")
-			} else {
-				ws.client.logger.Debugf("收到Pong响应，SN: %d", pong.SN)
-			}
-		} else {
-			ws.client.logger.Debug("收到Pong响应")
-		}
-		return nil
+		return ws.handleResumeAck()
 	default:
-		ws.client.logger.Warnf("收到未知信令类型: %d", msg.S)
+		ws.logger.Warn("收到未知信令类型", F("signal", msg.S))
 	}
 
 	return nil
@@ -319,150 +448,235 @@ func (ws *WebSocketClient) handleEvent(msg *WebSocketMessage) error {
 		return fmt.Errorf("解析事件失败: %w", err)
 	}
 
+	// 恢复会话后网关可能重发已处理过的事件
+	ws.sessionMu.Lock()
+	if ws.sn > 0 && !snAfter(msg.SN, ws.sn) {
+		last := ws.sn
+		ws.sessionMu.Unlock()
+		ws.logger.Debug("丢弃重复事件", F("sn", msg.SN), F("last_sn", last))
+		return nil
+	}
 	ws.sn = msg.SN
-	ws.client.logger.Debugf("收到事件: 类型=%d, 内容=%s", event.Type, event.Content)
-
-	// 调用事件处理器
+	pending := &inflightEvent{sn: msg.SN}
+	ws.inflight = append(ws.inflight, pending)
+	ws.sessionMu.Unlock()
+
+	ws.logger.Debug("收到事件",
+		F("sn", msg.SN),
+		F("event_type", event.Type),
+		F("target_id", event.TargetID),
+		F("msg_id", event.MsgID),
+	)
+
+	// 复制处理器列表，避免与 OnEvent 并发修改
 	ws.mu.RLock()
-	handlers := ws.eventHandlers[event.Type]
+	handlers := append([]EventHandler(nil), ws.eventHandlers[event.Type]...)
 	ws.mu.RUnlock()
 
-	for _, handler := range handlers {
-		go func(h EventHandler) {
-			defer func() {
-				if r := recover(); r != nil {
-					ws.client.logger.Errorf("事件处理器发生panic: %v", r)
-				}
-			}()
-			h(&event)
-		}(handler)
-	}
+	dispatchEvent(ws.client, ws.logger, EventSourceGateway, handlers, &event, func() {
+		ws.ackEvent(pending)
+	})
 
 	return nil
 }
 
+// ackEvent 在事件的处理器全部返回后推进已确认序号并保存会话
+//
+// 只有之前的事件也都处理完毕时序号才会前进，重启后从已确认序号恢复，处理中的事件会被重新投递。
+func (ws *WebSocketClient) ackEvent(pending *inflightEvent) {
+	ws.sessionMu.Lock()
+	pending.done = true
+	for len(ws.inflight) > 0 && ws.inflight[0].done {
+		ws.acked = ws.inflight[0].sn
+		ws.inflight = ws.inflight[1:]
+	}
+	ws.sessionMu.Unlock()
+
+	ws.saveSession(false)
+}
+
+// resetSN 会话失效时清空序号，调用方需持有 sessionMu
+func (ws *WebSocketClient) resetSN() {
+	ws.sn = 0
+	ws.acked = 0
+	ws.inflight = nil
+}
+
 // handleHello 处理Hello消息
-func (ws *WebSocketClient) handleHello(msg *WebSocketMessage) error {
+func (ws *WebSocketClient) handleHello(conn *gatewayConn, msg *WebSocketMessage) error {
 	var hello HelloMessage
 	if err := json.Unmarshal(msg.D, &hello); err != nil {
 		return fmt.Errorf("解析Hello消息失败: %w", err)
 	}
 
-	ws.sessionID = hello.SessionID
-	ws.client.logger.Infof("WebSocket会话建立成功: %s", ws.sessionID)
+	ws.sessionMu.Lock()
+	resuming := ws.resuming
+	ws.resuming = false
+	if hello.Code != 0 {
+		// 会话无法恢复时丢弃旧会话，重连后建立新会话
+		ws.sessionID = ""
+		ws.resetSN()
+	} else {
+		ws.sessionID = hello.SessionID
+	}
+	ws.sessionMu.Unlock()
+
+	if hello.Code != 0 {
+		if resuming {
+			ws.clearSession()
+		}
+		err := fmt.Errorf("网关握手失败，错误码: %d", hello.Code)
+		conn.close(err)
+		return err
+	}
+
+	if resuming {
+		ws.logger.Info("WebSocket会话恢复成功", F("session_id", hello.SessionID))
+	} else {
+		ws.logger.Info("WebSocket会话建立成功", F("session_id", hello.SessionID))
+	}
+	ws.saveSession(true)
 
 	// 启动心跳
-	ws.startHeartbeat()
+	conn.startHeartbeat()
 
 	return nil
 }
 
 // handlePing 处理Ping消息
-func (ws *WebSocketClient) handlePing(msg *WebSocketMessage) error {
+func (ws *WebSocketClient) handlePing(conn *gatewayConn, msg *WebSocketMessage) error {
 	var ping PingMessage
 	if err := json.Unmarshal(msg.D, &ping); err != nil {
 		return fmt.Errorf("解析Ping消息失败: %w", err)
 	}
 
 	// 发送Pong响应
-	pong := WebSocketMessage{
-		S: SignalPong,
-		D: nil,
-	}
-
 	pongData, _ := json.Marshal(PongMessage{SN: ping.SN})
-	pong.D = pongData
-
-	return ws.sendMessage(&pong)
+	return conn.send(&WebSocketMessage{S: SignalPong, D: pongData})
 }
 
-// handleReconnect 处理重连消息
-func (ws *WebSocketClient) handleReconnect(msg *WebSocketMessage) error {
-	ws.client.logger.Warn("服务器要求重连")
-
-	// 发送Resume消息
-	resume := WebSocketMessage{
-		S: SignalResume,
+// handlePong 处理Pong消息
+func (ws *WebSocketClient) handlePong(conn *gatewayConn, msg *WebSocketMessage) {
+	var pong PongMessage
+	if len(msg.D) > 0 {
+		if err := json.Unmarshal(msg.D, &pong); err != nil {
+			ws.logger.Debug("解析Pong消息失败，可能是空的Pong", ErrField(err))
+		}
 	}
+	ws.logger.Debug("收到Pong响应", F("sn", pong.SN))
 
-	resumeData, _ := json.Marshal(ResumeMessage{
-		SessionID: ws.sessionID,
-		SN:        ws.sn,
-	})
-	resume.D = resumeData
+	if sentAt := conn.lastPingAt.Swap(0); sentAt > 0 {
+		ws.client.metrics.ObserveDuration(MetricGatewayHeartbeat, time.Since(time.Unix(0, sentAt)), nil)
+	}
+	conn.missedPongs.Store(0)
+}
 
-	return ws.sendMessage(&resume)
+// handleReconnect 处理重连消息
+//
+// 服务端下发重连信令表示当前会话已失效，需要清空 sn 与会话后重新连接，
+// 关闭连接后由监督协程重连。
+func (ws *WebSocketClient) handleReconnect(conn *gatewayConn) error {
+	ws.logger.Warn("服务器要求重连，丢弃当前会话")
+
+	ws.sessionMu.Lock()
+	ws.sessionID = ""
+	ws.resetSN()
+	ws.sessionMu.Unlock()
+	ws.clearSession()
+
+	conn.close(errReconnectRequested)
+	return nil
 }
 
 // handleResumeAck 处理重连确认消息
-func (ws *WebSocketClient) handleResumeAck(msg *WebSocketMessage) error {
-	ws.client.logger.Info("重连成功")
+func (ws *WebSocketClient) handleResumeAck() error {
+	ws.logger.Info("重连成功", F("session_id", ws.SessionID()))
+	ws.saveSession(true)
 	return nil
 }
 
-// startHeartbeat 启动心跳
-func (ws *WebSocketClient) startHeartbeat() {
-	// 每30秒发送一次心跳
-	ws.heartbeatTicker = time.NewTicker(30 * time.Second)
-
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				ws.client.logger.Errorf("心跳处理发生panic: %v", r)
+// resumeURL 存在可恢复的会话时，在网关地址上附加恢复参数
+func (ws *WebSocketClient) resumeURL(gatewayURL string) string {
+	ws.sessionMu.Lock()
+	defer ws.sessionMu.Unlock()
+
+	ws.resuming = false
+
+	// 本进程内没有会话时从存储中读取
+	if ws.sessionID == "" && ws.sessionStore != nil {
+		session, err := ws.sessionStore.Load()
+		if err != nil {
+			ws.logger.Warn("读取网关会话失败", ErrField(err))
+		} else if session != nil && session.SessionID != "" {
+			if ws.resumeWindow > 0 && time.Since(session.UpdatedAt) > ws.resumeWindow {
+				ws.logger.Info("网关会话已过期，建立新会话",
+					F("session_id", session.SessionID), F("updated_at", session.UpdatedAt))
+			} else {
+				ws.sessionID = session.SessionID
+				ws.sn = session.SN
+				ws.acked = session.SN
 			}
-		}()
-
-		consecutiveFailures := 0
-		const maxFailures = 3
+		}
+	}
 
-		for {
-			select {
-			case <-ws.ctx.Done():
-				return
-			case <-ws.heartbeatTicker.C:
-				ping := WebSocketMessage{
-					S: SignalPing,
-				}
+	if ws.sessionID == "" {
+		return gatewayURL
+	}
 
-				pingData, _ := json.Marshal(PingMessage{SN: ws.sn})
-				ping.D = pingData
-
-				if err := ws.sendMessage(&ping); err != nil {
-					consecutiveFailures++
-					ws.client.logger.WithError(err).Errorf("发送心跳失败 (%d/%d)", consecutiveFailures, maxFailures)
-
-					if consecutiveFailures >= maxFailures {
-						ws.client.logger.Error("连续心跳失败，触发重连")
-						ws.connMu.Lock()
-						ws.isConnected = false
-						if ws.conn != nil {
-							ws.conn.Close()
-						}
-						ws.connMu.Unlock()
-						go ws.attemptReconnect()
-						return
-					}
-				} else {
-					if consecutiveFailures > 0 {
-						ws.client.logger.Info("心跳恢复正常")
-					}
-					consecutiveFailures = 0
-				}
-			}
-		}
-	}()
+	u, err := url.Parse(gatewayURL)
+	if err != nil {
+		return gatewayURL
+	}
+	query := u.Query()
+	query.Set("resume", "1")
+	query.Set("sn", strconv.Itoa(ws.sn))
+	query.Set("session_id", ws.sessionID)
+	u.RawQuery = query.Encode()
+
+	ws.resuming = true
+	ws.logger.Info("尝试恢复网关会话", F("session_id", ws.sessionID), F("sn", ws.sn))
+	return u.String()
 }
 
-// sendMessage 发送WebSocket消息
-func (ws *WebSocketClient) sendMessage(msg *WebSocketMessage) error {
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("序列化消息失败: %w", err)
+// saveSession 保存会话，force 为 false 时按保存间隔节流
+func (ws *WebSocketClient) saveSession(force bool) {
+	if ws.sessionStore == nil {
+		return
 	}
 
-	ws.client.logger.Debugf("发送WebSocket消息: %s", string(data))
+	ws.sessionMu.Lock()
+	if ws.sessionID == "" || (!force && time.Since(ws.lastSessionSave) < ws.saveInterval) {
+		ws.sessionMu.Unlock()
+		return
+	}
+	session := &GatewaySession{
+		SessionID: ws.sessionID,
+		SN:        ws.acked,
+		UpdatedAt: time.Now(),
+	}
+	ws.lastSessionSave = session.UpdatedAt
+	ws.sessionMu.Unlock()
+
+	if err := ws.sessionStore.Save(session); err != nil {
+		ws.logger.Warn("保存网关会话失败", ErrField(err))
+	}
+}
 
-	return ws.conn.WriteMessage(websocket.TextMessage, data)
+// clearSession 清除存储中的会话
+func (ws *WebSocketClient) clearSession() {
+	if ws.sessionStore == nil {
+		return
+	}
+	if err := ws.sessionStore.Clear(); err != nil {
+		ws.logger.Warn("清除网关会话失败", ErrField(err))
+	}
+}
+
+// currentSN 获取最后处理的消息序号
+func (ws *WebSocketClient) currentSN() int {
+	ws.sessionMu.Lock()
+	defer ws.sessionMu.Unlock()
+	return ws.sn
 }
 
 // decompress 解压数据
@@ -475,3 +689,204 @@ func (ws *WebSocketClient) decompress(data []byte) ([]byte, error) {
 
 	return io.ReadAll(r)
 }
+
+// gatewayConn 单次网关连接
+//
+// 连接的读取、写入和心跳协程在 done 关闭后退出，wait 返回时它们都已结束。
+type gatewayConn struct {
+	ws   *WebSocketClient
+	conn *websocket.Conn
+
+	outbound chan []byte
+	done     chan struct{}
+	once     sync.Once
+	err      error
+	wg       sync.WaitGroup
+
+	helloTimer    *time.Timer
+	heartbeatOnce sync.Once
+	lastPingAt    atomic.Int64
+	missedPongs   atomic.Int32
+}
+
+func newGatewayConn(ws *WebSocketClient, conn *websocket.Conn) *gatewayConn {
+	c := &gatewayConn{
+		ws:       ws,
+		conn:     conn,
+		outbound: make(chan []byte, 64),
+		done:     make(chan struct{}),
+	}
+	// 超时未收到Hello时断开
+	c.helloTimer = time.AfterFunc(ws.helloTimeout, func() {
+		c.close(errHelloTimeout)
+	})
+	return c
+}
+
+// start 启动读写协程
+func (c *gatewayConn) start() {
+	c.wg.Add(2)
+	go c.writeLoop()
+	go c.readLoop()
+}
+
+// close 关闭连接并记录原因，只有第一次调用生效
+func (c *gatewayConn) close(err error) {
+	c.once.Do(func() {
+		c.err = err
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// closeGracefully 发送关闭帧后关闭连接
+func (c *gatewayConn) closeGracefully() {
+	// WriteControl 可以与写入协程并发调用
+	c.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second))
+	c.close(errConnClosed)
+}
+
+// closed 判断连接是否已关闭
+func (c *gatewayConn) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// wait 等待连接关闭且所有协程退出，返回断开原因
+func (c *gatewayConn) wait() error {
+	<-c.done
+	c.wg.Wait()
+	return c.err
+}
+
+// send 将消息放入出站队列，由写入协程发送
+func (c *gatewayConn) send(msg *WebSocketMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %w", err)
+	}
+
+	select {
+	case c.outbound <- data:
+		return nil
+	case <-c.done:
+		return errConnClosed
+	}
+}
+
+// writeLoop 写入协程，是唯一向连接写入数据的地方
+func (c *gatewayConn) writeLoop() {
+	defer c.wg.Done()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case data := <-c.outbound:
+			c.ws.logger.Debug("发送WebSocket消息", F("data", string(data)))
+
+			c.conn.SetWriteDeadline(time.Now().Add(c.ws.writeTimeout))
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				if !c.closed() {
+					c.ws.logger.Error("发送WebSocket消息失败", ErrField(err))
+				}
+				c.close(err)
+				return
+			}
+		}
+	}
+}
+
+// readLoop 读取协程，按顺序处理收到的消息
+func (c *gatewayConn) readLoop() {
+	defer c.wg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			c.ws.logger.Error("WebSocket消息处理发生panic", F("panic", r))
+			c.close(fmt.Errorf("WebSocket消息处理发生panic: %v", r))
+		}
+	}()
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if !c.closed() {
+				c.ws.logger.Error("读取WebSocket消息失败", ErrField(err))
+			}
+			c.close(err)
+			return
+		}
+
+		// 如果启用了压缩，需要解压
+		if c.ws.compress {
+			data, err = c.ws.decompress(data)
+			if err != nil {
+				c.ws.logger.Error("解压消息失败", ErrField(err))
+				continue
+			}
+		}
+
+		c.ws.logger.Debug("收到WebSocket消息", F("data", string(data)))
+
+		var msg WebSocketMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.ws.logger.Error("解析WebSocket消息失败", ErrField(err))
+			continue
+		}
+
+		if msg.S == SignalHello {
+			c.helloTimer.Stop()
+		}
+
+		if err := c.ws.handleMessage(c, &msg); err != nil {
+			c.ws.logger.Error("处理WebSocket消息失败", ErrField(err), F("signal", msg.S))
+		}
+	}
+}
+
+// startHeartbeat 启动心跳协程，每个连接只启动一次
+func (c *gatewayConn) startHeartbeat() {
+	c.heartbeatOnce.Do(func() {
+		// 由读取协程调用，此时 wait 还不会返回
+		c.wg.Add(1)
+		go c.heartbeatLoop()
+	})
+}
+
+// heartbeatLoop 定时发送Ping，连续多次未收到Pong时断开连接
+func (c *gatewayConn) heartbeatLoop() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.ws.heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			// 上一次Ping还没有收到Pong
+			if c.lastPingAt.Load() != 0 {
+				missed := c.missedPongs.Add(1)
+				c.ws.logger.Warn("未收到心跳响应", F("missed", missed), F("max_missed", c.ws.maxMissedPongs))
+				if int(missed) >= c.ws.maxMissedPongs {
+					c.ws.logger.Error("心跳超时，触发重连")
+					c.close(errHeartbeatTimeout)
+					return
+				}
+			}
+
+			pingData, _ := json.Marshal(PingMessage{SN: c.ws.currentSN()})
+			c.lastPingAt.Store(time.Now().UnixNano())
+			if err := c.send(&WebSocketMessage{S: SignalPing, D: pingData}); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package kook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTimeout = 3 * time.Second
+
+// fakeGateway 模拟KOOK网关，每个接入的连接都会放入 conns
+type fakeGateway struct {
+	srv   *httptest.Server
+	conns chan *fakeConn
+
+	mu     sync.Mutex
+	opened []*fakeConn
+}
+
+// fakeConn 网关侧的单个连接
+type fakeConn struct {
+	conn  *websocket.Conn
+	query url.Values
+
+	writeMu sync.Mutex
+}
+
+func (fc *fakeConn) write(data []byte) error {
+	fc.writeMu.Lock()
+	defer fc.writeMu.Unlock()
+	return fc.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func newFakeGateway(t *testing.T) *fakeGateway {
+	t.Helper()
+
+	g := &fakeGateway{conns: make(chan *fakeConn, 16)}
+	upgrader := websocket.Upgrader{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/gateway/index", func(w http.ResponseWriter, r *http.Request) {
+		wsURL := "ws" + strings.TrimPrefix(g.srv.URL, "http") + "/ws?compress=0"
+		fmt.Fprintf(w, `{"code":0,"message":"","data":{"url":%q}}`, wsURL)
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		fc := &fakeConn{conn: conn, query: r.URL.Query()}
+		g.mu.Lock()
+		g.opened = append(g.opened, fc)
+		g.mu.Unlock()
+		g.conns <- fc
+	})
+	g.srv = httptest.NewServer(mux)
+
+	t.Cleanup(func() {
+		g.mu.Lock()
+		for _, fc := range g.opened {
+			fc.conn.Close()
+		}
+		g.mu.Unlock()
+		g.srv.Close()
+	})
+	return g
+}
+
+func (g *fakeGateway) client(t *testing.T, options ...WebSocketOption) *WebSocketClient {
+	t.Helper()
+
+	client := NewClient("test-token",
+		WithBaseURL(g.srv.URL),
+		WithoutRateLimit(),
+		WithoutRetry(),
+		WithLogLevel(LogLevelOff),
+	)
+	options = append([]WebSocketOption{WithReconnect(3, 10*time.Millisecond)}, options...)
+	ws := NewWebSocketClient(client, false, options...)
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+func (g *fakeGateway) accept(t *testing.T) *fakeConn {
+	t.Helper()
+
+	select {
+	case fc := <-g.conns:
+		return fc
+	case <-time.After(testTimeout):
+		t.Fatal("等待客户端连接超时")
+		return nil
+	}
+}
+
+func (fc *fakeConn) send(t *testing.T, signal int, data interface{}, sn int) {
+	t.Helper()
+
+	raw, err := json.Marshal(data)
+	require.NoError(t, err)
+	msg, err := json.Marshal(WebSocketMessage{S: signal, D: raw, SN: sn})
+	require.NoError(t, err)
+	require.NoError(t, fc.write(msg))
+}
+
+func (fc *fakeConn) hello(t *testing.T, sessionID string) {
+	t.Helper()
+	fc.send(t, SignalHello, HelloMessage{SessionID: sessionID}, 0)
+}
+
+func (fc *fakeConn) read(t *testing.T) WebSocketMessage {
+	t.Helper()
+
+	fc.conn.SetReadDeadline(time.Now().Add(testTimeout))
+	_, data, err := fc.conn.ReadMessage()
+	require.NoError(t, err)
+
+	var msg WebSocketMessage
+	require.NoError(t, json.Unmarshal(data, &msg))
+	return msg
+}
+
+func TestWebSocketClientDispatchesEvents(t *testing.T) {
+	g := newFakeGateway(t)
+	ws := g.client(t)
+
+	events := make(chan *Event, 1)
+	ws.OnEvent(1, func(event *Event) {
+		events <- event
+	})
+
+	require.NoError(t, ws.Connect())
+	fc := g.accept(t)
+	fc.hello(t, "session-1")
+	fc.send(t, SignalEvent, Event{Type: 1, TargetID: "channel", Content: "hello", MsgID: "msg-1"}, 1)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "hello", event.Content)
+		assert.Equal(t, "msg-1", event.MsgID)
+	case <-time.After(testTimeout):
+		t.Fatal("未收到事件")
+	}
+	assert.True(t, ws.IsConnected())
+	assert.Equal(t, "session-1", ws.SessionID())
+}
+
+func TestWebSocketClientRespondsToPing(t *testing.T) {
+	g := newFakeGateway(t)
+	ws := g.client(t)
+
+	require.NoError(t, ws.Connect())
+	fc := g.accept(t)
+	fc.hello(t, "session-1")
+	fc.send(t, SignalPing, PingMessage{SN: 7}, 0)
+
+	msg := fc.read(t)
+	require.Equal(t, SignalPong, msg.S)
+	var pong PongMessage
+	require.NoError(t, json.Unmarshal(msg.D, &pong))
+	assert.Equal(t, 7, pong.SN)
+}
+
+func TestWebSocketClientSerializesWrites(t *testing.T) {
+	g := newFakeGateway(t)
+	ws := g.client(t, WithHeartbeatInterval(time.Millisecond))
+
+	require.NoError(t, ws.Connect())
+	fc := g.accept(t)
+	fc.hello(t, "session-1")
+
+	// 心跳协程与读取协程同时产生出站消息，写入必须串行
+	const pings = 50
+	go func() {
+		for i := 1; i <= pings; i++ {
+			raw, _ := json.Marshal(PingMessage{SN: i})
+			msg, _ := json.Marshal(WebSocketMessage{S: SignalPing, D: raw})
+			if fc.write(msg) != nil {
+				return
+			}
+		}
+	}()
+
+	pongs := 0
+	for pongs < pings {
+		msg := fc.read(t)
+		switch msg.S {
+		case SignalPong:
+			pongs++
+		case SignalPing:
+			// 客户端心跳，回应后继续
+			require.NoError(t, fc.write([]byte(`{"s":3,"d":{}}`)))
+		default:
+			t.Fatalf("收到意外的信令 %d", msg.S)
+		}
+	}
+}
+
+func TestWebSocketClientHeartbeat(t *testing.T) {
+	g := newFakeGateway(t)
+	ws := g.client(t, WithHeartbeatInterval(20*time.Millisecond))
+
+	require.NoError(t, ws.Connect())
+	fc := g.accept(t)
+	fc.hello(t, "session-1")
+	fc.send(t, SignalEvent, Event{Type: 1}, 4)
+
+	for i := 0; i < 3; i++ {
+		msg := fc.read(t)
+		require.Equal(t, SignalPing, msg.S)
+		var ping PingMessage
+		require.NoError(t, json.Unmarshal(msg.D, &ping))
+		assert.Equal(t, 4, ping.SN)
+		fc.send(t, SignalPong, PongMessage{SN: ping.SN}, 0)
+	}
+	assert.True(t, ws.IsConnected())
+}
+
+func TestWebSocketClientReconnectsOnHeartbeatTimeout(t *testing.T) {
+	g := newFakeGateway(t)
+	ws := g.client(t, WithHeartbeatInterval(10*time.Millisecond))
+
+	require.NoError(t, ws.Connect())
+	first := g.accept(t)
+	first.hello(t, "session-1")
+
+	// 不回应Pong，客户端应断开并恢复会话
+	second := g.accept(t)
+	assert.Equal(t, "1", second.query.Get("resume"))
+	assert.Equal(t, "session-1", second.query.Get("session_id"))
+}
+
+func TestWebSocketClientReconnectsOnHelloTimeout(t *testing.T) {
+	g := newFakeGateway(t)
+	ws := g.client(t, WithHelloTimeout(20*time.Millisecond))
+
+	require.NoError(t, ws.Connect())
+	g.accept(t)
+
+	second := g.accept(t)
+	second.hello(t, "session-1")
+	require.Eventually(t, func() bool {
+		return ws.SessionID() == "session-1"
+	}, testTimeout, 5*time.Millisecond)
+}
+
+func TestWebSocketClientResumesAfterDisconnect(t *testing.T) {
+	g := newFakeGateway(t)
+	ws := g.client(t)
+
+	handled := make(chan struct{}, 1)
+	ws.OnEvent(1, func(*Event) { handled <- struct{}{} })
+
+	require.NoError(t, ws.Connect())
+	first := g.accept(t)
+	assert.Empty(t, first.query.Get("resume"))
+	first.hello(t, "session-1")
+	first.send(t, SignalEvent, Event{Type: 1}, 5)
+	<-handled
+	first.conn.Close()
+
+	second := g.accept(t)
+	assert.Equal(t, "1", second.query.Get("resume"))
+	assert.Equal(t, "5", second.query.Get("sn"))
+	assert.Equal(t, "session-1", second.query.Get("session_id"))
+}
+
+func TestWebSocketClientResumesFromStore(t *testing.T) {
+	store := NewMemorySessionStore()
+	require.NoError(t, store.Save(&GatewaySession{SessionID: "stored", SN: 42, UpdatedAt: time.Now()}))
+
+	g := newFakeGateway(t)
+	ws := g.client(t, WithSessionStore(store))
+
+	require.NoError(t, ws.Connect())
+	fc := g.accept(t)
+	assert.Equal(t, "1", fc.query.Get("resume"))
+	assert.Equal(t, "42", fc.query.Get("sn"))
+	assert.Equal(t, "stored", fc.query.Get("session_id"))
+}
+
+func TestWebSocketClientIgnoresExpiredSession(t *testing.T) {
+	store := NewMemorySessionStore()
+	require.NoError(t, store.Save(&GatewaySession{SessionID: "stored", SN: 42, UpdatedAt: time.Now().Add(-time.Hour)}))
+
+	g := newFakeGateway(t)
+	ws := g.client(t, WithSessionStore(store))
+
+	require.NoError(t, ws.Connect())
+	fc := g.accept(t)
+	assert.Empty(t, fc.query.Get("resume"))
+}
+
+func TestWebSocketClientReconnectSignalClearsSession(t *testing.T) {
+	store := NewMemorySessionStore()
+
+	g := newFakeGateway(t)
+	ws := g.client(t, WithSessionStore(store))
+
+	require.NoError(t, ws.Connect())
+	first := g.accept(t)
+	first.hello(t, "session-1")
+	require.Eventually(t, func() bool {
+		session, _ := store.Load()
+		return session != nil && session.SessionID == "session-1"
+	}, testTimeout, 5*time.Millisecond)
+
+	first.send(t, SignalReconnect, map[string]interface{}{"code": 41008}, 0)
+
+	second := g.accept(t)
+	assert.Empty(t, second.query.Get("resume"))
+	session, err := store.Load()
+	require.NoError(t, err)
+	assert.Nil(t, session)
+}
+
+func TestWebSocketClientHelloErrorDropsSession(t *testing.T) {
+	store := NewMemorySessionStore()
+	require.NoError(t, store.Save(&GatewaySession{SessionID: "stale", SN: 3, UpdatedAt: time.Now()}))
+
+	g := newFakeGateway(t)
+	ws := g.client(t, WithSessionStore(store))
+
+	require.NoError(t, ws.Connect())
+	first := g.accept(t)
+	assert.Equal(t, "stale", first.query.Get("session_id"))
+	first.send(t, SignalHello, HelloMessage{Code: 40106}, 0)
+
+	second := g.accept(t)
+	assert.Empty(t, second.query.Get("resume"))
+	session, err := store.Load()
+	require.NoError(t, err)
+	assert.Nil(t, session)
+}
+
+func TestWebSocketClientClose(t *testing.T) {
+	g := newFakeGateway(t)
+	ws := g.client(t)
+
+	require.NoError(t, ws.Connect())
+	assert.Error(t, ws.Connect())
+
+	fc := g.accept(t)
+	fc.hello(t, "session-1")
+	require.Eventually(t, ws.IsConnected, testTimeout, 5*time.Millisecond)
+
+	require.NoError(t, ws.Close())
+	require.NoError(t, ws.Close())
+	assert.False(t, ws.IsConnected())
+	assert.Error(t, ws.Connect())
+
+	// 关闭后不应再重连
+	select {
+	case <-g.conns:
+		t.Fatal("关闭后仍在重连")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWebSocketClientConcurrentOnEvent(t *testing.T) {
+	g := newFakeGateway(t)
+	ws := g.client(t)
+
+	require.NoError(t, ws.Connect())
+	fc := g.accept(t)
+	fc.hello(t, "session-1")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			ws.OnEvent(1, func(*Event) {})
+		}
+	}()
+	for sn := 1; sn <= 100; sn++ {
+		fc.send(t, SignalEvent, Event{Type: 1}, sn)
+	}
+	wg.Wait()
+}
+
+func TestWebSocketClientDropsDuplicateEvents(t *testing.T) {
+	g := newFakeGateway(t)
+	ws := g.client(t)
+
+	handled := make(chan int, 8)
+	ws.OnEvent(1, func(event *Event) { handled <- int(event.MsgTimestamp) })
+
+	require.NoError(t, ws.Connect())
+	fc := g.accept(t)
+	fc.hello(t, "session-1")
+	for _, sn := range []int{1, 2, 2, 1, 3} {
+		fc.send(t, SignalEvent, Event{Type: 1, MsgTimestamp: int64(sn)}, sn)
+	}
+
+	var got []int
+	for len(got) < 3 {
+		select {
+		case sn := <-handled:
+			got = append(got, sn)
+		case <-time.After(testTimeout):
+			t.Fatalf("只处理了 %v", got)
+		}
+	}
+	assert.ElementsMatch(t, []int{1, 2, 3}, got)
+	select {
+	case sn := <-handled:
+		t.Fatalf("重复事件 %d 被处理", sn)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWebSocketClientSavesSNAfterHandlers(t *testing.T) {
+	store := NewMemorySessionStore()
+	g := newFakeGateway(t)
+	ws := g.client(t, WithSessionStore(store), WithSessionSaveInterval(0))
+
+	release := make(chan struct{})
+	ws.OnEvent(1, func(event *Event) {
+		if event.MsgTimestamp == 1 {
+			<-release
+		}
+	})
+
+	require.NoError(t, ws.Connect())
+	fc := g.accept(t)
+	fc.hello(t, "session-1")
+	fc.send(t, SignalEvent, Event{Type: 1, MsgTimestamp: 1}, 1)
+	fc.send(t, SignalEvent, Event{Type: 1, MsgTimestamp: 2}, 2)
+
+	// 第2个事件已处理完，但第1个仍在处理中，保存的序号不能前进
+	time.Sleep(50 * time.Millisecond)
+	session, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 0, session.SN)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		session, err := store.Load()
+		return err == nil && session.SN == 2
+	}, testTimeout, 5*time.Millisecond)
+}
@@ -0,0 +1,375 @@
+package wizard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"kook-go-sdk/kook"
+	"kook-go-sdk/kook/internal/keylock"
+)
+
+// Manager 对话管理器，接收用户私聊并推进对话
+type Manager struct {
+	client      *kook.Client
+	logger      kook.Logger
+	store       Store
+	cancelWords []string
+	now         func() time.Time
+
+	mu      sync.Mutex
+	wizards map[string]*Wizard
+
+	// locks 同一用户的消息按顺序处理
+	locks keylock.Locker
+}
+
+// Option 对话管理器配置选项
+type Option func(*Manager)
+
+// WithStore 设置进度存储，默认使用内存存储
+func WithStore(store Store) Option {
+	return func(m *Manager) {
+		m.store = store
+	}
+}
+
+// WithLogger 设置日志器
+func WithLogger(logger kook.Logger) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
+
+// WithCancelWords 设置用户取消对话的指令，默认为 "取消" 和 "/cancel"
+func WithCancelWords(words ...string) Option {
+	return func(m *Manager) {
+		m.cancelWords = words
+	}
+}
+
+// NewManager 创建对话管理器
+func NewManager(client *kook.Client, opts ...Option) *Manager {
+	m := &Manager{
+		client:      client,
+		cancelWords: []string{"取消", "/cancel"},
+		now:         time.Now,
+		wizards:     make(map[string]*Wizard),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.store == nil {
+		m.store = NewMemoryStore()
+	}
+	if m.logger == nil {
+		m.logger = client.Logger("wizard")
+	}
+	return m
+}
+
+// Register 注册对话
+func (m *Manager) Register(w *Wizard) error {
+	if err := w.validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.wizards[w.Name]; exists {
+		return fmt.Errorf("对话 %s 已注册", w.Name)
+	}
+	m.wizards[w.Name] = w
+	return nil
+}
+
+// Attach 在事件源上注册私聊消息处理器
+func (m *Manager) Attach(dispatcher kook.EventDispatcher) {
+	dispatcher.OnEvent(kook.EventTypeTextMessage, m.HandleEvent)
+	dispatcher.OnEvent(kook.EventTypeKMDMessage, m.HandleEvent)
+}
+
+// Start 为用户开始对话并发送第一个问题，已有进行中的对话时会被替换
+//
+// guildID 为发起对话的服务器，用于 Conversation.GrantRole，可以为空；
+// data 为预先填入的回答，可以为 nil。
+func (m *Manager) Start(userID, guildID, wizardName string, data map[string]string) error {
+	if userID == "" {
+		return fmt.Errorf("用户ID不能为空")
+	}
+	w, err := m.wizard(wizardName)
+	if err != nil {
+		return err
+	}
+
+	unlock := m.lock(userID)
+	defer unlock()
+
+	now := m.now()
+	state := &State{
+		UserID:    userID,
+		GuildID:   guildID,
+		Wizard:    w.Name,
+		Step:      w.Steps[0].Name,
+		Data:      make(map[string]string, len(data)),
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	for k, v := range data {
+		state.Data[k] = v
+	}
+	if w.Timeout > 0 {
+		state.ExpiresAt = now.Add(w.Timeout)
+	}
+
+	if err := m.store.Save(state); err != nil {
+		return fmt.Errorf("保存对话进度失败: %w", err)
+	}
+
+	m.logger.Info("开始对话", kook.F("wizard", w.Name), kook.F("user_id", userID))
+	return m.send(userID, w.Steps[0].prompt(state))
+}
+
+// Cancel 取消用户进行中的对话
+func (m *Manager) Cancel(userID string) error {
+	unlock := m.lock(userID)
+	defer unlock()
+
+	state, err := m.store.Load(userID)
+	if err != nil {
+		return fmt.Errorf("读取对话进度失败: %w", err)
+	}
+	if state == nil {
+		return nil
+	}
+	return m.finish(state, false)
+}
+
+// Active 获取用户进行中的对话，没有时返回 nil
+func (m *Manager) Active(userID string) (*State, error) {
+	state, err := m.store.Load(userID)
+	if err != nil || state == nil {
+		return nil, err
+	}
+	if state.Expired(m.now()) {
+		return nil, nil
+	}
+	return state, nil
+}
+
+// HandleEvent 处理私聊消息，推进发送者进行中的对话
+func (m *Manager) HandleEvent(event *kook.Event) {
+	if event.ChannelType != "PERSON" || event.IsSystemEvent() {
+		return
+	}
+	if err := m.Answer(event.AuthorID, event.Content); err != nil {
+		m.logger.Error("处理对话回答失败", kook.F("user_id", event.AuthorID), kook.ErrField(err))
+	}
+}
+
+// Answer 提交用户的回答，用户没有进行中的对话时忽略
+func (m *Manager) Answer(userID, answer string) error {
+	unlock := m.lock(userID)
+	defer unlock()
+
+	state, err := m.store.Load(userID)
+	if err != nil {
+		return fmt.Errorf("读取对话进度失败: %w", err)
+	}
+	if state == nil {
+		return nil
+	}
+
+	now := m.now()
+	if state.Expired(now) {
+		return m.expire(state)
+	}
+
+	w, err := m.wizard(state.Wizard)
+	if err != nil {
+		// 对话定义已移除，丢弃进度
+		m.store.Delete(userID)
+		return err
+	}
+
+	answer = strings.TrimSpace(answer)
+	for _, word := range m.cancelWords {
+		if strings.EqualFold(answer, word) {
+			return m.finish(state, false)
+		}
+	}
+
+	step, index := w.step(state.Step)
+	if step == nil {
+		m.store.Delete(userID)
+		return fmt.Errorf("对话 %s 不存在步骤 %s", w.Name, state.Step)
+	}
+
+	if step.Validate != nil {
+		if err := step.Validate(answer, state); err != nil {
+			return m.send(userID, err.Error()+"\n"+step.prompt(state))
+		}
+	}
+	state.Data[step.key()] = answer
+
+	next, err := w.next(index, answer, state)
+	if err != nil {
+		return err
+	}
+
+	state.UpdatedAt = now
+	if w.Timeout > 0 {
+		state.ExpiresAt = now.Add(w.Timeout)
+	}
+	if next == End {
+		return m.complete(w, step, state)
+	}
+
+	state.Step = next
+	if err := m.store.Save(state); err != nil {
+		return fmt.Errorf("保存对话进度失败: %w", err)
+	}
+
+	nextStep, _ := w.step(next)
+	return m.send(userID, nextStep.prompt(state))
+}
+
+// Sweep 结束所有已过期的对话，返回结束的数量
+func (m *Manager) Sweep() (int, error) {
+	states, err := m.store.List()
+	if err != nil {
+		return 0, fmt.Errorf("读取对话进度失败: %w", err)
+	}
+
+	now := m.now()
+	expired := 0
+	for _, listed := range states {
+		if !listed.Expired(now) {
+			continue
+		}
+
+		unlock := m.lock(listed.UserID)
+		// 加锁后重新读取，期间用户可能已经回答
+		state, err := m.store.Load(listed.UserID)
+		if err == nil && state != nil && state.Expired(now) {
+			if err := m.expire(state); err != nil {
+				m.logger.Warn("结束过期对话失败", kook.F("user_id", state.UserID), kook.ErrField(err))
+			}
+			expired++
+		}
+		unlock()
+	}
+	return expired, nil
+}
+
+// Run 按 interval 定期清理过期对话，直到 ctx 结束
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := m.Sweep(); err != nil {
+				m.logger.Error("清理过期对话失败", kook.ErrField(err))
+			}
+		}
+	}
+}
+
+// complete 对话完成，调用 OnComplete 成功后删除进度
+//
+// OnComplete 失败时保留进度并重新提出最后一步，用户再次回答后重试。
+func (m *Manager) complete(w *Wizard, last *Step, state *State) error {
+	if w.OnComplete != nil {
+		if err := w.OnComplete(m.conversation(state)); err != nil {
+			if saveErr := m.store.Save(state); saveErr != nil {
+				m.logger.Error("保存对话进度失败", kook.F("user_id", state.UserID), kook.ErrField(saveErr))
+			}
+			m.send(state.UserID, fmt.Sprintf("处理失败：%v\n%s", err, last.prompt(state)))
+			return fmt.Errorf("对话 %s 完成处理失败: %w", w.Name, err)
+		}
+	}
+
+	if err := m.store.Delete(state.UserID); err != nil {
+		return fmt.Errorf("删除对话进度失败: %w", err)
+	}
+	m.logger.Info("对话完成", kook.F("wizard", w.Name), kook.F("user_id", state.UserID))
+
+	if w.CompleteMessage != "" {
+		return m.send(state.UserID, w.CompleteMessage)
+	}
+	return nil
+}
+
+// expire 对话超时
+func (m *Manager) expire(state *State) error {
+	return m.finish(state, true)
+}
+
+// finish 取消或超时结束对话
+func (m *Manager) finish(state *State, timedOut bool) error {
+	if err := m.store.Delete(state.UserID); err != nil {
+		return fmt.Errorf("删除对话进度失败: %w", err)
+	}
+
+	message := "已取消"
+	w, err := m.wizard(state.Wizard)
+	if timedOut {
+		message = "对话已超时，请重新开始"
+		if err == nil && w.TimeoutMessage != "" {
+			message = w.TimeoutMessage
+		}
+	} else if err == nil && w.CancelMessage != "" {
+		message = w.CancelMessage
+	}
+
+	m.logger.Info("对话结束", kook.F("wizard", state.Wizard), kook.F("user_id", state.UserID),
+		kook.F("timed_out", timedOut))
+
+	if err == nil && w.OnCancel != nil {
+		w.OnCancel(m.conversation(state), timedOut)
+	}
+	return m.send(state.UserID, message)
+}
+
+// conversation 创建对话上下文
+func (m *Manager) conversation(state *State) *Conversation {
+	return &Conversation{Client: m.client, State: state, manager: m}
+}
+
+// wizard 根据名称获取对话定义
+func (m *Manager) wizard(name string) (*Wizard, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.wizards[name]
+	if !ok {
+		return nil, fmt.Errorf("对话 %s 未注册", name)
+	}
+	return w, nil
+}
+
+// send 向用户发送私聊消息
+func (m *Manager) send(userID, content string) error {
+	_, err := m.client.Message.SendMessage(kook.SendMessageParams{
+		Type:     "private",
+		TargetID: userID,
+		Content:  content,
+		MsgType:  kook.MessageTypeKMD,
+	})
+	if err != nil {
+		return fmt.Errorf("发送私聊消息失败: %w", err)
+	}
+	return nil
+}
+
+// lock 锁定单个用户，返回解锁函数
+func (m *Manager) lock(userID string) func() {
+	return m.locks.Lock(userID)
+}
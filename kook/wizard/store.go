@@ -0,0 +1,114 @@
+package wizard
+
+import (
+	"sync"
+
+	"kook-go-sdk/kook/internal/jsonfile"
+)
+
+// Store 对话进度存储
+//
+// 每个用户同一时间只有一个进度，以用户ID为键。
+type Store interface {
+	// Load 读取用户的对话进度，没有进度时返回 nil, nil
+	Load(userID string) (*State, error)
+	// Save 保存对话进度
+	Save(state *State) error
+	// Delete 删除用户的对话进度
+	Delete(userID string) error
+	// List 列出所有对话进度，用于清理过期对话
+	List() ([]*State, error)
+}
+
+// MemoryStore 内存存储，进程退出后进度丢失
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]*State
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]*State)}
+}
+
+// Load 读取对话进度
+func (s *MemoryStore) Load(userID string) (*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[userID]
+	if !ok {
+		return nil, nil
+	}
+	return copyState(state), nil
+}
+
+// Save 保存对话进度
+func (s *MemoryStore) Save(state *State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[state.UserID] = copyState(state)
+	return nil
+}
+
+// Delete 删除对话进度
+func (s *MemoryStore) Delete(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.states, userID)
+	return nil
+}
+
+// List 列出所有对话进度
+func (s *MemoryStore) List() ([]*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states := make([]*State, 0, len(s.states))
+	for _, state := range s.states {
+		states = append(states, copyState(state))
+	}
+	return states, nil
+}
+
+// copyState 复制进度，避免调用方修改存储中的数据
+func copyState(state *State) *State {
+	copied := *state
+	copied.Data = make(map[string]string, len(state.Data))
+	for k, v := range state.Data {
+		copied.Data[k] = v
+	}
+	return &copied
+}
+
+// FileStore 文件存储，所有进度以JSON格式保存在一个文件中，进程重启后仍可继续
+type FileStore struct {
+	file *jsonfile.Map[State]
+}
+
+// NewFileStore 创建文件存储
+func NewFileStore(path string) *FileStore {
+	return &FileStore{file: jsonfile.NewMap[State](path, "对话")}
+}
+
+// Load 读取对话进度
+func (s *FileStore) Load(userID string) (*State, error) {
+	return s.file.Load(userID)
+}
+
+// Save 保存对话进度
+func (s *FileStore) Save(state *State) error {
+	return s.file.Save(state.UserID, state)
+}
+
+// Delete 删除对话进度
+func (s *FileStore) Delete(userID string) error {
+	return s.file.Delete(userID)
+}
+
+// List 列出所有对话进度
+func (s *FileStore) List() ([]*State, error) {
+	return s.file.List()
+}
@@ -0,0 +1,220 @@
+// Package wizard 基于私聊的多步对话框架
+//
+// Wizard 描述一组按顺序或分支执行的问题，Manager 在用户私聊中逐步提问、校验回答，
+// 并将进度保存到可替换的 Store 中，进程重启后对话可以继续。全部回答完成后调用
+// OnComplete，通常在其中授予角色等。每个用户同时只能进行一个对话。
+package wizard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"kook-go-sdk/kook"
+)
+
+// End 作为 Step.Next 的返回值时结束对话
+const End = "__end__"
+
+// State 用户的对话进度
+type State struct {
+	UserID    string            `json:"user_id"`            // 用户ID
+	GuildID   string            `json:"guild_id,omitempty"` // 发起对话的服务器ID
+	Wizard    string            `json:"wizard"`             // 对话名称
+	Step      string            `json:"step"`               // 当前步骤
+	Data      map[string]string `json:"data"`               // 已收集的回答
+	StartedAt time.Time         `json:"started_at"`         // 开始时间
+	UpdatedAt time.Time         `json:"updated_at"`         // 最近一次回答的时间
+	ExpiresAt time.Time         `json:"expires_at"`         // 过期时间，零值表示不过期
+}
+
+// Expired 判断对话是否已过期
+func (s *State) Expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && now.After(s.ExpiresAt)
+}
+
+// Step 对话中的一个问题
+type Step struct {
+	Name string // 步骤名称，在对话内唯一
+	Key  string // 回答保存到 State.Data 的键，默认为 Name
+
+	Prompt     string              // 提问内容
+	PromptFunc func(*State) string // 根据已有回答生成提问，优先于 Prompt
+
+	// Validate 校验回答，返回的错误信息会发送给用户并重新提问
+	Validate func(answer string, state *State) error
+	// Next 根据回答决定下一步的名称，返回空字符串时进入下一个步骤，返回 End 时结束
+	Next func(answer string, state *State) string
+}
+
+// key 获取回答保存的键
+func (s *Step) key() string {
+	if s.Key != "" {
+		return s.Key
+	}
+	return s.Name
+}
+
+// prompt 获取提问内容
+func (s *Step) prompt(state *State) string {
+	if s.PromptFunc != nil {
+		return s.PromptFunc(state)
+	}
+	return s.Prompt
+}
+
+// Wizard 多步对话定义
+type Wizard struct {
+	Name    string        // 对话名称，在 Manager 中唯一
+	Steps   []Step        // 步骤，默认按顺序执行
+	Timeout time.Duration // 两次回答之间的最长间隔，零值表示不过期
+
+	CompleteMessage string // 完成时发送的消息，为空时不发送
+	CancelMessage   string // 用户取消时发送的消息，默认 "已取消"
+	TimeoutMessage  string // 超时时发送的消息，默认 "对话已超时，请重新开始"
+
+	// OnComplete 全部步骤完成后调用，返回错误时会将错误信息发送给用户并保留进度，
+	// 用户重新回答最后一步后再次调用
+	OnComplete func(conv *Conversation) error
+	// OnCancel 用户取消或对话超时后调用，可选
+	OnCancel func(conv *Conversation, timedOut bool)
+}
+
+// validate 检查对话定义
+func (w *Wizard) validate() error {
+	if w.Name == "" {
+		return fmt.Errorf("对话名称不能为空")
+	}
+	if len(w.Steps) == 0 {
+		return fmt.Errorf("对话 %s 没有步骤", w.Name)
+	}
+
+	names := make(map[string]bool, len(w.Steps))
+	for i, step := range w.Steps {
+		if step.Name == "" || step.Name == End {
+			return fmt.Errorf("对话 %s 的第 %d 个步骤名称无效", w.Name, i+1)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("对话 %s 的步骤 %s 重复", w.Name, step.Name)
+		}
+		if step.Prompt == "" && step.PromptFunc == nil {
+			return fmt.Errorf("对话 %s 的步骤 %s 没有提问内容", w.Name, step.Name)
+		}
+		names[step.Name] = true
+	}
+	return nil
+}
+
+// step 根据名称查找步骤及其位置
+func (w *Wizard) step(name string) (*Step, int) {
+	for i := range w.Steps {
+		if w.Steps[i].Name == name {
+			return &w.Steps[i], i
+		}
+	}
+	return nil, -1
+}
+
+// next 计算回答之后的下一步，返回 End 表示结束
+func (w *Wizard) next(index int, answer string, state *State) (string, error) {
+	step := &w.Steps[index]
+	if step.Next != nil {
+		if name := step.Next(answer, state); name != "" {
+			if name != End {
+				if next, _ := w.step(name); next == nil {
+					return "", fmt.Errorf("对话 %s 的步骤 %s 跳转到不存在的步骤 %s", w.Name, step.Name, name)
+				}
+			}
+			return name, nil
+		}
+	}
+	if index+1 < len(w.Steps) {
+		return w.Steps[index+1].Name, nil
+	}
+	return End, nil
+}
+
+// Conversation 对话完成或取消时的上下文
+type Conversation struct {
+	Client *kook.Client // API客户端
+	State  *State       // 对话进度与回答
+
+	manager *Manager
+}
+
+// Reply 向用户发送私聊消息
+func (c *Conversation) Reply(content string) error {
+	return c.manager.send(c.State.UserID, content)
+}
+
+// Answer 获取指定键的回答
+func (c *Conversation) Answer(key string) string {
+	return c.State.Data[key]
+}
+
+// GrantRole 在发起对话的服务器中为用户授予角色
+func (c *Conversation) GrantRole(roleID int) error {
+	if c.State.GuildID == "" {
+		return fmt.Errorf("对话没有关联服务器")
+	}
+	_, err := c.Client.Role.GrantRole(c.State.GuildID, c.State.UserID, roleID)
+	return err
+}
+
+// Required 要求回答非空
+func Required(message string) func(string, *State) error {
+	return func(answer string, _ *State) error {
+		if strings.TrimSpace(answer) == "" {
+			return fmt.Errorf("%s", message)
+		}
+		return nil
+	}
+}
+
+// OneOf 要求回答为给定选项之一，忽略大小写
+func OneOf(options ...string) func(string, *State) error {
+	return func(answer string, _ *State) error {
+		answer = strings.TrimSpace(answer)
+		for _, option := range options {
+			if strings.EqualFold(answer, option) {
+				return nil
+			}
+		}
+		return fmt.Errorf("请回答以下选项之一：%s", strings.Join(options, "、"))
+	}
+}
+
+// IntRange 要求回答为 [min, max] 范围内的整数
+func IntRange(min, max int) func(string, *State) error {
+	return func(answer string, _ *State) error {
+		n, err := strconv.Atoi(strings.TrimSpace(answer))
+		if err != nil || n < min || n > max {
+			return fmt.Errorf("请输入 %d 到 %d 之间的整数", min, max)
+		}
+		return nil
+	}
+}
+
+// MaxLength 要求回答不超过 n 个字符
+func MaxLength(n int) func(string, *State) error {
+	return func(answer string, _ *State) error {
+		if len([]rune(answer)) > n {
+			return fmt.Errorf("回答不能超过 %d 个字符", n)
+		}
+		return nil
+	}
+}
+
+// Branch 根据回答选择下一步，回答不在 routes 中时进入下一个步骤，匹配时忽略大小写
+func Branch(routes map[string]string) func(string, *State) string {
+	return func(answer string, _ *State) string {
+		answer = strings.TrimSpace(answer)
+		for value, step := range routes {
+			if strings.EqualFold(answer, value) {
+				return step
+			}
+		}
+		return ""
+	}
+}